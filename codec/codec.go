@@ -0,0 +1,60 @@
+// Package codec converts tasks to and from the file formats used for
+// import and export.
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"example.com/todo-rest-api/models"
+)
+
+// ErrInvalidFile is returned when a file cannot be read as a whole, as
+// opposed to single rows that fail to parse.
+var ErrInvalidFile = errors.New("invalid file")
+
+// Row is one decoded task of an imported file. Err is set instead when the
+// row could not be parsed.
+type Row struct {
+	// Number is the row's position: the line of a CSV file or the index,
+	// counting from 1, in a JSON array.
+	Number int
+	Task   models.Task
+	Err    error
+}
+
+// FieldError reports a field that could not be parsed.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return "invalid " + e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ReadJSON decodes a JSON array of tasks. Elements that are not valid
+// tasks become rows with Err set.
+func ReadJSON(r io.Reader) ([]Row, error) {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&elements); err != nil {
+		return nil, ErrInvalidFile
+	}
+
+	rows := make([]Row, 0, len(elements))
+	for i, element := range elements {
+		row := Row{Number: i + 1}
+		if err := json.Unmarshal(element, &row.Task); err != nil {
+			row.Err = err
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,295 @@
+package codec
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// CSVHeader lists the columns written by WriteCSV. Tags, projects and
+// extensions are written as JSON so that any value survives the round
+// trip; times use RFC 3339.
+var CSVHeader = []string{
+	"id",
+	"description",
+	"parentId",
+	"listId",
+	"completed",
+	"completedAt",
+	"dueAt",
+	"priority",
+	"tags",
+	"projects",
+	"extensions",
+	"recurrenceRule",
+	"recurrenceTimeZone",
+	"recurrenceStart",
+	"recurrenceMode",
+	"uid",
+}
+
+// formulaPrefixes start the cells that spreadsheets evaluate as formulas.
+// Such cells are written after a ', which spreadsheets show as text and
+// ReadCSV removes again.
+const formulaPrefixes = "=+-@\t\r'"
+
+// WriteCSV writes tasks as CSV, starting with CSVHeader. Cells that a
+// spreadsheet would take for a formula are escaped.
+func WriteCSV(w io.Writer, tasks []models.Task) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(CSVHeader); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		record, err := csvRecord(task)
+		if err != nil {
+			return err
+		}
+		for i, cell := range record {
+			record[i] = escapeCell(cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvRecord(task models.Task) ([]string, error) {
+	tags, err := formatJSON(task.Tags, len(task.Tags))
+	if err != nil {
+		return nil, err
+	}
+	projects, err := formatJSON(task.Projects, len(task.Projects))
+	if err != nil {
+		return nil, err
+	}
+	extensions, err := formatJSON(task.Extensions, len(task.Extensions))
+	if err != nil {
+		return nil, err
+	}
+
+	var rule, timeZone, start, mode string
+	if task.Recurrence != nil {
+		rule = task.Recurrence.Rule
+		timeZone = task.Recurrence.TimeZone
+		start = formatTime(&task.Recurrence.Start)
+		mode = task.Recurrence.Mode
+	}
+
+	priority := ""
+	if task.Priority != 0 {
+		priority = strconv.Itoa(task.Priority)
+	}
+
+	return []string{
+		formatID(&task.Id),
+		task.Description,
+		formatID(task.ParentId),
+		formatID(task.ListId),
+		strconv.FormatBool(task.Completed),
+		formatTime(task.CompletedAt),
+		formatTime(task.DueAt),
+		priority,
+		tags,
+		projects,
+		extensions,
+		rule,
+		timeZone,
+		start,
+		mode,
+		task.Uid,
+	}, nil
+}
+
+// ReadCSV decodes CSV written by WriteCSV. Columns are matched by the
+// names in the header, which must include description; unknown columns
+// are ignored and missing ones left empty. Records that do not parse
+// become rows with Err set.
+func ReadCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil || !slices.Contains(header, "description") {
+		return nil, ErrInvalidFile
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var row Row
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			row.Number = parseErr.Line
+			row.Err = err
+		} else {
+			row.Number, _ = reader.FieldPos(0)
+			row.Task, row.Err = parseRecord(func(name string) string {
+				if i, ok := columns[name]; ok && i < len(record) {
+					return unescapeCell(record[i])
+				}
+				return ""
+			})
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// escapeCell prefixes cell with ' when it starts like a formula. A leading
+// ' is escaped too, so that unescapeCell restores any value.
+func escapeCell(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeCell undoes escapeCell.
+func unescapeCell(cell string) string {
+	return strings.TrimPrefix(cell, "'")
+}
+
+func parseRecord(field func(name string) string) (models.Task, error) {
+	task := models.Task{Description: field("description"), Uid: field("uid")}
+	var err error
+
+	if value := field("id"); value != "" {
+		if task.Id, err = bson.ObjectIDFromHex(value); err != nil {
+			return task, &FieldError{Field: "id", Err: err}
+		}
+	}
+
+	if task.ParentId, err = parseID(field("parentId")); err != nil {
+		return task, &FieldError{Field: "parentId", Err: err}
+	}
+
+	if task.ListId, err = parseID(field("listId")); err != nil {
+		return task, &FieldError{Field: "listId", Err: err}
+	}
+
+	if value := field("completed"); value != "" {
+		if task.Completed, err = strconv.ParseBool(value); err != nil {
+			return task, &FieldError{Field: "completed", Err: err}
+		}
+	}
+
+	if task.CompletedAt, err = parseTime(field("completedAt")); err != nil {
+		return task, &FieldError{Field: "completedAt", Err: err}
+	}
+
+	if task.DueAt, err = parseTime(field("dueAt")); err != nil {
+		return task, &FieldError{Field: "dueAt", Err: err}
+	}
+
+	if value := field("priority"); value != "" {
+		if task.Priority, err = strconv.Atoi(value); err != nil {
+			return task, &FieldError{Field: "priority", Err: err}
+		}
+	}
+
+	lists := []struct {
+		name   string
+		target any
+	}{{"tags", &task.Tags}, {"projects", &task.Projects}, {"extensions", &task.Extensions}}
+	for _, list := range lists {
+		if value := field(list.name); value != "" {
+			if err := json.Unmarshal([]byte(value), list.target); err != nil {
+				return task, &FieldError{Field: list.name, Err: err}
+			}
+		}
+	}
+
+	recurrence := models.Recurrence{
+		Rule:     field("recurrenceRule"),
+		TimeZone: field("recurrenceTimeZone"),
+		Mode:     field("recurrenceMode"),
+	}
+	start, err := parseTime(field("recurrenceStart"))
+	if err != nil {
+		return task, &FieldError{Field: "recurrenceStart", Err: err}
+	}
+	if start != nil {
+		recurrence.Start = *start
+	}
+	if recurrence != (models.Recurrence{}) {
+		task.Recurrence = &recurrence
+	}
+
+	return task, nil
+}
+
+// formatJSON encodes a list of n elements, or nothing when it is empty.
+func formatJSON(value any, n int) (string, error) {
+	if n == 0 {
+		return "", nil
+	}
+
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
+
+func formatID(id *bson.ObjectID) string {
+	if id == nil || id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+func parseID(value string) (*bson.ObjectID, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := bson.ObjectIDFromHex(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,112 @@
+package codec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	parent := bson.NewObjectID()
+	list := bson.NewObjectID()
+	due := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	done := due.Add(-time.Hour)
+
+	tasks := []models.Task{
+		{
+			Id:          bson.NewObjectID(),
+			Description: `Buy "oat" milk, 2 litres`,
+			ParentId:    &parent,
+			ListId:      &list,
+			Completed:   true,
+			CompletedAt: &done,
+			DueAt:       &due,
+			Priority:    2,
+			Tags:        []string{"errand", "a;b,c"},
+			Projects:    []string{"Home"},
+			Extensions:  []models.Extension{{Key: "rec", Value: "1w"}},
+			Recurrence: &models.Recurrence{
+				Rule:     "FREQ=WEEKLY;BYDAY=MO",
+				TimeZone: "Europe/Berlin",
+				Start:    due,
+				Mode:     models.RecurrenceRoll,
+			},
+			Uid: "4f1c@example.com",
+		},
+		{Id: bson.NewObjectID(), Description: "Plain"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, tasks))
+
+	rows, err := ReadCSV(&buf)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, 2, rows[0].Number)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, tasks[0], rows[0].Task)
+	assert.Equal(t, tasks[1], rows[1].Task)
+}
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	tasks := []models.Task{
+		{Description: `=HYPERLINK("http://example.com")`, Tags: []string{"x"}},
+		{Description: "+1", Uid: "@home"},
+		{Description: "-minus"},
+		{Description: "'quoted"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, tasks))
+
+	lines := strings.Split(buf.String(), "\n")
+	assert.True(t, strings.HasPrefix(lines[1], `,"'=HYPERLINK(""http://example.com"")",`), lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], ",'+1,"), lines[2])
+	assert.True(t, strings.HasSuffix(lines[2], ",'@home"), lines[2])
+	assert.True(t, strings.HasPrefix(lines[3], ",'-minus,"), lines[3])
+	assert.True(t, strings.HasPrefix(lines[4], ",''quoted,"), lines[4])
+
+	rows, err := ReadCSV(&buf)
+	require.NoError(t, err)
+	require.Len(t, rows, len(tasks))
+	for i, task := range tasks {
+		assert.Equal(t, task, rows[i].Task)
+	}
+}
+
+func TestReadCSVRejectsRows(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader("description,dueAt,extra\nFine,,x\nLate,tomorrow,\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "Fine", rows[0].Task.Description)
+
+	var fieldErr *FieldError
+	require.ErrorAs(t, rows[1].Err, &fieldErr)
+	assert.Equal(t, "dueAt", fieldErr.Field)
+	assert.Equal(t, 3, rows[1].Number)
+
+	_, err = ReadCSV(strings.NewReader("id,title\n1,Nope\n"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+}
+
+func TestReadJSON(t *testing.T) {
+	rows, err := ReadJSON(strings.NewReader(`[{"description": "Fine", "tags": ["x"]}, {"description": 3}]`))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, []string{"x"}, rows[0].Task.Tags)
+	assert.Error(t, rows[1].Err)
+	assert.Equal(t, 2, rows[1].Number)
+
+	_, err = ReadJSON(strings.NewReader(`{"description": "Not an array"}`))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+}
@@ -0,0 +1,223 @@
+package codec
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// icalProdID identifies this server as the producer of calendars.
+	icalProdID = "-//todo-rest-api//Tasks//EN"
+	// uidDomain makes UIDs globally unique, as RFC 5545 recommends.
+	uidDomain = "todo-rest-api"
+	// icalTime is the UTC date-time format of RFC 5545, icalLocalTime the
+	// local one and icalDate the date-only one.
+	icalTime      = "20060102T150405Z"
+	icalLocalTime = "20060102T150405"
+	icalDate      = "20060102"
+	// maxLineOctets is where content lines are folded.
+	maxLineOctets = 75
+)
+
+// UID returns the iCalendar UID derived from a task id.
+func UID(id bson.ObjectID) string {
+	return id.Hex() + "@" + uidDomain
+}
+
+// TaskUID returns the UID of a task: the one a calendar client created it
+// with, or else the one derived from its id.
+func TaskUID(task models.Task) string {
+	if task.Uid != "" {
+		return task.Uid
+	}
+	return UID(task.Id)
+}
+
+// ParseUID returns the task id a UID was derived from, if it was.
+func ParseUID(uid string) (bson.ObjectID, bool) {
+	hex, ok := strings.CutSuffix(uid, "@"+uidDomain)
+	if !ok {
+		return bson.ObjectID{}, false
+	}
+
+	id, err := bson.ObjectIDFromHex(hex)
+	return id, err == nil
+}
+
+// WriteICS writes tasks as an RFC 5545 calendar of VTODO components. The
+// output only depends on the tasks, so it can be hashed into an ETag:
+// DTSTAMP is the creation time from the id. parentUIDs holds the UIDs of
+// parent tasks that have their own; other parents get derived UIDs.
+func WriteICS(w io.Writer, tasks []models.Task, parentUIDs map[bson.ObjectID]string) error {
+	out := bufio.NewWriter(w)
+
+	writeLine(out, "BEGIN:VCALENDAR")
+	writeLine(out, "VERSION:2.0")
+	writeLine(out, "PRODID:"+icalProdID)
+
+	// Every TZID needs its VTIMEZONE, covering the times written with it.
+	type span struct{ first, last time.Time }
+	zones := map[string]*span{}
+	var names []string
+	for _, task := range tasks {
+		loc, start, ok := recurrenceStart(task)
+		if !ok || loc == nil {
+			continue
+		}
+		if zone, seen := zones[loc.String()]; seen {
+			zone.first = minTime(zone.first, start)
+			zone.last = maxTime(zone.last, start)
+			continue
+		}
+		zones[loc.String()] = &span{start, start}
+		names = append(names, loc.String())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		loc, _ := time.LoadLocation(name)
+		writeVTIMEZONE(out, loc, zones[name].first, zones[name].last)
+	}
+
+	for _, task := range tasks {
+		writeVTODO(out, task, parentUIDs)
+	}
+	writeLine(out, "END:VCALENDAR")
+
+	return out.Flush()
+}
+
+func writeVTODO(out *bufio.Writer, task models.Task, parentUIDs map[bson.ObjectID]string) {
+	writeLine(out, "BEGIN:VTODO")
+	writeLine(out, "UID:"+escapeText(TaskUID(task)))
+	writeLine(out, "DTSTAMP:"+task.Id.Timestamp().UTC().Format(icalTime))
+	writeLine(out, "SUMMARY:"+escapeText(task.Description))
+
+	loc, start, recurring := recurrenceStart(task)
+	if task.DueAt != nil && !recurring {
+		writeLine(out, "DUE:"+task.DueAt.UTC().Format(icalTime))
+	}
+
+	if task.Completed {
+		writeLine(out, "STATUS:COMPLETED")
+		if task.CompletedAt != nil {
+			writeLine(out, "COMPLETED:"+task.CompletedAt.UTC().Format(icalTime))
+		}
+	} else {
+		writeLine(out, "STATUS:NEEDS-ACTION")
+	}
+
+	if task.Priority > 0 {
+		// iCalendar priorities run from 1 (highest) to 9 (lowest).
+		writeLine(out, "PRIORITY:"+strconv.Itoa(min(task.Priority, 9)))
+	}
+
+	if len(task.Tags) > 0 {
+		categories := make([]string, len(task.Tags))
+		for i, tag := range task.Tags {
+			categories[i] = escapeText(tag)
+		}
+		writeLine(out, "CATEGORIES:"+strings.Join(categories, ","))
+	}
+
+	if task.ParentId != nil {
+		uid, ok := parentUIDs[*task.ParentId]
+		if !ok {
+			uid = UID(*task.ParentId)
+		}
+		writeLine(out, "RELATED-TO:"+escapeText(uid))
+	}
+
+	if recurring {
+		// Clients repeat DUE - DTSTART for every instance, so both are the
+		// current occurrence rather than DTSTART being the first one.
+		value := ":" + start.UTC().Format(icalTime)
+		if loc != nil {
+			value = ";TZID=" + loc.String() + ":" + start.In(loc).Format(icalLocalTime)
+		}
+		writeLine(out, "DTSTART"+value)
+		if task.DueAt != nil {
+			writeLine(out, "DUE"+value)
+		}
+		rule := task.Recurrence.From(start).Rule
+		writeLine(out, "RRULE:"+strings.TrimPrefix(rule, "RRULE:"))
+	}
+
+	writeLine(out, "END:VTODO")
+}
+
+// recurrenceStart returns the DTSTART of a recurring task: its current
+// occurrence, or the start of the series when it has no due date. The
+// location is that of its TZID, or nil for UTC.
+func recurrenceStart(task models.Task) (*time.Location, time.Time, bool) {
+	if task.Recurrence == nil {
+		return nil, time.Time{}, false
+	}
+
+	start := task.Recurrence.Start
+	if task.DueAt != nil {
+		start = *task.DueAt
+	}
+
+	loc, err := time.LoadLocation(task.Recurrence.TimeZone)
+	if err != nil || loc == time.UTC {
+		return nil, start, true
+	}
+	return loc, start, true
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// escapeText escapes a TEXT value as RFC 5545 section 3.3.11 requires.
+func escapeText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(text)
+}
+
+// writeLine writes a content line, folded so that no line exceeds 75
+// octets without splitting a UTF-8 sequence.
+func writeLine(out *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		out.WriteString(line[:cut])
+		out.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts.
+		limit = maxLineOctets - 1
+	}
+
+	out.WriteString(line)
+	out.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,275 @@
+package codec
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/todo-rest-api/models"
+)
+
+// Todo is a VTODO read from a calendar. The UIDs of the task and of its
+// parent are left for the caller to resolve against storage.
+type Todo struct {
+	Task   models.Task
+	UID    string
+	Parent string
+}
+
+// property is one content line: NAME;PARAM=value:VALUE.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// ReadICS decodes the VTODO components of an RFC 5545 calendar. Other
+// components, and properties without a Task field, are ignored.
+func ReadICS(r io.Reader) ([]Todo, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var todos []Todo
+	var current []property
+	// depth counts the components open inside VCALENDAR, so that the
+	// properties of e.g. a VALARM do not leak into its VTODO.
+	depth, inCalendar, inTodo := 0, false, false
+
+	for _, line := range lines {
+		prop, ok := parseProperty(line)
+		if !ok {
+			return nil, ErrInvalidFile
+		}
+
+		switch {
+		case prop.name == "BEGIN" && !inCalendar:
+			if !strings.EqualFold(prop.value, "VCALENDAR") {
+				return nil, ErrInvalidFile
+			}
+			inCalendar = true
+		case prop.name == "BEGIN":
+			depth++
+			if depth == 1 && strings.EqualFold(prop.value, "VTODO") {
+				inTodo = true
+				current = nil
+			}
+		case prop.name == "END" && depth == 0:
+			inCalendar = false
+		case prop.name == "END":
+			if depth == 1 && inTodo {
+				todo, err := parseTodo(current)
+				if err != nil {
+					return nil, err
+				}
+				todos = append(todos, todo)
+				inTodo = false
+			}
+			depth--
+		case inTodo && depth == 1:
+			current = append(current, prop)
+		}
+	}
+
+	if inCalendar || depth != 0 {
+		return nil, ErrInvalidFile
+	}
+
+	return todos, nil
+}
+
+func parseTodo(props []property) (Todo, error) {
+	var todo Todo
+	var status string
+	var start *time.Time
+	var rule, timeZone string
+
+	for _, prop := range props {
+		var err error
+
+		switch prop.name {
+		case "UID":
+			todo.UID = unescapeText(prop.value)
+		case "SUMMARY":
+			todo.Task.Description = unescapeText(prop.value)
+		case "DUE":
+			todo.Task.DueAt, err = parseICalTime(prop)
+		case "DTSTART":
+			start, err = parseICalTime(prop)
+			timeZone = prop.params["TZID"]
+		case "COMPLETED":
+			todo.Task.CompletedAt, err = parseICalTime(prop)
+		case "STATUS":
+			status = strings.ToUpper(prop.value)
+		case "PRIORITY":
+			todo.Task.Priority, err = strconv.Atoi(prop.value)
+			if err == nil && (todo.Task.Priority < 0 || todo.Task.Priority > 9) {
+				err = errors.New("out of range")
+			}
+		case "CATEGORIES":
+			for _, tag := range splitText(prop.value) {
+				todo.Task.Tags = append(todo.Task.Tags, unescapeText(tag))
+			}
+		case "RELATED-TO":
+			if reltype := prop.params["RELTYPE"]; reltype == "" || strings.EqualFold(reltype, "PARENT") {
+				todo.Parent = unescapeText(prop.value)
+			}
+		case "RRULE":
+			rule = prop.value
+		}
+
+		if err != nil {
+			return todo, &FieldError{Field: prop.name, Err: err}
+		}
+	}
+
+	todo.Task.Completed = status == "COMPLETED" || (status == "" && todo.Task.CompletedAt != nil)
+	if !todo.Task.Completed {
+		todo.Task.CompletedAt = nil
+	}
+
+	if rule != "" {
+		todo.Task.Recurrence = &models.Recurrence{Rule: rule, TimeZone: timeZone}
+		if start != nil {
+			todo.Task.Recurrence.Start = *start
+		}
+		if todo.Task.DueAt == nil {
+			todo.Task.DueAt = start
+		}
+	}
+
+	return todo, nil
+}
+
+// unfold reads the content lines of r, joining folded ones.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line into its name, parameters and
+// value. Parameter values may be quoted to contain ";", ":" or ",".
+func parseProperty(line string) (property, bool) {
+	prop := property{params: map[string]string{}}
+
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return prop, false
+	}
+	prop.name = strings.ToUpper(line[:end])
+	rest := line[end:]
+
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return prop, false
+		}
+		key := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return prop, false
+			}
+			value = rest[1 : closing+1]
+			rest = rest[closing+2:]
+		} else {
+			stop := strings.IndexAny(rest, ";:")
+			if stop < 0 {
+				return prop, false
+			}
+			value = rest[:stop]
+			rest = rest[stop:]
+		}
+		prop.params[key] = value
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return prop, false
+	}
+	prop.value = rest[1:]
+
+	return prop, true
+}
+
+// parseICalTime reads a DATE or DATE-TIME value. Times with an unknown
+// TZID, and floating times, are taken as UTC; dates as midnight UTC.
+func parseICalTime(prop property) (*time.Time, error) {
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(prop.value) == len(icalDate) {
+		t, err := time.Parse(icalDate, prop.value)
+		return &t, err
+	}
+
+	if strings.HasSuffix(prop.value, "Z") {
+		t, err := time.Parse(icalTime, prop.value)
+		return &t, err
+	}
+
+	loc := time.UTC
+	if zone, err := time.LoadLocation(prop.params["TZID"]); err == nil {
+		loc = zone
+	}
+
+	t, err := time.ParseInLocation(icalLocalTime, prop.value, loc)
+	return &t, err
+}
+
+// splitText splits a list of TEXT values on the commas that are not
+// escaped.
+func splitText(value string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// unescapeText reverses escapeText.
+func unescapeText(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i == len(text)-1 {
+			b.WriteByte(text[i])
+			continue
+		}
+
+		i++
+		switch text[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
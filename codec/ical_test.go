@@ -0,0 +1,167 @@
+package codec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestWriteICS(t *testing.T) {
+	now := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	due := time.Date(2025, 3, 11, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	parent := bson.NewObjectID()
+
+	tasks := []models.Task{
+		{Id: bson.NewObjectIDFromTimestamp(now), Description: "Call Bob; bring notes, slides\nand snacks", DueAt: &due, Priority: 12, Tags: []string{"work", "q1,q2"}, ParentId: &parent},
+		{Id: bson.NewObjectID(), Description: "Done", Completed: true, CompletedAt: &now},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteICS(&buf, tasks, map[bson.ObjectID]string{parent: "parent@example.com"}))
+	ics := buf.String()
+
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 2, strings.Count(ics, "BEGIN:VTODO\r\n"))
+
+	assert.Contains(t, ics, "UID:"+tasks[0].Id.Hex()+"@todo-rest-api\r\n")
+	assert.Contains(t, ics, "DTSTAMP:20250310T080000Z\r\n")
+	assert.Contains(t, ics, `SUMMARY:Call Bob\; bring notes\, slides\nand snacks`+"\r\n")
+	assert.Contains(t, ics, "DUE:20250311T083000Z\r\n")
+	assert.Contains(t, ics, "STATUS:NEEDS-ACTION\r\n")
+	assert.Contains(t, ics, "PRIORITY:9\r\n")
+	assert.Contains(t, ics, `CATEGORIES:work,q1\,q2`+"\r\n")
+	assert.Contains(t, ics, "RELATED-TO:parent@example.com\r\n")
+	assert.Contains(t, ics, "STATUS:COMPLETED\r\nCOMPLETED:20250310T080000Z\r\n")
+}
+
+func TestWriteICSRecurrence(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, berlin)
+	due := time.Date(2025, 4, 7, 9, 0, 0, 0, berlin)
+
+	tasks := []models.Task{
+		{Id: bson.NewObjectID(), Description: "Standup", DueAt: &due, Recurrence: &models.Recurrence{Rule: "FREQ=WEEKLY;BYDAY=MO;COUNT=10", TimeZone: "Europe/Berlin", Start: start}},
+		{Id: bson.NewObjectID(), Description: "Water plants", DueAt: &due, Recurrence: &models.Recurrence{Rule: "FREQ=DAILY", TimeZone: "Europe/Berlin", Start: due}},
+		{Id: bson.NewObjectID(), Description: "Backup", DueAt: &due, Recurrence: &models.Recurrence{Rule: "FREQ=DAILY", Start: due}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteICS(&buf, tasks, nil))
+	ics := buf.String()
+
+	// One VTIMEZONE per TZID, with the yearly changes of summer time.
+	assert.Equal(t, 1, strings.Count(ics, "BEGIN:VTIMEZONE\r\n"))
+	assert.Contains(t, ics, "BEGIN:VTIMEZONE\r\nTZID:Europe/Berlin\r\n")
+	assert.Contains(t, ics, "BEGIN:DAYLIGHT\r\nDTSTART:20240331T020000\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\n")
+	assert.Contains(t, ics, "BEGIN:STANDARD\r\nDTSTART:20241027T030000\r\nRRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\n")
+
+	// DTSTART and DUE are the current occurrence, and the occurrences left
+	// stay the same.
+	assert.Contains(t, ics, "DTSTART;TZID=Europe/Berlin:20250407T090000\r\nDUE;TZID=Europe/Berlin:20250407T090000\r\nRRULE:FREQ=WEEKLY;BYDAY=MO;UNTIL=20250505T070000Z\r\n")
+	assert.Contains(t, ics, "DTSTART:20250407T070000Z\r\nDUE:20250407T070000Z\r\nRRULE:FREQ=DAILY\r\n")
+	assert.NotContains(t, ics, "20250303")
+
+	fixed, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	buf.Reset()
+	tasks[0].Recurrence.TimeZone = fixed.String()
+	require.NoError(t, WriteICS(&buf, tasks[:1], nil))
+	assert.Contains(t, buf.String(), "BEGIN:STANDARD\r\nDTSTART:20240101T090000\r\nTZOFFSETFROM:+0900\r\nTZOFFSETTO:+0900\r\n")
+}
+
+func TestWriteLineFolds(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteICS(&buf, []models.Task{{Description: strings.Repeat("é", 100)}}, nil))
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, line)
+	}
+	assert.Contains(t, buf.String(), "\r\n é")
+}
+
+func TestICSRoundTrip(t *testing.T) {
+	due := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	done := due.Add(-time.Hour)
+	parent := bson.NewObjectID()
+
+	task := models.Task{
+		Id:          bson.NewObjectID(),
+		Uid:         "client-uid@example.com",
+		Description: "Weekly review; with notes, and\nmore",
+		ParentId:    &parent,
+		Completed:   true,
+		CompletedAt: &done,
+		DueAt:       &due,
+		Priority:    3,
+		Tags:        []string{"work", "a,b"},
+		Recurrence: &models.Recurrence{
+			Rule:     "FREQ=WEEKLY;BYDAY=MO",
+			TimeZone: "Europe/Berlin",
+			Start:    due,
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteICS(&buf, []models.Task{task}, nil))
+
+	todos, err := ReadICS(&buf)
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+
+	todo := todos[0]
+	assert.Equal(t, "client-uid@example.com", todo.UID)
+	assert.Equal(t, UID(parent), todo.Parent)
+	assert.Equal(t, task.Description, todo.Task.Description)
+	assert.True(t, todo.Task.Completed)
+	assert.True(t, done.Equal(*todo.Task.CompletedAt))
+	assert.True(t, due.Equal(*todo.Task.DueAt))
+	assert.Equal(t, 3, todo.Task.Priority)
+	assert.Equal(t, task.Tags, todo.Task.Tags)
+	assert.Equal(t, task.Recurrence.Rule, todo.Task.Recurrence.Rule)
+	assert.Equal(t, "Europe/Berlin", todo.Task.Recurrence.TimeZone)
+	assert.True(t, due.Equal(todo.Task.Recurrence.Start))
+
+	id, ok := ParseUID(UID(parent))
+	assert.True(t, ok)
+	assert.Equal(t, parent, id)
+	_, ok = ParseUID(todo.UID)
+	assert.False(t, ok)
+}
+
+func TestReadICS(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\nVERSION:2.0\n" +
+		"BEGIN:VEVENT\nUID:event\nSUMMARY:Not a task\nEND:VEVENT\n" +
+		"BEGIN:VTODO\nUID:todo-1\nSUMMARY:Pay\n  rent\nDUE;VALUE=DATE:20250301\n" +
+		"STATUS:IN-PROCESS\nCOMPLETED:20250301T100000Z\n" +
+		"RELATED-TO;RELTYPE=SIBLING:other\n" +
+		"BEGIN:VALARM\nACTION:DISPLAY\nSUMMARY:Alarm\nEND:VALARM\n" +
+		"DTSTART;TZID=\"America/New_York\":20250301T090000\nX-CUSTOM;X-P=\"a;b:c\":kept out\n" +
+		"END:VTODO\nEND:VCALENDAR\n"
+
+	todos, err := ReadICS(strings.NewReader(ics))
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+
+	task := todos[0].Task
+	assert.Equal(t, "Pay rent", task.Description)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), *task.DueAt)
+	assert.False(t, task.Completed)
+	assert.Nil(t, task.CompletedAt)
+	assert.Empty(t, todos[0].Parent)
+
+	_, err = ReadICS(strings.NewReader("BEGIN:VTODO\nEND:VTODO\n"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	_, err = ReadICS(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VTODO\nDUE:tomorrow\nEND:VTODO\nEND:VCALENDAR\n"))
+	var fieldErr *FieldError
+	assert.ErrorAs(t, err, &fieldErr)
+}
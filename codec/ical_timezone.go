@@ -0,0 +1,164 @@
+package codec
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// icalWeekdays are the BYDAY names of time.Weekday.
+var icalWeekdays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// tzTransition is a change of the UTC offset of a time zone.
+type tzTransition struct {
+	at       time.Time
+	from, to int
+	name     string
+	dst      bool
+}
+
+// onset is when t takes effect on the wall clock it replaces, which is
+// how observances give their DTSTART.
+func (t tzTransition) onset() time.Time {
+	return t.at.Add(time.Duration(t.from) * time.Second).UTC()
+}
+
+// rule is the yearly RRULE that t follows, e.g. the last Sunday of March
+// at 02:00.
+func (t tzTransition) rule() string {
+	onset := t.onset()
+	week := (onset.Day()-1)/7 + 1
+	if onset.AddDate(0, 0, 7).Month() != onset.Month() {
+		week = -1
+	}
+
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", onset.Month(), week, icalWeekdays[onset.Weekday()])
+}
+
+// follows reports whether t repeats prev a year later.
+func (t tzTransition) follows(prev tzTransition) bool {
+	return t.onset().Year() == prev.onset().Year()+1 &&
+		t.onset().Format("150405") == prev.onset().Format("150405") &&
+		t.rule() == prev.rule() &&
+		t.from == prev.from && t.to == prev.to && t.name == prev.name && t.dst == prev.dst
+}
+
+// writeVTIMEZONE writes the VTIMEZONE of loc, which RFC 5545 requires for
+// every TZID used, covering the times from the year of first to some years
+// after last. Transitions that recur yearly are written as RRULEs, so
+// clients can expand them indefinitely.
+func writeVTIMEZONE(out *bufio.Writer, loc *time.Location, first, last time.Time) {
+	from := time.Date(first.Year()-1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(last.Year()+3, time.January, 1, 0, 0, 0, 0, time.UTC)
+	transitions := zoneTransitions(loc, from, to)
+
+	writeLine(out, "BEGIN:VTIMEZONE")
+	writeLine(out, "TZID:"+loc.String())
+
+	// Without a transition before first, the offset in effect at the
+	// start of the range needs an observance of its own.
+	if len(transitions) == 0 || !transitions[0].at.Before(first) {
+		name, offset := from.In(loc).Zone()
+		initial := tzTransition{at: from, from: offset, to: offset, name: name, dst: from.In(loc).IsDST()}
+		writeObservance(out, initial, "", time.Time{})
+	}
+
+	// Transitions to the same offset, e.g. every start of summer time,
+	// that follow the same rule in consecutive years become one
+	// observance.
+	var runs [][]tzTransition
+	for _, t := range transitions {
+		extended := false
+		for i, run := range runs {
+			if t.follows(run[len(run)-1]) {
+				runs[i] = append(run, t)
+				extended = true
+				break
+			}
+		}
+		if !extended {
+			runs = append(runs, []tzTransition{t})
+		}
+	}
+
+	for _, run := range runs {
+		rule := run[0].rule()
+		var until time.Time
+		switch last := run[len(run)-1]; {
+		case len(run) == 1:
+			rule = ""
+		case last.onset().Year() < to.Year()-1:
+			until = last.at
+		}
+		writeObservance(out, run[0], rule, until)
+	}
+
+	writeLine(out, "END:VTIMEZONE")
+}
+
+// writeObservance writes a STANDARD or DAYLIGHT component starting with t
+// and, given a rule, repeating until until, or forever when it is zero.
+func writeObservance(out *bufio.Writer, t tzTransition, rule string, until time.Time) {
+	component := "STANDARD"
+	if t.dst {
+		component = "DAYLIGHT"
+	}
+
+	writeLine(out, "BEGIN:"+component)
+	writeLine(out, "DTSTART:"+t.onset().Format(icalLocalTime))
+	if rule != "" {
+		if !until.IsZero() {
+			rule += ";UNTIL=" + until.UTC().Format(icalTime)
+		}
+		writeLine(out, "RRULE:"+rule)
+	}
+	writeLine(out, "TZOFFSETFROM:"+formatOffset(t.from))
+	writeLine(out, "TZOFFSETTO:"+formatOffset(t.to))
+	if t.name != "" {
+		writeLine(out, "TZNAME:"+escapeText(t.name))
+	}
+	writeLine(out, "END:"+component)
+}
+
+// zoneTransitions lists the changes of offset of loc between from and to.
+// It checks once a day and then looks for the second of the change.
+func zoneTransitions(loc *time.Location, from, to time.Time) []tzTransition {
+	var transitions []tzTransition
+
+	_, offset := from.In(loc).Zone()
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		if _, nextOffset := next.In(loc).Zone(); nextOffset == offset {
+			continue
+		}
+
+		// The first second of the day with the new offset.
+		seconds := sort.Search(24*60*60, func(i int) bool {
+			_, o := day.Add(time.Duration(i+1) * time.Second).In(loc).Zone()
+			return o != offset
+		})
+		at := day.Add(time.Duration(seconds+1) * time.Second)
+		name, newOffset := at.In(loc).Zone()
+
+		transitions = append(transitions, tzTransition{at: at, from: offset, to: newOffset, name: name, dst: at.In(loc).IsDST()})
+		offset = newOffset
+	}
+
+	return transitions
+}
+
+// formatOffset writes a UTC offset in seconds as RFC 5545 wants it, e.g.
+// +0100, with the seconds only when there are some.
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+
+	offset := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		offset += fmt.Sprintf("%02d", seconds%60)
+	}
+	return offset
+}
@@ -0,0 +1,296 @@
+package codec
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// todoTxtDate is the date format of todo.txt lines.
+const todoTxtDate = "2006-01-02"
+
+// Keys of the todo.txt key:value pairs that map to task fields. Any other
+// pair is kept in Task.Extensions.
+const (
+	keyDue    = "due"
+	keyID     = "id"
+	keyParent = "parent"
+	// keyPriority holds the priority of completed tasks, which drop the
+	// leading "(A)".
+	keyPriority = "pri"
+)
+
+// maxTodoTxtPriority is the lowest priority a letter can express: (Z).
+const maxTodoTxtPriority = 26
+
+// WriteTodoTxt writes tasks in the todo.txt format, one per line:
+//
+//	x 2025-03-10 2025-03-01 Call Mom +Family @phone due:2025-03-12 id:...
+//
+// Priorities 1 to 26 become (A) to (Z), the creation date comes from the
+// id and tags become contexts. Due dates at midnight UTC are written as
+// dates, others in RFC 3339. Description words that would be read back as
+// something else, such as "@home" or "re:budget", get a leading backslash,
+// as do words that start with one; ReadTodoTxt takes it off again.
+func WriteTodoTxt(w io.Writer, tasks []models.Task) error {
+	out := bufio.NewWriter(w)
+
+	for _, task := range tasks {
+		out.WriteString(todoTxtLine(task))
+		out.WriteString("\n")
+	}
+
+	return out.Flush()
+}
+
+func todoTxtLine(task models.Task) string {
+	var parts []string
+	created := ""
+	if !task.Id.IsZero() {
+		created = task.Id.Timestamp().UTC().Format(todoTxtDate)
+	}
+
+	letter, hasLetter := priorityLetter(task.Priority)
+	switch {
+	case task.Completed:
+		parts = append(parts, "x")
+		// A creation date is only allowed after a completion date.
+		if task.CompletedAt != nil {
+			parts = append(parts, task.CompletedAt.UTC().Format(todoTxtDate))
+			if created != "" {
+				parts = append(parts, created)
+			}
+		}
+	case hasLetter:
+		parts = append(parts, "("+letter+")")
+		fallthrough
+	default:
+		if created != "" {
+			parts = append(parts, created)
+		}
+	}
+
+	for i, word := range strings.Fields(task.Description) {
+		parts = append(parts, escapeWord(word, i == 0))
+	}
+	for _, project := range task.Projects {
+		parts = append(parts, "+"+project)
+	}
+	for _, tag := range task.Tags {
+		parts = append(parts, "@"+tag)
+	}
+
+	if task.DueAt != nil {
+		parts = append(parts, keyDue+":"+formatDue(*task.DueAt))
+	}
+	if task.Completed && hasLetter {
+		parts = append(parts, keyPriority+":"+letter)
+	}
+	if !task.Id.IsZero() {
+		parts = append(parts, keyID+":"+task.Id.Hex())
+	}
+	if task.ParentId != nil {
+		parts = append(parts, keyParent+":"+task.ParentId.Hex())
+	}
+	for _, extension := range task.Extensions {
+		parts = append(parts, extension.Key+":"+extension.Value)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ReadTodoTxt decodes a todo.txt file, skipping blank lines. Lines that do
+// not parse become rows with Err set. A line with a creation date but no
+// id gets an id from that date, so the task keeps it. A word with a
+// leading backslash is part of the description, without the backslash.
+func ReadTodoTxt(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var rows []Row
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		task, err := parseTodoTxtLine(line)
+		rows = append(rows, Row{Number: number, Task: task, Err: err})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, ErrInvalidFile
+	}
+
+	return rows, nil
+}
+
+func parseTodoTxtLine(line string) (models.Task, error) {
+	var task models.Task
+	tokens := strings.Fields(line)
+
+	var created *time.Time
+	if tokens[0] == "x" {
+		task.Completed = true
+		tokens = tokens[1:]
+		if date, ok := parseTodoTxtDate(tokens); ok {
+			task.CompletedAt = &date
+			tokens = tokens[1:]
+			if date, ok := parseTodoTxtDate(tokens); ok {
+				created = &date
+				tokens = tokens[1:]
+			}
+		}
+	} else {
+		if priority, ok := parsePriorityMarker(tokens[0]); ok {
+			task.Priority = priority
+			tokens = tokens[1:]
+		}
+		if date, ok := parseTodoTxtDate(tokens); ok {
+			created = &date
+			tokens = tokens[1:]
+		}
+	}
+
+	var words []string
+	for _, token := range tokens {
+		switch {
+		case token[0] == '\\':
+			words = append(words, token[1:])
+		case len(token) > 1 && token[0] == '+':
+			task.Projects = append(task.Projects, token[1:])
+		case len(token) > 1 && token[0] == '@':
+			task.Tags = append(task.Tags, token[1:])
+		default:
+			key, value, ok := splitPair(token)
+			if !ok {
+				words = append(words, token)
+				continue
+			}
+			if err := setPair(&task, key, value); err != nil {
+				return task, err
+			}
+		}
+	}
+	task.Description = strings.Join(words, " ")
+
+	if task.Id.IsZero() && created != nil {
+		task.Id = bson.NewObjectIDFromTimestamp(*created)
+	}
+
+	return task, nil
+}
+
+// setPair stores a key:value pair in its task field or, for unknown keys,
+// in Extensions.
+func setPair(task *models.Task, key, value string) error {
+	var err error
+
+	switch key {
+	case keyDue:
+		var due time.Time
+		if due, err = time.Parse(todoTxtDate, value); err != nil {
+			due, err = time.Parse(time.RFC3339, value)
+		}
+		if err != nil {
+			return &FieldError{Field: keyDue, Err: err}
+		}
+		task.DueAt = &due
+	case keyID:
+		if task.Id, err = bson.ObjectIDFromHex(value); err != nil {
+			return &FieldError{Field: keyID, Err: err}
+		}
+	case keyParent:
+		if task.ParentId, err = parseID(value); err != nil {
+			return &FieldError{Field: keyParent, Err: err}
+		}
+	case keyPriority:
+		priority, ok := parsePriorityMarker("(" + value + ")")
+		if !ok {
+			return &FieldError{Field: keyPriority, Err: errors.New("not a letter from A to Z")}
+		}
+		task.Priority = priority
+	default:
+		task.Extensions = append(task.Extensions, models.Extension{Key: key, Value: value})
+	}
+
+	return nil
+}
+
+// escapeWord puts a backslash in front of a description word that would be
+// read as a project, context or key:value pair or, as the first word, as
+// the completion mark, priority or a date.
+func escapeWord(word string, first bool) string {
+	_, _, pair := splitPair(word)
+	escape := pair || word[0] == '\\' || len(word) > 1 && (word[0] == '+' || word[0] == '@')
+	if first {
+		_, priority := parsePriorityMarker(word)
+		_, date := parseTodoTxtDate([]string{word})
+		escape = escape || word == "x" || priority || date
+	}
+
+	if escape {
+		return "\\" + word
+	}
+	return word
+}
+
+// splitPair splits a key:value token. Keys start with a letter and hold
+// letters, digits, "-" and "_"; values do not start with "/". Times such
+// as 10:30 and URLs thus stay part of the description.
+func splitPair(token string) (string, string, bool) {
+	key, value, ok := strings.Cut(token, ":")
+	if !ok || key == "" || value == "" || strings.HasPrefix(value, "/") {
+		return "", "", false
+	}
+
+	for i, r := range key {
+		letter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (i == 0 || !(r >= '0' && r <= '9' || r == '-' || r == '_')) {
+			return "", "", false
+		}
+	}
+
+	return key, value, true
+}
+
+// priorityLetter returns the todo.txt letter of a priority, if it has one.
+func priorityLetter(priority int) (string, bool) {
+	if priority < 1 || priority > maxTodoTxtPriority {
+		return "", false
+	}
+	return string(rune('A' + priority - 1)), true
+}
+
+// parsePriorityMarker reads a priority such as "(A)".
+func parsePriorityMarker(token string) (int, bool) {
+	if len(token) != 3 || token[0] != '(' || token[2] != ')' || token[1] < 'A' || token[1] > 'Z' {
+		return 0, false
+	}
+	return int(token[1]-'A') + 1, true
+}
+
+// parseTodoTxtDate reads a leading YYYY-MM-DD date from tokens.
+func parseTodoTxtDate(tokens []string) (time.Time, bool) {
+	if len(tokens) == 0 {
+		return time.Time{}, false
+	}
+
+	date, err := time.Parse(todoTxtDate, tokens[0])
+	return date, err == nil
+}
+
+// formatDue writes a due date as a date when it falls on midnight UTC,
+// and in full otherwise.
+func formatDue(due time.Time) string {
+	due = due.UTC()
+	if due.Equal(due.Truncate(24 * time.Hour)) {
+		return due.Format(todoTxtDate)
+	}
+	return due.Format(time.RFC3339)
+}
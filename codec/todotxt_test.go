@@ -0,0 +1,134 @@
+package codec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestWriteTodoTxt(t *testing.T) {
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	done := time.Date(2025, 3, 10, 18, 30, 0, 0, time.UTC)
+	due := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)
+	id := bson.NewObjectIDFromTimestamp(created)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTodoTxt(&buf, []models.Task{
+		{
+			Id:          id,
+			Description: "Call Mom",
+			Priority:    1,
+			DueAt:       &due,
+			Projects:    []string{"Family"},
+			Tags:        []string{"phone"},
+			Extensions:  []models.Extension{{Key: "rec", Value: "1w"}},
+		},
+		{Id: id, Description: "Pay rent", Priority: 2, Completed: true, CompletedAt: &done},
+	}))
+
+	assert.Equal(t,
+		"(A) 2025-03-01 Call Mom +Family @phone due:2025-03-12 id:"+id.Hex()+" rec:1w\n"+
+			"x 2025-03-10 2025-03-01 Pay rent pri:B id:"+id.Hex()+"\n",
+		buf.String())
+}
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	parent := bson.NewObjectID()
+	due := time.Date(2025, 3, 12, 17, 0, 0, 0, time.UTC)
+	done := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tasks := []models.Task{
+		{
+			Id:          bson.NewObjectID(),
+			Description: "Review the 10:30 notes at https://example.com/x",
+			ParentId:    &parent,
+			Priority:    3,
+			DueAt:       &due,
+			Projects:    []string{"Work", "Q1"},
+			Tags:        []string{"office"},
+			Extensions:  []models.Extension{{Key: "t", Value: "2025-03-11"}, {Key: "rec", Value: "+1m"}},
+		},
+		{Id: bson.NewObjectID(), Description: "Done", Completed: true, CompletedAt: &done, Priority: 26},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTodoTxt(&buf, tasks))
+
+	rows, err := ReadTodoTxt(&buf)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	for i, row := range rows {
+		assert.NoError(t, row.Err)
+		assert.Equal(t, tasks[i], row.Task)
+	}
+}
+
+func TestTodoTxtRoundTripKeepsDescription(t *testing.T) {
+	done := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	// Words that look like metadata, and without an id or a completion
+	// date also the leading markers, must come back as they were.
+	tasks := []models.Task{
+		{Id: bson.NewObjectID(), Description: "re:budget for @home +1 C:\\temp"},
+		{Description: "x marks the spot"},
+		{Description: "(A) is not a priority"},
+		{Priority: 2, Description: "2025-03-01 is not a creation date"},
+		{Completed: true, Description: "2025-03-01 is no completion date"},
+		{Completed: true, CompletedAt: &done, Description: "2025-03-01 either"},
+		{Description: "\\@ and \\\\ stay as written"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTodoTxt(&buf, tasks))
+	assert.Contains(t, buf.String(), " \\re:budget for \\@home \\+1 \\C:\\temp id:")
+
+	rows, err := ReadTodoTxt(&buf)
+	require.NoError(t, err)
+	require.Len(t, rows, len(tasks))
+
+	for i, row := range rows {
+		assert.NoError(t, row.Err)
+		assert.Equal(t, tasks[i], row.Task)
+	}
+}
+
+func TestReadTodoTxt(t *testing.T) {
+	rows, err := ReadTodoTxt(strings.NewReader("" +
+		"(B) 2025-03-01 Plan trip +Travel @home due:2025-04-01 custom:value\n" +
+		"\n" +
+		"x Old task\n" +
+		"Broken due:someday\n" +
+		"(a) lowercase is no priority\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	task := rows[0].Task
+	assert.Equal(t, 1, rows[0].Number)
+	assert.Equal(t, "Plan trip", task.Description)
+	assert.Equal(t, 2, task.Priority)
+	assert.Equal(t, []string{"Travel"}, task.Projects)
+	assert.Equal(t, []string{"home"}, task.Tags)
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), *task.DueAt)
+	assert.Equal(t, []models.Extension{{Key: "custom", Value: "value"}}, task.Extensions)
+	// The creation date is kept in the id.
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), task.Id.Timestamp().UTC())
+
+	assert.Equal(t, 3, rows[1].Number)
+	assert.True(t, rows[1].Task.Completed)
+	assert.Nil(t, rows[1].Task.CompletedAt)
+	assert.True(t, rows[1].Task.Id.IsZero())
+
+	var fieldErr *FieldError
+	require.ErrorAs(t, rows[2].Err, &fieldErr)
+	assert.Equal(t, "due", fieldErr.Field)
+
+	assert.Equal(t, 0, rows[3].Task.Priority)
+	assert.Equal(t, "(a) lowercase is no priority", rows[3].Task.Description)
+}
@@ -0,0 +1,420 @@
+// Package config reads the settings of the app from a YAML or TOML file,
+// the environment and command line flags.
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the app. A field is set, from lowest to
+// highest precedence, by its default, by the file, by the environment
+// variable named in its env tag and by the flag named in its flag tag.
+// Fields tagged reload may change while the app runs; see Reload.
+type Config struct {
+	Server  Server  `yaml:"server" toml:"server"`
+	MongoDB MongoDB `yaml:"mongodb" toml:"mongodb"`
+	Tasks   Tasks   `yaml:"tasks" toml:"tasks"`
+	API     API     `yaml:"api" toml:"api"`
+	Log     Log     `yaml:"log" toml:"log"`
+}
+
+// Server configures the listeners.
+type Server struct {
+	Addr           string   `yaml:"addr" toml:"addr" env:"HTTP_ADDR" flag:"addr" usage:"address of the HTTP server"`
+	GRPCAddr       string   `yaml:"grpcAddr" toml:"grpcAddr" env:"GRPC_ADDR" flag:"grpc-addr" usage:"address of the gRPC server"`
+	RequestTimeout Duration `yaml:"requestTimeout" toml:"requestTimeout" env:"REQUEST_TIMEOUT" flag:"request-timeout" usage:"how long the storage calls of a request may take" reload:"true"`
+	// SecureCookies marks session cookies Secure even on requests that
+	// came over plain HTTP, as they do behind a proxy that terminates TLS.
+	SecureCookies Bool `yaml:"secureCookies" toml:"secureCookies" env:"SECURE_COOKIES" flag:"secure-cookies" usage:"mark session cookies Secure, for a proxy that terminates HTTPS" reload:"true"`
+}
+
+// MongoDB configures the storage.
+type MongoDB struct {
+	URI             string `yaml:"uri" toml:"uri" env:"MONGODB_URI" flag:"mongodb-uri" usage:"MongoDB connection string" secret:"true"`
+	Database        string `yaml:"database" toml:"database" env:"MONGODB_DATABASE" flag:"mongodb-database" usage:"MongoDB database"`
+	TasksCollection string `yaml:"tasksCollection" toml:"tasksCollection" env:"MONGODB_TASKS_COLLECTION" flag:"mongodb-tasks-collection" usage:"MongoDB collection of the tasks"`
+}
+
+// Tasks configures how long deleted tasks can be brought back.
+type Tasks struct {
+	TrashRetention Duration `yaml:"trashRetention" toml:"trashRetention" env:"TRASH_RETENTION" flag:"trash-retention" usage:"how long deleted tasks stay in the trash" reload:"true"`
+	ConfirmTTL     Duration `yaml:"confirmTTL" toml:"confirmTTL" env:"CONFIRM_TTL" flag:"confirm-ttl" usage:"how long a DELETE /api/tasks confirmation token stays valid" reload:"true"`
+	UndoWindow     Duration `yaml:"undoWindow" toml:"undoWindow" env:"UNDO_WINDOW" flag:"undo-window" usage:"how long a confirmed bulk delete can be undone" reload:"true"`
+}
+
+// API configures the versions of the REST API.
+type API struct {
+	V1Deprecation Date `yaml:"v1Deprecation" toml:"v1Deprecation" env:"API_V1_DEPRECATION" flag:"api-v1-deprecation" usage:"date since which version 1 of the API is deprecated"`
+	V1Sunset      Date `yaml:"v1Sunset" toml:"v1Sunset" env:"API_V1_SUNSET" flag:"api-v1-sunset" usage:"date on which version 1 of the API goes away"`
+}
+
+// Log configures logging.
+type Log struct {
+	// Level is debug, info, warn or error. Requests are logged at info;
+	// warn only logs those answered with 4xx or 5xx, error only 5xx.
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"debug, info, warn or error" reload:"true"`
+}
+
+// Log levels.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// FileEnv and FileFlag name the configuration file, which is optional.
+const (
+	FileEnv  = "CONFIG_FILE"
+	FileFlag = "config"
+)
+
+// Duration is a time.Duration written like "90s" or "720h".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(value)
+	return nil
+}
+
+// Bool is a bool written like "true" or "false"; a flag can leave the
+// value out to mean true.
+type Bool bool
+
+func (b Bool) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatBool(bool(b))), nil
+}
+
+func (b *Bool) UnmarshalText(text []byte) error {
+	value, err := strconv.ParseBool(string(text))
+	if err != nil {
+		return err
+	}
+	*b = Bool(value)
+	return nil
+}
+
+// Date is a day written like "2027-04-30", starting at midnight UTC.
+type Date time.Time
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(time.Time(d).Format(time.DateOnly)), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	value, err := time.Parse(time.DateOnly, string(text))
+	if err != nil {
+		// TOML dates arrive as timestamps.
+		if at, rfcErr := time.Parse(time.RFC3339, string(text)); rfcErr == nil {
+			value, err = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	*d = Date(value)
+	return nil
+}
+
+// Default returns the settings used when nothing else sets them.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:           ":8080",
+			GRPCAddr:       ":9090",
+			RequestTimeout: Duration(5 * time.Second),
+		},
+		MongoDB: MongoDB{
+			Database:        "todo-app-go",
+			TasksCollection: "tasks",
+		},
+		Tasks: Tasks{
+			TrashRetention: Duration(30 * 24 * time.Hour),
+			ConfirmTTL:     Duration(2 * time.Minute),
+			UndoWindow:     Duration(10 * time.Minute),
+		},
+		API: API{
+			V1Deprecation: Date(time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)),
+			V1Sunset:      Date(time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)),
+		},
+		Log: Log{Level: LevelInfo},
+	}
+}
+
+// Load reads the configuration given the command line arguments args,
+// without the program name, and the environment getenv. It does not
+// validate it. With -h it returns flag.ErrHelp after printing the flags.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+	fields := fieldsOf(&cfg)
+
+	flags := flag.NewFlagSet("todo-app", flag.ContinueOnError)
+	file := flags.String(FileFlag, getenv(FileEnv), "YAML or TOML configuration file (env "+FileEnv+")")
+	set := map[string]string{}
+	for _, f := range fields {
+		usage := f.usage + " (env " + f.env
+		if value := f.String(); value != "" {
+			usage += ", default " + value
+		}
+		define := flags.Func
+		if _, ok := f.value.Interface().(Bool); ok {
+			define = flags.BoolFunc
+		}
+		define(f.flag, usage+")", func(value string) error {
+			set[f.flag] = value
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, err
+	}
+	if flags.NArg() > 0 {
+		return cfg, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	if *file != "" {
+		if err := readFile(*file, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	for _, f := range fields {
+		if value := getenv(f.env); value != "" {
+			if err := f.set(value); err != nil {
+				return cfg, fmt.Errorf("%s: %w", f.env, err)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if value, ok := set[f.flag]; ok {
+			if err := f.set(value); err != nil {
+				return cfg, fmt.Errorf("-%s: %w", f.flag, err)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// readFile reads the file at path into cfg, as YAML or TOML depending on
+// its extension. Keys that are not settings are an error, to catch typos.
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: unknown setting %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("%s: not a .yaml, .yml or .toml file", path)
+	}
+
+	return nil
+}
+
+// Validate reports every setting that is missing or invalid.
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		invalid("server.addr", "%v", err)
+	}
+	if _, _, err := net.SplitHostPort(c.Server.GRPCAddr); err != nil {
+		invalid("server.grpcAddr", "%v", err)
+	} else if c.Server.GRPCAddr == c.Server.Addr {
+		invalid("server.grpcAddr", "same as server.addr")
+	}
+
+	if c.MongoDB.URI == "" {
+		invalid("mongodb.uri", "missing; set MONGODB_URI")
+	} else if !strings.HasPrefix(c.MongoDB.URI, "mongodb://") && !strings.HasPrefix(c.MongoDB.URI, "mongodb+srv://") {
+		invalid("mongodb.uri", "not a mongodb:// or mongodb+srv:// URI")
+	}
+	if c.MongoDB.Database == "" || strings.ContainsAny(c.MongoDB.Database, `/\. "$`) {
+		invalid("mongodb.database", "%q is not a database name", c.MongoDB.Database)
+	}
+	if c.MongoDB.TasksCollection == "" || strings.Contains(c.MongoDB.TasksCollection, "$") || strings.HasPrefix(c.MongoDB.TasksCollection, "system.") {
+		invalid("mongodb.tasksCollection", "%q is not a collection name", c.MongoDB.TasksCollection)
+	}
+
+	durations := []struct {
+		key   string
+		value Duration
+	}{
+		{"server.requestTimeout", c.Server.RequestTimeout},
+		{"tasks.trashRetention", c.Tasks.TrashRetention},
+		{"tasks.confirmTTL", c.Tasks.ConfirmTTL},
+		{"tasks.undoWindow", c.Tasks.UndoWindow},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			invalid(d.key, "must be positive")
+		}
+	}
+	// Undoing a bulk delete takes the tasks back out of the trash, so they
+	// must still be there.
+	if c.Tasks.UndoWindow > c.Tasks.TrashRetention && c.Tasks.TrashRetention > 0 {
+		invalid("tasks.undoWindow", "longer than tasks.trashRetention (%s)", time.Duration(c.Tasks.TrashRetention))
+	}
+
+	if !time.Time(c.API.V1Sunset).After(time.Time(c.API.V1Deprecation)) {
+		invalid("api.v1Sunset", "not after api.v1Deprecation (%s)", time.Time(c.API.V1Deprecation).Format(time.DateOnly))
+	}
+
+	switch c.Log.Level {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError:
+	default:
+		invalid("log.level", "%q is not debug, info, warn or error", c.Log.Level)
+	}
+
+	return errors.Join(errs...)
+}
+
+// String lists the settings one per line, with secrets redacted, for
+// logging the configuration in effect.
+func (c Config) String() string {
+	var b strings.Builder
+	for _, f := range fieldsOf(&c) {
+		value := f.String()
+		if f.secret {
+			value = redact(value)
+		}
+		fmt.Fprintf(&b, "%s = %s\n", f.key, value)
+	}
+	return b.String()
+}
+
+// redact hides the password of a URI, or the whole value of any other
+// secret.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return "xxxxx"
+	}
+	return u.Redacted()
+}
+
+// Reload returns next with the settings that cannot change while the app
+// runs taken from current, and the keys of those that next would have
+// changed. The result is validated like at startup; when it is invalid,
+// current stays in effect.
+func Reload(current, next Config) (Config, []string, error) {
+	var ignored []string
+
+	currentFields := fieldsOf(&current)
+	for i, f := range fieldsOf(&next) {
+		if f.reload || reflect.DeepEqual(f.value.Interface(), currentFields[i].value.Interface()) {
+			continue
+		}
+		f.value.Set(currentFields[i].value)
+		ignored = append(ignored, f.key)
+	}
+
+	if err := next.Validate(); err != nil {
+		return current, nil, err
+	}
+
+	return next, ignored, nil
+}
+
+// field is one setting of a Config.
+type field struct {
+	// key is where the setting is in the file, e.g. server.addr.
+	key    string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	reload bool
+	value  reflect.Value
+}
+
+// fieldsOf lists the settings of cfg, in order.
+func fieldsOf(cfg *Config) []field {
+	var fields []field
+
+	sections := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		sectionKey := sections.Type().Field(i).Tag.Get("yaml")
+
+		for j := 0; j < section.NumField(); j++ {
+			tag := section.Type().Field(j).Tag
+			fields = append(fields, field{
+				key:    sectionKey + "." + tag.Get("yaml"),
+				env:    tag.Get("env"),
+				flag:   tag.Get("flag"),
+				usage:  tag.Get("usage"),
+				secret: tag.Get("secret") == "true",
+				reload: tag.Get("reload") == "true",
+				value:  section.Field(j),
+			})
+		}
+	}
+
+	return fields
+}
+
+func (f field) set(value string) error {
+	if u, ok := f.value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	f.value.SetString(value)
+	return nil
+}
+
+func (f field) String() string {
+	if m, ok := f.value.Interface().(encoding.TextMarshaler); ok {
+		text, _ := m.MarshalText()
+		return string(text)
+	}
+
+	return f.value.String()
+}
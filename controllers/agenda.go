@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultUpcomingDays = 7
+	maxUpcomingDays     = 365
+)
+
+// viewGroup is a titled section of the task list in the web view.
+type viewGroup struct {
+	Id    string
+	Title string
+	Tasks []models.ViewTask
+}
+
+// TodayTasks lists open tasks due during the current calendar day.
+func (tc TaskController) TodayTasks(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	now, ok := tc.clock(c)
+	if !ok {
+		return
+	}
+
+	start := models.StartOfDay(now)
+	end := start.AddDate(0, 0, 1)
+	completed := false
+
+	tc.listAgenda(ctx, c, repository.TaskFilter{
+		Completed: &completed,
+		DueFrom:   &start,
+		DueBefore: &end,
+	}, now)
+}
+
+// OverdueTasks lists open tasks whose due date has passed.
+func (tc TaskController) OverdueTasks(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	now, ok := tc.clock(c)
+	if !ok {
+		return
+	}
+
+	completed := false
+
+	tc.listAgenda(ctx, c, repository.TaskFilter{
+		Completed: &completed,
+		DueBefore: &now,
+	}, now)
+}
+
+// UpcomingTasks lists open tasks due within the next ?days=N days.
+func (tc TaskController) UpcomingTasks(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	now, ok := tc.clock(c)
+	if !ok {
+		return
+	}
+
+	days := defaultUpcomingDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxUpcomingDays {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid days parameter"})
+			return
+		}
+		days = parsed
+	}
+
+	end := now.AddDate(0, 0, days)
+	completed := false
+
+	tc.listAgenda(ctx, c, repository.TaskFilter{
+		Completed: &completed,
+		DueFrom:   &now,
+		DueBefore: &end,
+	}, now)
+}
+
+func (tc TaskController) listAgenda(ctx context.Context, c *gin.Context, filter repository.TaskFilter, now time.Time) {
+	filter.Owner = currentUser(c)
+
+	tasks, err := tc.repo.List(ctx, filter, repository.ListOptions{Sort: repository.SortDueAt})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	for i := range tasks {
+		tasks[i].ComputeDueFlags(now)
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// clock returns the current time in the zone named by ?tz=, defaulting to
+// the server's zone. On an unknown zone it writes a 400 response.
+func (tc TaskController) clock(c *gin.Context) (time.Time, bool) {
+	now := tc.now()
+
+	if name := c.Query("tz"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid time zone"})
+			return now, false
+		}
+		now = now.In(loc)
+	}
+
+	return now, true
+}
+
+func newViewTask(task models.Task, now time.Time) models.ViewTask {
+	task.ComputeDueFlags(now)
+
+	viewTask := models.ViewTask{
+		Id:          task.Id.Hex(),
+		Description: task.Description,
+		Completed:   task.Completed,
+		Overdue:     task.Overdue,
+		DueToday:    task.DueToday,
+		Recurring:   task.Recurrence != nil,
+	}
+
+	if task.DueAt != nil {
+		viewTask.DueAt = task.DueAt.In(now.Location()).Format("Jan 2, 15:04")
+	}
+
+	if task.Progress != nil {
+		viewTask.Progress = fmt.Sprintf("%d/%d", task.Progress.Done, task.Progress.Total)
+	}
+
+	return viewTask
+}
+
+// groupViewTasks splits tasks into Overdue, Today and Later sections,
+// dropping the ones that end up empty.
+func groupViewTasks(tasks []models.ViewTask) []viewGroup {
+	groups := []viewGroup{
+		{Id: "overdue", Title: "Overdue"},
+		{Id: "today", Title: "Today"},
+		{Id: "later", Title: "Later"},
+	}
+
+	for _, task := range tasks {
+		switch {
+		case task.Overdue:
+			groups[0].Tasks = append(groups[0].Tasks, task)
+		case task.DueToday:
+			groups[1].Tasks = append(groups[1].Tasks, task)
+		default:
+			groups[2].Tasks = append(groups[2].Tasks, task)
+		}
+	}
+
+	nonEmpty := groups[:0]
+	for _, group := range groups {
+		if len(group.Tasks) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+
+	return nonEmpty
+}
@@ -0,0 +1,504 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookie  = "session"
+	sessionTTL     = 7 * 24 * time.Hour
+	minPasswordLen = 8
+	// bcrypt ignores everything past 72 bytes, so longer passwords are
+	// rejected rather than silently truncated.
+	maxPasswordLen = 72
+	// userKey is the gin context key holding the logged-in user's id.
+	userKey = "userId"
+	// basicAuthTTL is how long verified Basic credentials are remembered,
+	// so that calendar clients, which send them with every request, do not
+	// cost a bcrypt comparison each time.
+	basicAuthTTL = time.Minute
+)
+
+// dummyHash is compared against when logging in with an unknown email, so
+// that the response time does not reveal which emails are registered.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+type AuthController struct {
+	users    repository.UserRepository
+	sessions repository.SessionRepository
+	// verified remembers Basic credentials for basicAuthTTL.
+	verified *credentialCache
+	now      func() time.Time
+}
+
+func NewAuthController(s repository.Store) *AuthController {
+	return &AuthController{
+		users:    s.Users,
+		sessions: s.Sessions,
+		verified: &credentialCache{users: map[[sha256.Size]byte]cachedUser{}},
+		now:      time.Now,
+	}
+}
+
+// credentialCache maps the SHA-256 of verified Basic credentials to their
+// user. Only credentials that were right are kept.
+type credentialCache struct {
+	mu    sync.Mutex
+	users map[[sha256.Size]byte]cachedUser
+}
+
+type cachedUser struct {
+	id        bson.ObjectID
+	expiresAt time.Time
+}
+
+func credentialKey(creds credentials) [sha256.Size]byte {
+	return sha256.Sum256([]byte(normalizeEmail(creds.Email) + "\x00" + creds.Password))
+}
+
+// get returns the user of creds if they were verified before now.
+func (cc *credentialCache) get(creds credentials, now time.Time) (bson.ObjectID, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	user, ok := cc.users[credentialKey(creds)]
+	if !ok || !now.Before(user.expiresAt) {
+		return bson.ObjectID{}, false
+	}
+	return user.id, true
+}
+
+// put remembers that creds are those of user until now plus basicAuthTTL,
+// dropping what has expired.
+func (cc *credentialCache) put(creds credentials, user bson.ObjectID, now time.Time) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	for key, cached := range cc.users {
+		if !now.Before(cached.expiresAt) {
+			delete(cc.users, key)
+		}
+	}
+	cc.users[credentialKey(creds)] = cachedUser{id: user, expiresAt: now.Add(basicAuthTTL)}
+}
+
+type credentials struct {
+	Email    string `json:"email" form:"email"`
+	Password string `json:"password" form:"password"`
+}
+
+func (ac AuthController) getContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), settings().RequestTimeout)
+}
+
+// Register creates an account. It does not log the user in.
+func (ac AuthController) Register(c *gin.Context) {
+	ctx, cancel := ac.getContext()
+	defer cancel()
+
+	var creds credentials
+
+	if err := c.BindJSON(&creds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+
+	user, status, message := ac.register(ctx, creds)
+	if status != http.StatusCreated {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// Login exchanges an email and password for a bearer token.
+func (ac AuthController) Login(c *gin.Context) {
+	ctx, cancel := ac.getContext()
+	defer cancel()
+
+	var creds credentials
+
+	if err := c.BindJSON(&creds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+
+	token, session, status, message := ac.login(ctx, creds)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":     token,
+		"expiresAt": session.ExpiresAt,
+	})
+}
+
+// Logout ends the session the request was authenticated with.
+func (ac AuthController) Logout(c *gin.Context) {
+	ctx, cancel := ac.getContext()
+	defer cancel()
+
+	ac.logout(ctx, c)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// Me returns the logged-in user.
+func (ac AuthController) Me(c *gin.Context) {
+	ctx, cancel := ac.getContext()
+	defer cancel()
+
+	userID := currentUser(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Authentication required"})
+		return
+	}
+
+	user, err := ac.users.Get(ctx, *userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ShowLogin renders the login and registration page.
+func (ac AuthController) ShowLogin(c *gin.Context) {
+	c.HTML(http.StatusOK, "login.gohtml", gin.H{})
+}
+
+// SubmitLogin handles the login form and starts a cookie session.
+func (ac AuthController) SubmitLogin(c *gin.Context) {
+	ctx, cancel := ac.getContext()
+	defer cancel()
+
+	var creds credentials
+	_ = c.ShouldBind(&creds)
+
+	token, session, status, message := ac.login(ctx, creds)
+	if status != http.StatusOK {
+		c.HTML(status, "login.gohtml", gin.H{"error": message, "email": creds.Email})
+		return
+	}
+
+	ac.setSessionCookie(c, token, session.ExpiresAt)
+	c.Redirect(http.StatusSeeOther, "/view/tasks")
+}
+
+// SubmitRegister handles the registration form, then logs the new user in.
+func (ac AuthController) SubmitRegister(c *gin.Context) {
+	ctx, cancel := ac.getContext()
+	defer cancel()
+
+	var creds credentials
+	_ = c.ShouldBind(&creds)
+
+	if _, status, message := ac.register(ctx, creds); status != http.StatusCreated {
+		c.HTML(status, "login.gohtml", gin.H{"error": message, "email": creds.Email, "register": true})
+		return
+	}
+
+	token, session, status, message := ac.login(ctx, creds)
+	if status != http.StatusOK {
+		c.HTML(status, "login.gohtml", gin.H{"error": message, "email": creds.Email})
+		return
+	}
+
+	ac.setSessionCookie(c, token, session.ExpiresAt)
+	c.Redirect(http.StatusSeeOther, "/view/tasks")
+}
+
+// SubmitLogout ends the cookie session and returns to the login page.
+func (ac AuthController) SubmitLogout(c *gin.Context) {
+	ctx, cancel := ac.getContext()
+	defer cancel()
+
+	ac.logout(ctx, c)
+
+	c.Redirect(http.StatusSeeOther, "/view/login")
+}
+
+// RequireAPIAuth rejects API requests without a valid session. Clients
+// send a bearer token; the web view's own requests carry the session
+// cookie instead.
+func (ac AuthController) RequireAPIAuth(c *gin.Context) {
+	ac.requireAuth(c, `Bearer realm="api"`, false)
+}
+
+// RequireFeedAuth is RequireAPIAuth for the iCalendar feed, which calendar
+// apps subscribe to with the email and password, using HTTP Basic
+// authentication.
+func (ac AuthController) RequireFeedAuth(c *gin.Context) {
+	ac.requireAuth(c, `Basic realm="api", charset="UTF-8"`, true)
+}
+
+// RequireDAVAuth is RequireFeedAuth for CalDAV clients, which only log in
+// once challenged for Basic authentication.
+func (ac AuthController) RequireDAVAuth(c *gin.Context) {
+	ac.requireAuth(c, `Basic realm="caldav", charset="UTF-8"`, true)
+}
+
+// requireAuth rejects requests without a valid session, or Basic
+// credentials when basic allows them, sending challenge with the 401
+// response.
+func (ac AuthController) requireAuth(c *gin.Context, challenge string, basic bool) {
+	ctx, cancel := ac.getContext()
+	defer cancel()
+
+	if email, password, ok := c.Request.BasicAuth(); ok && basic {
+		user, ok := ac.verifyBasic(ctx, credentials{Email: email, Password: password})
+		if !ok {
+			c.Header("WWW-Authenticate", challenge)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authentication required"})
+			return
+		}
+
+		c.Set(userKey, user)
+		c.Next()
+		return
+	}
+
+	session, ok := ac.authenticate(ctx, requestToken(c))
+	if !ok {
+		c.Header("WWW-Authenticate", challenge)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authentication required"})
+		return
+	}
+
+	c.Set(userKey, session.UserId)
+	c.Next()
+}
+
+// verifyBasic checks Basic credentials, trusting those verified within
+// basicAuthTTL.
+func (ac AuthController) verifyBasic(ctx context.Context, creds credentials) (bson.ObjectID, bool) {
+	now := ac.now()
+	if user, ok := ac.verified.get(creds, now); ok {
+		return user, true
+	}
+
+	user, status, _ := ac.verify(ctx, creds)
+	if status != http.StatusOK {
+		return bson.ObjectID{}, false
+	}
+
+	ac.verified.put(creds, user.Id, now)
+	return user.Id, true
+}
+
+// RequireViewAuth sends visitors without a valid session cookie to the
+// login page.
+func (ac AuthController) RequireViewAuth(c *gin.Context) {
+	ctx, cancel := ac.getContext()
+	defer cancel()
+
+	token, _ := c.Cookie(sessionCookie)
+
+	session, ok := ac.authenticate(ctx, token)
+	if !ok {
+		c.Redirect(http.StatusSeeOther, "/view/login")
+		c.Abort()
+		return
+	}
+
+	c.Set(userKey, session.UserId)
+	c.Next()
+}
+
+// register validates creds and stores a new user. It returns the HTTP
+// status to answer with and, on failure, the message to show.
+func (ac AuthController) register(ctx context.Context, creds credentials) (models.User, int, string) {
+	email := normalizeEmail(creds.Email)
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return models.User{}, http.StatusBadRequest, "Invalid email address"
+	}
+
+	if len(creds.Password) < minPasswordLen || len(creds.Password) > maxPasswordLen {
+		return models.User{}, http.StatusBadRequest, "Password must be between 8 and 72 characters"
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, http.StatusInternalServerError, "Failed to create user"
+	}
+
+	user, err := ac.users.Insert(ctx, models.User{
+		Email:        email,
+		PasswordHash: string(hash),
+		CreatedAt:    ac.now().UTC(),
+	})
+	if errors.Is(err, repository.ErrDuplicateEmail) {
+		return user, http.StatusConflict, "Email already registered"
+	}
+	if err != nil {
+		log.Println("Error creating user:", err)
+		return user, http.StatusInternalServerError, "Failed to create user"
+	}
+
+	return user, http.StatusCreated, ""
+}
+
+// login checks creds and opens a session. It returns the session token,
+// which is never stored, along with the HTTP status to answer with.
+func (ac AuthController) login(ctx context.Context, creds credentials) (string, models.Session, int, string) {
+	user, status, message := ac.verify(ctx, creds)
+	if status != http.StatusOK {
+		return "", models.Session{}, status, message
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", models.Session{}, http.StatusInternalServerError, "Failed to log in"
+	}
+
+	session := models.Session{
+		TokenHash: hashToken(token),
+		UserId:    user.Id,
+		ExpiresAt: ac.now().Add(sessionTTL).UTC(),
+	}
+	if err := ac.sessions.Insert(ctx, session); err != nil {
+		log.Println("Error creating session:", err)
+		return "", session, http.StatusInternalServerError, "Failed to log in"
+	}
+
+	return token, session, http.StatusOK, ""
+}
+
+// verify checks creds and returns the user they belong to, along with the
+// HTTP status to answer with.
+func (ac AuthController) verify(ctx context.Context, creds credentials) (models.User, int, string) {
+	user, err := ac.users.GetByEmail(ctx, normalizeEmail(creds.Email))
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Println("Error fetching user:", err)
+		return user, http.StatusInternalServerError, "Failed to log in"
+	}
+
+	hash := []byte(user.PasswordHash)
+	if err != nil {
+		hash = dummyHash
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(creds.Password)) != nil || user.Id.IsZero() {
+		return user, http.StatusUnauthorized, "Invalid email or password"
+	}
+
+	return user, http.StatusOK, ""
+}
+
+// logout deletes the request's session, if any, and clears the cookie.
+func (ac AuthController) logout(ctx context.Context, c *gin.Context) {
+	if token := requestToken(c); token != "" {
+		err := ac.sessions.Delete(ctx, hashToken(token))
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Println("Error deleting session:", err)
+		}
+	}
+
+	ac.setSessionCookie(c, "", time.Unix(0, 0))
+}
+
+// authenticate looks up the session for token, dropping it once expired.
+func (ac AuthController) authenticate(ctx context.Context, token string) (models.Session, bool) {
+	if token == "" {
+		return models.Session{}, false
+	}
+
+	session, err := ac.sessions.Get(ctx, hashToken(token))
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.Println("Error fetching session:", err)
+		}
+		return session, false
+	}
+
+	if !ac.now().Before(session.ExpiresAt) {
+		_ = ac.sessions.Delete(ctx, session.TokenHash)
+		return session, false
+	}
+
+	return session, true
+}
+
+// setSessionCookie sets the session cookie, Secure when the request came
+// over TLS or the settings say a proxy in front of the app terminates it.
+func (ac AuthController) setSessionCookie(c *gin.Context, token string, expires time.Time) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   c.Request.TLS != nil || settings().SecureCookies,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// requestToken returns the bearer token of the request, falling back to
+// the session cookie.
+func requestToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if token := strings.TrimPrefix(header, "Bearer "); token != header {
+		return strings.TrimSpace(token)
+	}
+
+	token, _ := c.Cookie(sessionCookie)
+	return token
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// currentUser returns the id of the logged-in user, or nil when the route
+// is not behind authentication, e.g. in tests or when embedding the
+// controllers.
+func currentUser(c *gin.Context) *bson.ObjectID {
+	value, ok := c.Get(userKey)
+	if !ok {
+		return nil
+	}
+
+	userID := value.(bson.ObjectID)
+	return &userID
+}
+
+// ownedBy reports whether a document with the given owner is visible to
+// the logged-in user.
+func ownedBy(c *gin.Context, owner *bson.ObjectID) bool {
+	userID := currentUser(c)
+	return userID == nil || (owner != nil && *owner == *userID)
+}
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthControllerTestSuite struct {
+	controllerSuite
+	auth *AuthController
+}
+
+func (suite *AuthControllerTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.auth = NewAuthController(suite.store)
+	suite.auth.now = func() time.Time { return suite.now }
+	lc := NewListController(suite.store)
+
+	suite.router.LoadHTMLGlob("../templates/*.gohtml")
+	suite.router.POST("/api/auth/register", suite.auth.Register)
+	suite.router.POST("/api/auth/login", suite.auth.Login)
+	suite.router.POST("/view/login", suite.auth.SubmitLogin)
+	suite.router.POST("/view/register", suite.auth.SubmitRegister)
+
+	api := suite.router.Group("/api", suite.auth.RequireAPIAuth)
+	api.GET("/auth/me", suite.auth.Me)
+	api.POST("/auth/logout", suite.auth.Logout)
+	api.POST("/task", suite.controller.CreateTask)
+	api.GET("/tasks", suite.controller.GetTasks)
+	api.POST("/lists", lc.CreateList)
+	api.GET("/lists", lc.GetLists)
+
+	view := suite.router.Group("/view", suite.auth.RequireViewAuth)
+	view.GET("/tasks", suite.controller.ShowAllTasks)
+
+	suite.router.GET("/api/tasks.ics", suite.auth.RequireFeedAuth, suite.controller.TaskFeed)
+
+	dav := suite.router.Group("/caldav", suite.auth.RequireDAVAuth)
+	dav.GET("/tasks/:name", suite.controller.GetCalendarObject)
+}
+
+// authorized sends body as JSON with token as the bearer credential.
+func (suite *AuthControllerTestSuite) authorized(method, url string, body interface{}, token string) *httptest.ResponseRecorder {
+	jsonData, _ := json.Marshal(body)
+
+	req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return suite.serve(req)
+}
+
+func (suite *AuthControllerTestSuite) register(email, password string) string {
+	credentials := gin.H{"email": email, "password": password}
+
+	w := suite.request("POST", "/api/auth/register", credentials)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+
+	w = suite.request("POST", "/api/auth/login", credentials)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var response map[string]string
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response["token"]
+}
+
+func (suite *AuthControllerTestSuite) TestRegister() {
+	w := suite.request("POST", "/api/auth/register", gin.H{"email": " Ada@Example.com ", "password": "correct horse"})
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"email":"ada@example.com"`)
+	assert.NotContains(suite.T(), w.Body.String(), "correct horse")
+	assert.NotContains(suite.T(), w.Body.String(), "passwordHash")
+
+	w = suite.request("POST", "/api/auth/register", gin.H{"email": "ada@example.com", "password": "another one"})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *AuthControllerTestSuite) TestRegisterValidation() {
+	for _, body := range []gin.H{
+		{"email": "not an email", "password": "correct horse"},
+		{"email": "ada@example.com", "password": "short"},
+		{"email": "ada@example.com", "password": strings.Repeat("x", 73)},
+	} {
+		w := suite.request("POST", "/api/auth/register", body)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func (suite *AuthControllerTestSuite) TestLoginAndMe() {
+	token := suite.register("ada@example.com", "correct horse")
+	assert.NotEmpty(suite.T(), token)
+
+	w := suite.authorized("GET", "/api/auth/me", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"email":"ada@example.com"`)
+
+	w = suite.request("POST", "/api/auth/login", gin.H{"email": "ada@example.com", "password": "wrong horse"})
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = suite.request("POST", "/api/auth/login", gin.H{"email": "nobody@example.com", "password": "correct horse"})
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *AuthControllerTestSuite) TestRequireAPIAuth() {
+	w := suite.request("GET", "/api/tasks", nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("WWW-Authenticate"), "Bearer")
+
+	w = suite.authorized("GET", "/api/tasks", nil, "bogus")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *AuthControllerTestSuite) TestBasicAuth() {
+	suite.register("ada@example.com", "correct horse")
+
+	basic := func(url, password string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.SetBasicAuth("Ada@Example.com", password)
+		return suite.serve(req)
+	}
+
+	// The feed and CalDAV take a password, with their own realms.
+	w := basic("/api/tasks.ics", "correct horse")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = basic("/api/tasks.ics", "wrong horse")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	assert.Equal(suite.T(), `Basic realm="api", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+	w = basic("/caldav/tasks/missing.ics", "wrong horse")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	assert.Equal(suite.T(), `Basic realm="caldav", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+
+	// The rest of the API only takes sessions.
+	w = basic("/api/auth/me", "correct horse")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	assert.Equal(suite.T(), `Bearer realm="api"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func (suite *AuthControllerTestSuite) TestBasicAuthIsRemembered() {
+	suite.register("ada@example.com", "correct horse")
+	ctx := context.Background()
+	creds := credentials{Email: "ada@example.com", Password: "correct horse"}
+
+	_, ok := suite.auth.verifyBasic(ctx, creds)
+	suite.Require().True(ok)
+
+	// Without the user in storage, only remembered credentials get in.
+	suite.auth.users = repository.NewMemoryUserRepository()
+	_, ok = suite.auth.verifyBasic(ctx, creds)
+	assert.True(suite.T(), ok)
+	_, ok = suite.auth.verifyBasic(ctx, credentials{Email: "ada@example.com", Password: "wrong horse"})
+	assert.False(suite.T(), ok)
+
+	suite.now = suite.now.Add(basicAuthTTL)
+	_, ok = suite.auth.verifyBasic(ctx, creds)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *AuthControllerTestSuite) TestCalDAVChallenge() {
+	// Without credentials the API asks for a token, CalDAV for a password.
+	w := suite.request("GET", "/api/auth/me", nil)
+	assert.Equal(suite.T(), `Bearer realm="api"`, w.Header().Get("WWW-Authenticate"))
+
+	w = suite.request("GET", "/caldav/tasks/missing.ics", nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("WWW-Authenticate"), "Basic")
+}
+
+func (suite *AuthControllerTestSuite) TestSessionExpires() {
+	token := suite.register("ada@example.com", "correct horse")
+
+	suite.now = suite.now.Add(sessionTTL)
+
+	w := suite.authorized("GET", "/api/auth/me", nil, token)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *AuthControllerTestSuite) TestLogout() {
+	token := suite.register("ada@example.com", "correct horse")
+
+	w := suite.authorized("POST", "/api/auth/logout", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.authorized("GET", "/api/auth/me", nil, token)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *AuthControllerTestSuite) TestScopesTasksAndLists() {
+	ada := suite.register("ada@example.com", "correct horse")
+	bob := suite.register("bob@example.com", "battery staple")
+
+	w := suite.authorized("POST", "/api/task", gin.H{"description": "Ada's task"}, ada)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), "owner")
+
+	w = suite.authorized("POST", "/api/lists", gin.H{"name": "Ada's list"}, ada)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	w = suite.authorized("GET", "/api/tasks", nil, bob)
+	assert.JSONEq(suite.T(), "[]", w.Body.String())
+
+	w = suite.authorized("GET", "/api/lists", nil, bob)
+	assert.JSONEq(suite.T(), "[]", w.Body.String())
+
+	w = suite.authorized("GET", "/api/tasks", nil, ada)
+	assert.Contains(suite.T(), w.Body.String(), "Ada's task")
+}
+
+func (suite *AuthControllerTestSuite) TestViewLoginSetsCookie() {
+	w := suite.request("GET", "/view/tasks", nil)
+	assert.Equal(suite.T(), http.StatusSeeOther, w.Code)
+	assert.Equal(suite.T(), "/view/login", w.Header().Get("Location"))
+
+	form := url.Values{"email": {"ada@example.com"}, "password": {"correct horse"}}
+	req, _ := http.NewRequest("POST", "/view/register", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = suite.serve(req)
+
+	assert.Equal(suite.T(), http.StatusSeeOther, w.Code)
+	assert.Equal(suite.T(), "/view/tasks", w.Header().Get("Location"))
+
+	cookies := w.Result().Cookies()
+	suite.Require().Len(cookies, 1)
+	assert.Equal(suite.T(), sessionCookie, cookies[0].Name)
+	assert.False(suite.T(), cookies[0].Secure)
+	assert.True(suite.T(), cookies[0].HttpOnly)
+
+	// The cookie opens both the view and, for the page's own scripts, the API.
+	req, _ = http.NewRequest("GET", "/view/tasks", nil)
+	req.AddCookie(cookies[0])
+	w = suite.serve(req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/tasks", nil)
+	req.AddCookie(cookies[0])
+	w = suite.serve(req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *AuthControllerTestSuite) TestSecureCookie() {
+	defer Configure(DefaultSettings)
+	suite.register("ada@example.com", "correct horse")
+
+	login := func(overTLS bool) *http.Cookie {
+		form := url.Values{"email": {"ada@example.com"}, "password": {"correct horse"}}
+		req, _ := http.NewRequest("POST", "/view/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if overTLS {
+			req.TLS = &tls.ConnectionState{}
+		}
+		cookies := suite.serve(req).Result().Cookies()
+		suite.Require().Len(cookies, 1)
+		return cookies[0]
+	}
+
+	assert.False(suite.T(), login(false).Secure)
+	assert.True(suite.T(), login(true).Secure)
+
+	// Behind a proxy that terminates TLS the app only sees plain HTTP.
+	s := DefaultSettings
+	s.SecureCookies = true
+	Configure(s)
+	assert.True(suite.T(), login(false).Secure)
+}
+
+func (suite *AuthControllerTestSuite) TestViewLoginFailure() {
+	form := url.Values{"email": {"ada@example.com"}, "password": {"wrong horse"}}
+	req, _ := http.NewRequest("POST", "/view/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := suite.serve(req)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Invalid email or password")
+	assert.Empty(suite.T(), w.Result().Cookies())
+}
+
+func TestAuthControllerSuite(t *testing.T) {
+	suite.Run(t, new(AuthControllerTestSuite))
+}
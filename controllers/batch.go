@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"example.com/todo-rest-api/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxBatchSize caps the operations of one batch request.
+const maxBatchSize = 100
+
+// Batch operations, named in each operation's "op" field.
+const (
+	opCreate   = "create"
+	opUpdate   = "update"
+	opComplete = "complete"
+	opTag      = "tag"
+	opDelete   = "delete"
+)
+
+// errBatchFailed aborts the transaction of an atomic batch.
+var errBatchFailed = errors.New("batch operation failed")
+
+type batchRequest struct {
+	// Atomic rolls back every operation once one of them fails.
+	Atomic     bool             `json:"atomic"`
+	Operations []batchOperation `json:"operations"`
+}
+
+// batchOperation is one step of a batch. Which fields apply depends on Op:
+// Task for create, Patch (a merge patch) for update, Add and Remove for
+// tag, and Children (the subtask policy) for delete. Every operation but
+// create needs Id.
+type batchOperation struct {
+	Op       string                 `json:"op"`
+	Id       string                 `json:"id,omitempty"`
+	Task     *models.Task           `json:"task,omitempty"`
+	Patch    map[string]interface{} `json:"patch,omitempty"`
+	Add      []string               `json:"add,omitempty"`
+	Remove   []string               `json:"remove,omitempty"`
+	Children string                 `json:"children,omitempty"`
+}
+
+// batchResult reports the outcome of one operation with the status its
+// single-task endpoint would have answered with.
+type batchResult struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message,omitempty"`
+	Task    *models.Task `json:"task,omitempty"`
+}
+
+// BatchTasks runs a list of operations in order and answers with one
+// result per operation. Failed operations do not stop the ones after
+// them unless the batch is atomic: then the first failure rolls back the
+// whole batch, the response takes that operation's status and every other
+// operation reports 424 Failed Dependency.
+func (tc TaskController) BatchTasks(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	var request batchRequest
+
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+
+	if len(request.Operations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "A batch needs at least one operation"})
+		return
+	}
+	if len(request.Operations) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "A batch holds at most 100 operations"})
+		return
+	}
+
+	if !request.Atomic {
+		c.JSON(http.StatusOK, gin.H{"results": tc.runBatch(ctx, c, request.Operations, false)})
+		return
+	}
+
+	if tc.tx == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"message": "Atomic batches are not supported by this storage"})
+		return
+	}
+
+	var results []batchResult
+	err := tc.transaction(ctx, func(ctx context.Context) error {
+		// The transaction may be retried, so every attempt starts over.
+		results = tc.runBatch(ctx, c, request.Operations, true)
+		if failed(results) >= 0 {
+			return errBatchFailed
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBatchFailed) {
+		index := failed(results)
+		failure := results[index]
+
+		results = make([]batchResult, len(request.Operations))
+		for i := range results {
+			results[i] = batchResult{Status: http.StatusFailedDependency, Message: "Batch rolled back"}
+		}
+		results[index] = failure
+
+		c.JSON(failure.Status, gin.H{"message": "Batch rolled back", "results": results})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to run batch"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// runBatch runs operations in order. With stopOnFailure it leaves out the
+// operations after the first failure.
+func (tc TaskController) runBatch(ctx context.Context, c *gin.Context, operations []batchOperation, stopOnFailure bool) []batchResult {
+	results := make([]batchResult, 0, len(operations))
+
+	for _, operation := range operations {
+		result := tc.runOperation(ctx, c, operation)
+		results = append(results, result)
+
+		if stopOnFailure && result.Status >= http.StatusBadRequest {
+			break
+		}
+	}
+
+	return results
+}
+
+// failed returns the index of the first failed result, or -1.
+func failed(results []batchResult) int {
+	return slices.IndexFunc(results, func(result batchResult) bool {
+		return result.Status >= http.StatusBadRequest
+	})
+}
+
+func (tc TaskController) runOperation(ctx context.Context, c *gin.Context, operation batchOperation) batchResult {
+	if operation.Op == opCreate {
+		if operation.Task == nil {
+			return batchResult{Status: http.StatusBadRequest, Message: "Missing task"}
+		}
+
+		return taskResult(tc.insertTask(ctx, c, *operation.Task))
+	}
+
+	objectID, err := bson.ObjectIDFromHex(operation.Id)
+	if err != nil {
+		return batchResult{Status: http.StatusBadRequest, Message: "Invalid ID format"}
+	}
+
+	switch operation.Op {
+	case opUpdate:
+		return tc.batchUpdate(ctx, c, objectID, operation.Patch)
+	case opComplete:
+		return tc.batchComplete(ctx, c, objectID)
+	case opTag:
+		return tc.batchTag(ctx, c, objectID, operation.Add, operation.Remove)
+	case opDelete:
+		return tc.batchDelete(ctx, c, objectID, operation.Children)
+	default:
+		return batchResult{Status: http.StatusBadRequest, Message: "Unknown operation"}
+	}
+}
+
+func (tc TaskController) batchUpdate(ctx context.Context, c *gin.Context, id bson.ObjectID, patch map[string]interface{}) batchResult {
+	if patch == nil {
+		return batchResult{Status: http.StatusBadRequest, Message: "Missing patch"}
+	}
+
+	current, status, message := tc.lookupTask(ctx, c, id)
+	if status != http.StatusOK {
+		return batchResult{Status: status, Message: message}
+	}
+
+	task, err := applyMergePatch(current, patch)
+	if err != nil {
+		return batchResult{Status: http.StatusBadRequest, Message: "Invalid patch document"}
+	}
+	task.Id = id
+	task.OwnerId = current.OwnerId
+	task.Uid, task.ResourceName = current.Uid, current.ResourceName
+	task.DeletedAt = nil
+	task.SyncCompletion(tc.now())
+
+	return taskResult(tc.updateTask(ctx, c, task))
+}
+
+func (tc TaskController) batchComplete(ctx context.Context, c *gin.Context, id bson.ObjectID) batchResult {
+	task, status, message := tc.lookupTask(ctx, c, id)
+	if status != http.StatusOK {
+		return batchResult{Status: status, Message: message}
+	}
+
+	task.Completed = true
+	task.SyncCompletion(tc.now())
+
+	return taskResult(tc.updateTask(ctx, c, task))
+}
+
+func (tc TaskController) batchTag(ctx context.Context, c *gin.Context, id bson.ObjectID, add, remove []string) batchResult {
+	if len(add) == 0 && len(remove) == 0 {
+		return batchResult{Status: http.StatusBadRequest, Message: "Missing tags"}
+	}
+
+	task, status, message := tc.lookupTask(ctx, c, id)
+	if status != http.StatusOK {
+		return batchResult{Status: status, Message: message}
+	}
+
+	dropped := models.Task{Tags: remove}
+	dropped.NormalizeTags()
+
+	task.Tags = slices.Concat(task.Tags, add)
+	task.NormalizeTags()
+	task.Tags = slices.DeleteFunc(task.Tags, func(tag string) bool {
+		return slices.Contains(dropped.Tags, tag)
+	})
+
+	return taskResult(tc.updateTask(ctx, c, task))
+}
+
+func (tc TaskController) batchDelete(ctx context.Context, c *gin.Context, id bson.ObjectID, policy string) batchResult {
+	if policy == "" {
+		policy = childrenPromote
+	}
+	if policy != childrenPromote && policy != childrenCascade {
+		return batchResult{Status: http.StatusBadRequest, Message: "Invalid children policy"}
+	}
+
+	if _, status, message := tc.lookupTask(ctx, c, id); status != http.StatusOK {
+		return batchResult{Status: status, Message: message}
+	}
+
+	deleted, err := tc.deleteTask(ctx, c, id, policy)
+	if err != nil {
+		return batchResult{Status: http.StatusInternalServerError, Message: "Failed to delete task"}
+	}
+	if len(deleted) == 0 {
+		return batchResult{Status: http.StatusNotFound, Message: "Task not found"}
+	}
+
+	return batchResult{Status: http.StatusOK, Message: "Task deleted successfully"}
+}
+
+// taskResult turns the outcome of insertTask or updateTask into a result.
+func taskResult(task models.Task, status int, message string) batchResult {
+	if status >= http.StatusBadRequest {
+		return batchResult{Status: status, Message: message}
+	}
+
+	return batchResult{Status: status, Task: &task}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/todo-rest-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type BatchTestSuite struct {
+	controllerSuite
+	task models.Task
+}
+
+type batchResponse struct {
+	Message string        `json:"message"`
+	Results []batchResult `json:"results"`
+}
+
+func (suite *BatchTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.router.GET("/api/tasks", suite.controller.GetTasks)
+	suite.router.POST("/api/tasks/batch", func(c *gin.Context) { suite.controller.BatchTasks(c) })
+
+	var err error
+	suite.task, err = suite.repo.Insert(context.Background(), models.Task{Description: "Existing", Tags: []string{"home"}})
+	suite.Require().NoError(err)
+}
+
+func (suite *BatchTestSuite) batch(body interface{}) (*httptest.ResponseRecorder, batchResponse) {
+	w := suite.request("POST", "/api/tasks/batch", body)
+
+	var response batchResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func (suite *BatchTestSuite) TestPerOperationResults() {
+	w, response := suite.batch(gin.H{"operations": []gin.H{
+		{"op": "create", "task": gin.H{"description": "New", "tags": []string{" Work ", "work"}}},
+		{"op": "complete", "id": suite.task.Id.Hex()},
+		{"op": "tag", "id": suite.task.Id.Hex(), "add": []string{"Errand"}, "remove": []string{"HOME"}},
+		{"op": "delete", "id": "bogus"},
+		{"op": "launch", "id": suite.task.Id.Hex()},
+	}})
+
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Require().Len(response.Results, 5)
+
+	assert.Equal(suite.T(), http.StatusCreated, response.Results[0].Status)
+	assert.Equal(suite.T(), []string{"work"}, response.Results[0].Task.Tags)
+	assert.Equal(suite.T(), http.StatusOK, response.Results[1].Status)
+	assert.True(suite.T(), response.Results[1].Task.Completed)
+	assert.Equal(suite.T(), http.StatusOK, response.Results[2].Status)
+	assert.Equal(suite.T(), []string{"errand"}, response.Results[2].Task.Tags)
+	assert.Equal(suite.T(), http.StatusBadRequest, response.Results[3].Status)
+	assert.Equal(suite.T(), "Invalid ID format", response.Results[3].Message)
+	assert.Equal(suite.T(), "Unknown operation", response.Results[4].Message)
+
+	assert.Len(suite.T(), suite.stored(), 2)
+}
+
+func (suite *BatchTestSuite) TestUpdateAndDelete() {
+	w, response := suite.batch(gin.H{"operations": []gin.H{
+		{"op": "update", "id": suite.task.Id.Hex(), "patch": gin.H{"description": "Renamed"}},
+		{"op": "delete", "id": suite.task.Id.Hex()},
+		{"op": "delete", "id": suite.task.Id.Hex()},
+	}})
+
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "Renamed", response.Results[0].Task.Description)
+	assert.Equal(suite.T(), http.StatusOK, response.Results[1].Status)
+	assert.Equal(suite.T(), http.StatusNotFound, response.Results[2].Status)
+	assert.Empty(suite.T(), suite.stored())
+}
+
+func (suite *BatchTestSuite) TestAtomicRollsBack() {
+	w, response := suite.batch(gin.H{"atomic": true, "operations": []gin.H{
+		{"op": "create", "task": gin.H{"description": "New"}},
+		{"op": "complete", "id": suite.task.Id.Hex()},
+		{"op": "create", "task": gin.H{"description": "Orphan", "parentId": "65f000000000000000000000"}},
+		{"op": "delete", "id": suite.task.Id.Hex()},
+	}})
+
+	suite.Require().Equal(http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "Batch rolled back", response.Message)
+	suite.Require().Len(response.Results, 4)
+	assert.Equal(suite.T(), http.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(suite.T(), http.StatusFailedDependency, response.Results[1].Status)
+	assert.Equal(suite.T(), "Parent task not found", response.Results[2].Message)
+	assert.Equal(suite.T(), http.StatusFailedDependency, response.Results[3].Status)
+
+	tasks := suite.stored()
+	suite.Require().Len(tasks, 1)
+	assert.False(suite.T(), tasks[0].Completed)
+
+	history, err := suite.controller.history.List(context.Background(), suite.task.Id)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), history)
+}
+
+func (suite *BatchTestSuite) TestAtomicCommits() {
+	w, response := suite.batch(gin.H{"atomic": true, "operations": []gin.H{
+		{"op": "create", "task": gin.H{"description": "New"}},
+		{"op": "complete", "id": suite.task.Id.Hex()},
+	}})
+
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), http.StatusCreated, response.Results[0].Status)
+	assert.Len(suite.T(), suite.stored(), 2)
+}
+
+func (suite *BatchTestSuite) TestAtomicNeedsTransactions() {
+	suite.controller.tx = nil
+
+	w, _ := suite.batch(gin.H{"atomic": true, "operations": []gin.H{
+		{"op": "complete", "id": suite.task.Id.Hex()},
+	}})
+	assert.Equal(suite.T(), http.StatusNotImplemented, w.Code)
+}
+
+func (suite *BatchTestSuite) TestValidation() {
+	w, _ := suite.batch(gin.H{"operations": []gin.H{}})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	operations := make([]gin.H, maxBatchSize+1)
+	for i := range operations {
+		operations[i] = gin.H{"op": "complete", "id": suite.task.Id.Hex()}
+	}
+	w, _ = suite.batch(gin.H{"operations": operations})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *BatchTestSuite) TestTagFilter() {
+	suite.batch(gin.H{"operations": []gin.H{
+		{"op": "create", "task": gin.H{"description": "Work item", "tags": []string{"work"}}},
+	}})
+
+	w := suite.request("GET", "/api/tasks?tag=Work", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Work item")
+	assert.NotContains(suite.T(), w.Body.String(), "Existing")
+}
+
+func TestBatchSuite(t *testing.T) {
+	suite.Run(t, new(BatchTestSuite))
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// UndoDeleteAll brings back the tasks of a confirmed bulk delete, given the
+// same ?token= that confirmed it. Subtasks that were moved up a level stay
+// where they are.
+func (tc TaskController) UndoDeleteAll(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	deletion, ok := tc.takeDeletion(ctx, c, c.Query("token"), true)
+	if !ok {
+		return
+	}
+
+	var restoredCount int64
+	if len(deletion.TaskIds) > 0 {
+		var err error
+		restoredCount, err = tc.repo.SetDeleted(ctx, repository.TaskFilter{
+			Owner:   deletion.OwnerId,
+			Trashed: true,
+			Ids:     deletion.TaskIds,
+		}, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to restore tasks"})
+			return
+		}
+		tc.publishTasks(ctx, c, webhook.EventTaskCreated, deletion.TaskIds)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Delete undone successfully",
+		"restoredCount": restoredCount,
+	})
+}
+
+// pendingDeletion is a bulk delete waiting for confirmation.
+type pendingDeletion struct {
+	Token     string
+	Count     int
+	ExpiresAt time.Time
+}
+
+// requestDeleteAll is the first step of DeleteAllTasks: it records which
+// tasks match and hands out the token that confirms deleting them.
+func (tc TaskController) requestDeleteAll(ctx context.Context, c *gin.Context, filter repository.TaskFilter, policy string) {
+	pending, status, message := tc.prepareDeleteAll(ctx, c, filter, policy)
+	if status != http.StatusAccepted {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   "Repeat the request with the token to confirm",
+		"token":     pending.Token,
+		"count":     pending.Count,
+		"expiresAt": pending.ExpiresAt,
+	})
+}
+
+// prepareDeleteAll is requestDeleteAll without the response: it returns
+// the HTTP status to answer with and, on failure, the message to show.
+func (tc TaskController) prepareDeleteAll(ctx context.Context, c *gin.Context, filter repository.TaskFilter, policy string) (pendingDeletion, int, string) {
+	tasks, err := tc.repo.List(ctx, filter, repository.ListOptions{})
+	if err != nil {
+		return pendingDeletion{}, http.StatusInternalServerError, "Unable to fetch tasks"
+	}
+
+	ids := make([]bson.ObjectID, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.Id)
+	}
+
+	count := len(ids)
+	if policy == childrenCascade {
+		children, err := loadSubtasks(ctx, tc.repo, filter.Owner, tasks)
+		if err != nil {
+			return pendingDeletion{}, http.StatusInternalServerError, "Unable to fetch tasks"
+		}
+		for _, level := range children {
+			for _, child := range level {
+				if !slices.Contains(ids, child.Id) {
+					count++
+				}
+			}
+		}
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return pendingDeletion{}, http.StatusInternalServerError, "Failed to delete tasks"
+	}
+
+	deletion := models.Deletion{
+		TokenHash: hashToken(token),
+		OwnerId:   currentUser(c),
+		TaskIds:   ids,
+		Policy:    policy,
+		ExpiresAt: tc.now().Add(settings().ConfirmTTL).UTC(),
+	}
+	if err := tc.deletions.Insert(ctx, deletion); err != nil {
+		log.Println("Error storing deletion:", err)
+		return pendingDeletion{}, http.StatusInternalServerError, "Failed to delete tasks"
+	}
+
+	return pendingDeletion{Token: token, Count: count, ExpiresAt: deletion.ExpiresAt}, http.StatusAccepted, ""
+}
+
+// confirmDeleteAll is the second step of DeleteAllTasks: it deletes the
+// tasks recorded under token and keeps the record around for undo.
+func (tc TaskController) confirmDeleteAll(ctx context.Context, c *gin.Context, token string) {
+	deletion, status, message := tc.executeDeleteAll(ctx, c, token)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "All tasks deleted successfully",
+		"deletedCount": len(deletion.TaskIds),
+		"undoUntil":    deletion.ExpiresAt,
+	})
+}
+
+// executeDeleteAll is confirmDeleteAll without the response. It returns the
+// deletion as kept for undo, with the ids of the tasks deleted, along with
+// the HTTP status to answer with and, on failure, the message to show.
+func (tc TaskController) executeDeleteAll(ctx context.Context, c *gin.Context, token string) (models.Deletion, int, string) {
+	deletion, status, message := tc.claimDeletion(ctx, c, token, false)
+	if status != http.StatusOK {
+		return deletion, status, message
+	}
+
+	now := tc.now().UTC()
+
+	var deleted []bson.ObjectID
+	if len(deletion.TaskIds) > 0 {
+		var err error
+		deleted, err = tc.removeTasks(ctx, c, repository.TaskFilter{
+			Owner: deletion.OwnerId,
+			Ids:   deletion.TaskIds,
+		}, deletion.Policy, now)
+		if err != nil {
+			return deletion, http.StatusInternalServerError, "Failed to delete tasks"
+		}
+	}
+
+	if len(deleted) > 0 {
+		tc.publish(ctx, deletion.OwnerId, webhook.EventTasksCleared, clearedTasks{DeletedCount: len(deleted), Ids: deleted})
+	}
+
+	deletion.TaskIds = deleted
+	deletion.DeletedAt = &now
+	deletion.ExpiresAt = now.Add(settings().UndoWindow)
+	if err := tc.deletions.Insert(ctx, deletion); err != nil {
+		// The tasks are gone either way; they just cannot be undone in bulk.
+		log.Println("Error storing deletion:", err)
+	}
+
+	return deletion, http.StatusOK, ""
+}
+
+// takeDeletion looks up and removes the bulk delete behind token, so each
+// token confirms and undoes at most once. confirmed selects which step is
+// expected. On failure it writes a 400 or 500 response and returns false.
+func (tc TaskController) takeDeletion(ctx context.Context, c *gin.Context, token string, confirmed bool) (models.Deletion, bool) {
+	deletion, status, message := tc.claimDeletion(ctx, c, token, confirmed)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"message": message})
+		return deletion, false
+	}
+
+	return deletion, true
+}
+
+// claimDeletion is takeDeletion without the response: it returns the HTTP
+// status to answer with and, on failure, the message to show.
+func (tc TaskController) claimDeletion(ctx context.Context, c *gin.Context, token string, confirmed bool) (models.Deletion, int, string) {
+	if token == "" {
+		return models.Deletion{}, http.StatusBadRequest, "Invalid or expired token"
+	}
+
+	deletion, err := tc.deletions.Get(ctx, hashToken(token))
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return deletion, http.StatusInternalServerError, "Unable to fetch deletion"
+	}
+
+	valid := err == nil &&
+		ownedBy(c, deletion.OwnerId) &&
+		(deletion.DeletedAt != nil) == confirmed &&
+		tc.now().Before(deletion.ExpiresAt)
+	if valid {
+		err = tc.deletions.Delete(ctx, deletion.TokenHash)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return deletion, http.StatusInternalServerError, "Unable to fetch deletion"
+		}
+		valid = err == nil
+	}
+
+	if !valid {
+		return deletion, http.StatusBadRequest, "Invalid or expired token"
+	}
+
+	return deletion, http.StatusOK, ""
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// deleteAll runs both steps of DELETE url and returns the confirmation's
+// response.
+func deleteAll(t *testing.T, router *gin.Engine, url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", url, nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	separator := "?"
+	if strings.Contains(url, "?") {
+		separator = "&"
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", url+separator+"token="+response["token"].(string), nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+type BulkDeleteTestSuite struct {
+	controllerSuite
+}
+
+func (suite *BulkDeleteTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.router.GET("/api/tasks", suite.controller.GetTasks)
+	suite.router.DELETE("/api/tasks", suite.controller.DeleteAllTasks)
+	suite.router.POST("/api/tasks/undo", suite.controller.UndoDeleteAll)
+
+	for _, description := range []string{"Open", "Done"} {
+		_, err := suite.repo.Insert(context.Background(), models.Task{
+			Description: description,
+			Completed:   description == "Done",
+		})
+		suite.Require().NoError(err)
+	}
+}
+
+// call sends a request without a body and decodes the object answered.
+func (suite *BulkDeleteTestSuite) call(method, url string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	w := suite.request(method, url, nil)
+	return w, jsonObject(w)
+}
+
+func (suite *BulkDeleteTestSuite) remaining() int {
+	count, err := suite.repo.Count(context.Background(), repository.TaskFilter{})
+	suite.Require().NoError(err)
+	return int(count)
+}
+
+func (suite *BulkDeleteTestSuite) TestRequestDeletesNothing() {
+	w, response := suite.call("DELETE", "/api/tasks?status=done")
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+	assert.Equal(suite.T(), float64(1), response["count"])
+	assert.NotEmpty(suite.T(), response["token"])
+	assert.Equal(suite.T(), 2, suite.remaining())
+}
+
+func (suite *BulkDeleteTestSuite) TestConfirmDeletesTheCountedTasks() {
+	_, response := suite.call("DELETE", "/api/tasks?status=done")
+	token := response["token"].(string)
+
+	// Later filters are ignored: the token confirms what was counted.
+	w, response := suite.call("DELETE", "/api/tasks?token="+token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), float64(1), response["deletedCount"])
+	assert.Equal(suite.T(), 1, suite.remaining())
+}
+
+func (suite *BulkDeleteTestSuite) TestTokenExpires() {
+	_, response := suite.call("DELETE", "/api/tasks")
+	token := response["token"].(string)
+
+	suite.now = suite.now.Add(DefaultSettings.ConfirmTTL)
+
+	w, _ := suite.call("DELETE", "/api/tasks?token="+token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), 2, suite.remaining())
+
+	w, _ = suite.call("DELETE", "/api/tasks?token=bogus")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *BulkDeleteTestSuite) TestUndo() {
+	_, response := suite.call("DELETE", "/api/tasks")
+	token := response["token"].(string)
+
+	// A token cannot undo before it has confirmed.
+	w, _ := suite.call("POST", "/api/tasks/undo?token="+token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	suite.call("DELETE", "/api/tasks?token="+token)
+	assert.Equal(suite.T(), 0, suite.remaining())
+
+	w, response = suite.call("POST", "/api/tasks/undo?token="+token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), float64(2), response["restoredCount"])
+	assert.Equal(suite.T(), 2, suite.remaining())
+
+	w, _ = suite.call("POST", "/api/tasks/undo?token="+token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *BulkDeleteTestSuite) TestUndoWindowCloses() {
+	_, response := suite.call("DELETE", "/api/tasks")
+	token := response["token"].(string)
+	suite.call("DELETE", "/api/tasks?token="+token)
+
+	suite.now = suite.now.Add(DefaultSettings.UndoWindow)
+
+	w, _ := suite.call("POST", "/api/tasks/undo?token="+token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), 0, suite.remaining())
+}
+
+func TestBulkDeleteTestSuite(t *testing.T) {
+	suite.Run(t, new(BulkDeleteTestSuite))
+}
@@ -0,0 +1,791 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/todo-rest-api/codec"
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Paths of the CalDAV tree: the root doubles as the user's principal and
+// calendar home, holding the one calendar of all their tasks.
+const (
+	davRoot     = "/caldav/"
+	davCalendar = "/caldav/tasks/"
+)
+
+// XML namespaces of WebDAV, CalDAV and the calendarserver.org extensions.
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+)
+
+// davPrivileges is the current-user-privilege-set of the calendar home
+// and of the calendar in it. Users own all of their tasks, so both report
+// the same: some clients take the home's privileges for its calendars.
+const davPrivileges = "<D:privilege><D:read/></D:privilege><D:privilege><D:write/></D:privilege>" +
+	"<D:privilege><D:write-content/></D:privilege><D:privilege><D:bind/></D:privilege><D:privilege><D:unbind/></D:privilege>"
+
+// maxCalendarObjectSize caps the body of a PUT.
+const maxCalendarObjectSize = 1 << 20
+
+// davPrefixes are the prefixes multistatus responses declare.
+var davPrefixes = map[string]string{nsDAV: "D", nsCalDAV: "C", nsCS: "CS"}
+
+var (
+	propCalendarData = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propGetETag      = xml.Name{Space: nsDAV, Local: "getetag"}
+)
+
+// davRequest is the body of a PROPFIND or REPORT. Only the parts this
+// server acts on are decoded.
+type davRequest struct {
+	XMLName xml.Name
+	AllProp *struct{}  `xml:"DAV: allprop"`
+	Prop    *davProps  `xml:"DAV: prop"`
+	Hrefs   []string   `xml:"DAV: href"`
+	Filter  *davFilter `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+type davProps struct {
+	Names []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+// davFilter is a comp-filter of a calendar-query, with the nested
+// comp-filters and prop-filters it holds.
+type davFilter struct {
+	Name  string      `xml:"name,attr"`
+	Comps []davFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	Props []struct {
+		Name         string    `xml:"name,attr"`
+		IsNotDefined *struct{} `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	} `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+}
+
+// davResource is one response of a multistatus: the properties of the
+// resource at href as inner XML, or a status when it could not be found.
+type davResource struct {
+	href   string
+	props  map[xml.Name]string
+	status int
+}
+
+// calendarObject is a task as a resource of the calendar collection.
+type calendarObject struct {
+	task models.Task
+	href string
+	data string
+	etag string
+}
+
+// CalDAVOptions advertises CalDAV support to clients probing a URL.
+func (tc TaskController) CalDAVOptions(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT")
+	c.Status(http.StatusOK)
+}
+
+// RedirectCalDAV sends clients looking up /.well-known/caldav to the root
+// of the CalDAV tree.
+func (tc TaskController) RedirectCalDAV(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, davRoot)
+}
+
+// PropfindRoot describes the principal and calendar home and, at depth 1,
+// the tasks calendar inside it.
+func (tc TaskController) PropfindRoot(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	names, ok := parsePropfind(c)
+	if !ok {
+		return
+	}
+
+	resources := []davResource{{href: davRoot, props: map[xml.Name]string{
+		{Space: nsDAV, Local: "resourcetype"}:               "<D:collection/><D:principal/>",
+		{Space: nsDAV, Local: "displayname"}:                "Tasks",
+		{Space: nsDAV, Local: "current-user-principal"}:     davHref(davRoot),
+		{Space: nsDAV, Local: "principal-URL"}:              davHref(davRoot),
+		{Space: nsCalDAV, Local: "calendar-home-set"}:       davHref(davRoot),
+		{Space: nsDAV, Local: "current-user-privilege-set"}: davPrivileges,
+	}}}
+
+	if c.GetHeader("Depth") != "0" {
+		objects, err := tc.calendarObjects(ctx, c, repository.TaskFilter{Owner: currentUser(c)})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+			return
+		}
+		resources = append(resources, calendarResource(objects))
+	}
+
+	writeMultistatus(c, resources, names)
+}
+
+// PropfindCalendar describes the tasks calendar and, at depth 1, the
+// tasks in it.
+func (tc TaskController) PropfindCalendar(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	names, ok := parsePropfind(c)
+	if !ok {
+		return
+	}
+
+	objects, err := tc.calendarObjects(ctx, c, repository.TaskFilter{Owner: currentUser(c)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	resources := []davResource{calendarResource(objects)}
+	if c.GetHeader("Depth") != "0" {
+		for _, object := range objects {
+			resources = append(resources, objectResource(object, names))
+		}
+	}
+
+	writeMultistatus(c, resources, names)
+}
+
+// PropfindCalendarObject describes a single task.
+func (tc TaskController) PropfindCalendarObject(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	names, ok := parsePropfind(c)
+	if !ok {
+		return
+	}
+
+	task, status, message := tc.findCalendarObject(ctx, c, c.Param("name"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	object, err := tc.calendarObject(ctx, c, task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	writeMultistatus(c, []davResource{objectResource(object, names)}, names)
+}
+
+// ReportCalendar answers the calendar-query and calendar-multiget reports
+// clients sync with. A query matches all tasks, or only open ones when it
+// asks for VTODOs without a COMPLETED property; a query for any other
+// component matches nothing.
+func (tc TaskController) ReportCalendar(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	var request davRequest
+	if err := xml.NewDecoder(c.Request.Body).Decode(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid XML format"})
+		return
+	}
+
+	names := []xml.Name{propGetETag}
+	if request.Prop != nil {
+		names = propNames(request.Prop)
+	}
+
+	var resources []davResource
+
+	switch request.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		filter, ok := queryFilter(request.Filter)
+		if ok {
+			filter.Owner = currentUser(c)
+			objects, err := tc.calendarObjects(ctx, c, filter)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+				return
+			}
+			for _, object := range objects {
+				resources = append(resources, objectResource(object, names))
+			}
+		}
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		for _, href := range request.Hrefs {
+			resource, err := tc.multigetResource(ctx, c, href, names)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+				return
+			}
+			resources = append(resources, resource)
+		}
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"message": "Unsupported report"})
+		return
+	}
+
+	writeMultistatus(c, resources, names)
+}
+
+// GetCalendarObject downloads a single task as an iCalendar file.
+func (tc TaskController) GetCalendarObject(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	task, status, message := tc.findCalendarObject(ctx, c, c.Param("name"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	object, err := tc.calendarObject(ctx, c, task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	c.Header("ETag", object.etag)
+	if etagListed(c.GetHeader("If-None-Match"), object.etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(object.data))
+}
+
+// PutCalendarObject creates or replaces a task from an iCalendar file
+// holding one VTODO. If-Match and If-None-Match guard against overwriting
+// changes made elsewhere. The list and the recurrence mode of a task are
+// not part of the file and are kept.
+func (tc TaskController) PutCalendarObject(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	name := c.Param("name")
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCalendarObjectSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid calendar data"})
+		return
+	}
+	if len(body) > maxCalendarObjectSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Calendar data too large"})
+		return
+	}
+
+	todos, err := codec.ReadICS(bytes.NewReader(body))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": rowMessage(err)})
+		return
+	}
+	if len(todos) != 1 {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Calendar data must hold exactly one VTODO"})
+		return
+	}
+	todo := todos[0]
+	if todo.UID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid UID"})
+		return
+	}
+
+	existing, status, message := tc.findCalendarObject(ctx, c, name)
+	if status != http.StatusOK && status != http.StatusNotFound {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+	exists := status == http.StatusOK
+
+	etag := ""
+	if exists {
+		object, err := tc.calendarObject(ctx, c, existing)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch task"})
+			return
+		}
+		etag = object.etag
+	}
+	if !preconditionsMet(c, etag) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"message": "Task has changed"})
+		return
+	}
+
+	task := existing
+	if exists {
+		if todo.UID != codec.TaskUID(existing) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "UID cannot change"})
+			return
+		}
+	} else {
+		task, status, message = tc.newCalendarObject(ctx, c, name, todo.UID)
+		if status != http.StatusOK {
+			c.JSON(status, gin.H{"message": message})
+			return
+		}
+	}
+
+	task.Description = todo.Task.Description
+	task.DueAt = todo.Task.DueAt
+	task.Completed = todo.Task.Completed
+	task.CompletedAt = todo.Task.CompletedAt
+	task.Priority = todo.Task.Priority
+	task.Tags = todo.Task.Tags
+	if todo.Task.Recurrence != nil && task.Recurrence != nil {
+		todo.Task.Recurrence.Mode = task.Recurrence.Mode
+	}
+	task.Recurrence = todo.Task.Recurrence
+
+	task.ParentId, err = tc.resolveUID(ctx, c, todo.Parent)
+	if err == nil {
+		err = tc.dropMissingRefs(ctx, c, &task)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch task"})
+		return
+	}
+
+	status = http.StatusCreated
+	if exists {
+		task.SyncCompletion(tc.now())
+		_, status, message = tc.updateTask(ctx, c, task)
+		if status == http.StatusOK {
+			status = http.StatusNoContent
+		}
+	} else {
+		task, status, message = tc.insertTask(ctx, c, task)
+	}
+	if status != http.StatusCreated && status != http.StatusNoContent {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	// Report the ETag of the task as stored, which completing a recurring
+	// task may have changed.
+	if stored, err := tc.repo.Get(ctx, task.Id); err == nil {
+		if object, err := tc.calendarObject(ctx, c, stored); err == nil {
+			c.Header("ETag", object.etag)
+		}
+	}
+
+	c.Status(status)
+}
+
+// DeleteCalendarObject deletes a task. Its subtasks move up to its parent.
+func (tc TaskController) DeleteCalendarObject(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	task, status, message := tc.findCalendarObject(ctx, c, c.Param("name"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	object, err := tc.calendarObject(ctx, c, task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch task"})
+		return
+	}
+	if !preconditionsMet(c, object.etag) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"message": "Task has changed"})
+		return
+	}
+
+	deleted, err := tc.deleteTask(ctx, c, task.Id, childrenPromote)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete task"})
+		return
+	}
+	if len(deleted) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Task not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// findCalendarObject fetches the task stored under a resource name: the
+// name a client created it with or, for other tasks, "<id>.ics". It
+// returns the HTTP status to answer with and, on failure, the message to
+// show.
+func (tc TaskController) findCalendarObject(ctx context.Context, c *gin.Context, name string) (models.Task, int, string) {
+	tasks, err := tc.repo.List(ctx, repository.TaskFilter{Owner: currentUser(c), ResourceName: name}, repository.ListOptions{Limit: 1})
+	if err != nil {
+		return models.Task{}, http.StatusInternalServerError, "Unable to fetch task"
+	}
+	if len(tasks) > 0 {
+		return tasks[0], http.StatusOK, ""
+	}
+
+	hexID, ok := strings.CutSuffix(name, ".ics")
+	id, err := bson.ObjectIDFromHex(hexID)
+	if !ok || err != nil {
+		return models.Task{}, http.StatusNotFound, "Task not found"
+	}
+
+	task, status, message := tc.lookupTask(ctx, c, id)
+	if status == http.StatusOK && task.ResourceName != "" {
+		return task, http.StatusNotFound, "Task not found"
+	}
+	return task, status, message
+}
+
+// newCalendarObject prepares the task a PUT creates at name. A client
+// reusing a name this server handed out gets that id back; any other name
+// is stored as given. The UID is stored unless it is the one derived from
+// the id.
+func (tc TaskController) newCalendarObject(ctx context.Context, c *gin.Context, name, uid string) (models.Task, int, string) {
+	taken, err := tc.repo.List(ctx, repository.TaskFilter{Owner: currentUser(c), Uid: uid}, repository.ListOptions{Limit: 1})
+	if err != nil {
+		return models.Task{}, http.StatusInternalServerError, "Unable to fetch task"
+	}
+	if id, ok := codec.ParseUID(uid); ok && len(taken) == 0 {
+		if _, status, _ := tc.lookupTask(ctx, c, id); status != http.StatusNotFound {
+			taken = append(taken, models.Task{Id: id})
+		}
+	}
+	if len(taken) > 0 {
+		return models.Task{}, http.StatusForbidden, "UID already in use"
+	}
+
+	task := models.Task{Id: bson.NewObjectID(), ResourceName: name}
+
+	hexID, _ := strings.CutSuffix(name, ".ics")
+	if id, err := bson.ObjectIDFromHex(hexID); err == nil {
+		_, err := tc.repo.Get(ctx, id)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			task.Id, task.ResourceName = id, ""
+		case err != nil:
+			return task, http.StatusInternalServerError, "Unable to fetch task"
+		}
+	}
+
+	if uid != codec.UID(task.Id) {
+		task.Uid = uid
+	}
+
+	return task, http.StatusOK, ""
+}
+
+// resolveUID returns the id of the task with the given UID, or nil if
+// there is none.
+func (tc TaskController) resolveUID(ctx context.Context, c *gin.Context, uid string) (*bson.ObjectID, error) {
+	if uid == "" {
+		return nil, nil
+	}
+
+	tasks, err := tc.repo.List(ctx, repository.TaskFilter{Owner: currentUser(c), Uid: uid}, repository.ListOptions{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) > 0 {
+		return &tasks[0].Id, nil
+	}
+
+	if id, ok := codec.ParseUID(uid); ok {
+		return &id, nil
+	}
+	return nil, nil
+}
+
+// calendarObjects renders the tasks matching filter as calendar resources.
+func (tc TaskController) calendarObjects(ctx context.Context, c *gin.Context, filter repository.TaskFilter) ([]calendarObject, error) {
+	tasks, err := tc.repo.List(ctx, filter, repository.ListOptions{})
+	if err != nil {
+		log.Println("Error fetching tasks:", err)
+		return nil, err
+	}
+
+	parents, err := tc.parentUIDs(ctx, c, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]calendarObject, len(tasks))
+	for i, task := range tasks {
+		if objects[i], err = renderCalendarObject(task, parents); err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// calendarObject renders a single task as a calendar resource.
+func (tc TaskController) calendarObject(ctx context.Context, c *gin.Context, task models.Task) (calendarObject, error) {
+	parents, err := tc.parentUIDs(ctx, c, []models.Task{task})
+	if err != nil {
+		return calendarObject{}, err
+	}
+
+	return renderCalendarObject(task, parents)
+}
+
+// multigetResource describes the task at href for a calendar-multiget.
+func (tc TaskController) multigetResource(ctx context.Context, c *gin.Context, href string, names []xml.Name) (davResource, error) {
+	notFound := davResource{href: href, status: http.StatusNotFound}
+
+	decoded, err := url.PathUnescape(href)
+	if err != nil || path.Dir(decoded)+"/" != davCalendar {
+		return notFound, nil
+	}
+
+	task, status, _ := tc.findCalendarObject(ctx, c, path.Base(decoded))
+	switch status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return notFound, nil
+	default:
+		return notFound, errors.New("unable to fetch task")
+	}
+
+	object, err := tc.calendarObject(ctx, c, task)
+	if err != nil {
+		return notFound, err
+	}
+
+	return objectResource(object, names), nil
+}
+
+// renderCalendarObject renders task on its own. The ETag hashes the
+// rendered data, so it changes whenever the task as clients see it does.
+func renderCalendarObject(task models.Task, parents map[bson.ObjectID]string) (calendarObject, error) {
+	var data strings.Builder
+	if err := codec.WriteICS(&data, []models.Task{task}, parents); err != nil {
+		return calendarObject{}, err
+	}
+
+	name := task.ResourceName
+	if name == "" {
+		name = task.Id.Hex() + ".ics"
+	}
+
+	sum := sha256.Sum256([]byte(data.String()))
+	return calendarObject{
+		task: task,
+		href: davCalendar + url.PathEscape(name),
+		data: data.String(),
+		etag: `"` + hex.EncodeToString(sum[:]) + `"`,
+	}, nil
+}
+
+// calendarResource describes the tasks calendar. Its CTag hashes the
+// ETags of all tasks, so clients can skip syncing when it has not changed.
+func calendarResource(objects []calendarObject) davResource {
+	tags := make([]string, len(objects))
+	for i, object := range objects {
+		tags[i] = object.href + object.etag
+	}
+	sort.Strings(tags)
+	sum := sha256.Sum256([]byte(strings.Join(tags, "\n")))
+	ctag := hex.EncodeToString(sum[:])
+
+	return davResource{href: davCalendar, props: map[xml.Name]string{
+		{Space: nsDAV, Local: "resourcetype"}:                        "<D:collection/><C:calendar/>",
+		{Space: nsDAV, Local: "displayname"}:                         "Tasks",
+		{Space: nsDAV, Local: "current-user-principal"}:              davHref(davRoot),
+		{Space: nsDAV, Local: "getetag"}:                             `"` + ctag + `"`,
+		{Space: nsCS, Local: "getctag"}:                              ctag,
+		{Space: nsCalDAV, Local: "supported-calendar-component-set"}: `<C:comp name="VTODO"/>`,
+		{Space: nsDAV, Local: "supported-report-set"}: "<D:supported-report><D:report><C:calendar-query/></D:report></D:supported-report>" +
+			"<D:supported-report><D:report><C:calendar-multiget/></D:report></D:supported-report>",
+		{Space: nsDAV, Local: "current-user-privilege-set"}: davPrivileges,
+	}}
+}
+
+// objectResource describes a task. Its calendar data is only included
+// when asked for by name.
+func objectResource(object calendarObject, names []xml.Name) davResource {
+	props := map[xml.Name]string{
+		{Space: nsDAV, Local: "resourcetype"}:   "",
+		{Space: nsDAV, Local: "getetag"}:        xmlText(object.etag),
+		{Space: nsDAV, Local: "getcontenttype"}: "text/calendar; charset=utf-8; component=VTODO",
+		{Space: nsDAV, Local: "displayname"}:    xmlText(object.task.Description),
+	}
+	for _, name := range names {
+		if name == propCalendarData {
+			props[propCalendarData] = xmlText(object.data)
+		}
+	}
+
+	return davResource{href: object.href, props: props}
+}
+
+// queryFilter maps the comp-filter of a calendar-query to a task filter.
+// It returns false when the query cannot match any task.
+func queryFilter(filter *davFilter) (repository.TaskFilter, bool) {
+	var taskFilter repository.TaskFilter
+	if filter == nil {
+		return taskFilter, true
+	}
+	if !strings.EqualFold(filter.Name, "VCALENDAR") {
+		return taskFilter, false
+	}
+
+	for _, comp := range filter.Comps {
+		if !strings.EqualFold(comp.Name, "VTODO") {
+			return taskFilter, false
+		}
+		for _, prop := range comp.Props {
+			if strings.EqualFold(prop.Name, "COMPLETED") && prop.IsNotDefined != nil {
+				open := false
+				taskFilter.Completed = &open
+			}
+		}
+	}
+
+	return taskFilter, true
+}
+
+// parsePropfind reads the properties a PROPFIND asks for; nil means all of
+// them. On failure it writes a 400 response and returns false.
+func parsePropfind(c *gin.Context) ([]xml.Name, bool) {
+	var request davRequest
+	err := xml.NewDecoder(c.Request.Body).Decode(&request)
+	if errors.Is(err, io.EOF) || (err == nil && request.Prop == nil) {
+		return nil, true
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid XML format"})
+		return nil, false
+	}
+
+	return propNames(request.Prop), true
+}
+
+func propNames(props *davProps) []xml.Name {
+	names := make([]xml.Name, len(props.Names))
+	for i, prop := range props.Names {
+		names[i] = prop.XMLName
+	}
+	return names
+}
+
+// writeMultistatus answers with the requested properties of resources.
+// Properties a resource does not have are listed as not found; with names
+// nil, all properties are returned.
+func writeMultistatus(c *gin.Context, resources []davResource, names []xml.Name) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav" xmlns:CS="http://calendarserver.org/ns/">`)
+
+	for _, resource := range resources {
+		b.WriteString("<D:response><D:href>" + xmlText(resource.href) + "</D:href>")
+
+		if resource.status != 0 {
+			b.WriteString(davStatus(resource.status) + "</D:response>")
+			continue
+		}
+
+		requested := names
+		if requested == nil {
+			for name := range resource.props {
+				requested = append(requested, name)
+			}
+			sort.Slice(requested, func(i, j int) bool {
+				return requested[i].Space+requested[i].Local < requested[j].Space+requested[j].Local
+			})
+		}
+
+		var found, missing strings.Builder
+		for _, name := range requested {
+			if value, ok := resource.props[name]; ok {
+				writeProp(&found, name, value)
+			} else {
+				writeProp(&missing, name, "")
+			}
+		}
+
+		if found.Len() > 0 {
+			b.WriteString("<D:propstat><D:prop>" + found.String() + "</D:prop>" + davStatus(http.StatusOK) + "</D:propstat>")
+		}
+		if missing.Len() > 0 {
+			b.WriteString("<D:propstat><D:prop>" + missing.String() + "</D:prop>" + davStatus(http.StatusNotFound) + "</D:propstat>")
+		}
+		b.WriteString("</D:response>")
+	}
+
+	b.WriteString("</D:multistatus>")
+	c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", []byte(b.String()))
+}
+
+// writeProp writes a property element holding the given inner XML.
+// Properties of namespaces without a declared prefix declare their own.
+func writeProp(b *strings.Builder, name xml.Name, value string) {
+	tag, attr := name.Local, ""
+	if prefix, ok := davPrefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else {
+		tag, attr = "X:"+name.Local, ` xmlns:X="`+xmlText(name.Space)+`"`
+	}
+
+	if value == "" {
+		b.WriteString("<" + tag + attr + "/>")
+		return
+	}
+	b.WriteString("<" + tag + attr + ">" + value + "</" + tag + ">")
+}
+
+// preconditionsMet checks If-Match and If-None-Match against the ETag of
+// the resource, empty when it does not exist.
+func preconditionsMet(c *gin.Context, etag string) bool {
+	if match := c.GetHeader("If-Match"); match != "" {
+		if etag == "" || !etagListed(match, etag) {
+			return false
+		}
+	}
+
+	if noneMatch := c.GetHeader("If-None-Match"); noneMatch != "" {
+		if etag != "" && etagListed(noneMatch, etag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// etagListed reports whether a list of ETags, as in If-Match, holds etag
+// or is "*".
+func etagListed(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || (candidate != "" && candidate == etag) {
+			return true
+		}
+	}
+	return false
+}
+
+func davHref(href string) string {
+	return "<D:href>" + xmlText(href) + "</D:href>"
+}
+
+func davStatus(status int) string {
+	return "<D:status>HTTP/1.1 " + strconv.Itoa(status) + " " + http.StatusText(status) + "</D:status>"
+}
+
+// xmlText escapes text for use in XML content or attribute values.
+func xmlText(text string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
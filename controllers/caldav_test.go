@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CalDAVTestSuite struct {
+	controllerSuite
+}
+
+func (suite *CalDAVTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.router.Handle("PROPFIND", "/caldav/", suite.controller.PropfindRoot)
+	suite.router.Handle("PROPFIND", "/caldav/tasks/", suite.controller.PropfindCalendar)
+	suite.router.Handle("REPORT", "/caldav/tasks/", suite.controller.ReportCalendar)
+	suite.router.GET("/caldav/tasks/:name", suite.controller.GetCalendarObject)
+	suite.router.PUT("/caldav/tasks/:name", suite.controller.PutCalendarObject)
+	suite.router.DELETE("/caldav/tasks/:name", suite.controller.DeleteCalendarObject)
+}
+
+func (suite *CalDAVTestSuite) request(method, url, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return suite.serve(req)
+}
+
+func vtodo(uid, summary string, extra ...string) string {
+	lines := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0", "BEGIN:VTODO", "UID:" + uid, "SUMMARY:" + summary}, extra...)
+	return strings.Join(append(lines, "END:VTODO", "END:VCALENDAR"), "\r\n") + "\r\n"
+}
+
+func (suite *CalDAVTestSuite) TestPropfind() {
+	task, err := suite.repo.Insert(context.Background(), models.Task{Description: "Existing"})
+	suite.Require().NoError(err)
+
+	w := suite.request("PROPFIND", "/caldav/", `<?xml version="1.0"?>
+<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:current-user-principal/><C:calendar-home-set/><D:quota-used-bytes/></D:prop>
+</D:propfind>`, map[string]string{"Depth": "0"})
+	suite.Require().Equal(http.StatusMultiStatus, w.Code)
+	body := w.Body.String()
+	assert.Contains(suite.T(), body, "<C:calendar-home-set><D:href>/caldav/</D:href></C:calendar-home-set>")
+	assert.Contains(suite.T(), body, "<D:prop><D:quota-used-bytes/></D:prop><D:status>HTTP/1.1 404 Not Found</D:status>")
+	assert.NotContains(suite.T(), body, "/caldav/tasks/")
+
+	w = suite.request("PROPFIND", "/caldav/tasks/", "", map[string]string{"Depth": "1"})
+	suite.Require().Equal(http.StatusMultiStatus, w.Code)
+	body = w.Body.String()
+	assert.Contains(suite.T(), body, `<C:comp name="VTODO"/>`)
+	assert.Contains(suite.T(), body, "<D:href>/caldav/tasks/"+task.Id.Hex()+".ics</D:href>")
+	assert.Contains(suite.T(), body, "<CS:getctag>")
+	// Calendar data is only sent when asked for.
+	assert.NotContains(suite.T(), body, "BEGIN:VTODO")
+
+	// The home and its calendar grant the same privileges.
+	privileges := "<D:current-user-privilege-set>" + davPrivileges + "</D:current-user-privilege-set>"
+	assert.Contains(suite.T(), body, privileges)
+	w = suite.request("PROPFIND", "/caldav/", `<?xml version="1.0"?>
+<D:propfind xmlns:D="DAV:"><D:prop><D:current-user-privilege-set/></D:prop></D:propfind>`, map[string]string{"Depth": "1"})
+	suite.Require().Equal(http.StatusMultiStatus, w.Code)
+	assert.Equal(suite.T(), 2, strings.Count(w.Body.String(), privileges))
+}
+
+func (suite *CalDAVTestSuite) TestPutGetDelete() {
+	w := suite.request("PUT", "/caldav/tasks/client-1.ics", vtodo("client-1", "Buy milk", "DUE:20250311T090000Z", "CATEGORIES:Home"), map[string]string{"If-None-Match": "*"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	etag := w.Header().Get("ETag")
+	suite.Require().NotEmpty(etag)
+
+	tasks, err := suite.repo.List(context.Background(), repository.TaskFilter{}, repository.ListOptions{})
+	suite.Require().NoError(err)
+	suite.Require().Len(tasks, 1)
+	assert.Equal(suite.T(), "Buy milk", tasks[0].Description)
+	assert.Equal(suite.T(), "client-1", tasks[0].Uid)
+	assert.Equal(suite.T(), []string{"home"}, tasks[0].Tags)
+
+	w = suite.request("GET", "/caldav/tasks/client-1.ics", "", nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Equal(suite.T(), etag, w.Header().Get("ETag"))
+	assert.Contains(suite.T(), w.Body.String(), "UID:client-1\r\n")
+
+	// A second create at the same name, or an update against a stale ETag,
+	// fails.
+	w = suite.request("PUT", "/caldav/tasks/client-1.ics", vtodo("client-1", "Again"), map[string]string{"If-None-Match": "*"})
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+	w = suite.request("PUT", "/caldav/tasks/client-1.ics", vtodo("client-1", "Stale"), map[string]string{"If-Match": `"stale"`})
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+
+	w = suite.request("PUT", "/caldav/tasks/client-1.ics", vtodo("client-1", "Buy oat milk", "STATUS:COMPLETED"), map[string]string{"If-Match": etag})
+	suite.Require().Equal(http.StatusNoContent, w.Code, w.Body.String())
+	assert.NotEqual(suite.T(), etag, w.Header().Get("ETag"))
+
+	task, err := suite.repo.Get(context.Background(), tasks[0].Id)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Buy oat milk", task.Description)
+	assert.True(suite.T(), task.Completed)
+	assert.NotNil(suite.T(), task.CompletedAt)
+
+	w = suite.request("PUT", "/caldav/tasks/other.ics", vtodo("client-1", "Copy"), nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.request("DELETE", "/caldav/tasks/client-1.ics", "", map[string]string{"If-Match": etag})
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+	w = suite.request("DELETE", "/caldav/tasks/client-1.ics", "", nil)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	w = suite.request("GET", "/caldav/tasks/client-1.ics", "", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *CalDAVTestSuite) TestEditServerTask() {
+	parent, err := suite.repo.Insert(context.Background(), models.Task{Description: "Parent"})
+	suite.Require().NoError(err)
+	task, err := suite.repo.Insert(context.Background(), models.Task{Description: "Child"})
+	suite.Require().NoError(err)
+
+	name := "/caldav/tasks/" + task.Id.Hex() + ".ics"
+	w := suite.request("PUT", name, vtodo(task.Id.Hex()+"@todo-rest-api", "Renamed", "RELATED-TO:"+parent.Id.Hex()+"@todo-rest-api"), nil)
+	suite.Require().Equal(http.StatusNoContent, w.Code, w.Body.String())
+
+	task, err = suite.repo.Get(context.Background(), task.Id)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Renamed", task.Description)
+	assert.Equal(suite.T(), &parent.Id, task.ParentId)
+	assert.Empty(suite.T(), task.Uid)
+
+	w = suite.request("PUT", name, vtodo("changed", "Renamed"), nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request("PUT", name, "not a calendar", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *CalDAVTestSuite) TestReports() {
+	open, err := suite.repo.Insert(context.Background(), models.Task{Description: "Open"})
+	suite.Require().NoError(err)
+	_, err = suite.repo.Insert(context.Background(), models.Task{Description: "Done", Completed: true})
+	suite.Require().NoError(err)
+
+	query := `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/></D:prop>
+  <C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO">%s</C:comp-filter></C:comp-filter></C:filter>
+</C:calendar-query>`
+
+	w := suite.request("REPORT", "/caldav/tasks/", strings.Replace(query, "%s", "", 1), nil)
+	suite.Require().Equal(http.StatusMultiStatus, w.Code)
+	assert.Equal(suite.T(), 2, strings.Count(w.Body.String(), "BEGIN:VTODO"))
+
+	w = suite.request("REPORT", "/caldav/tasks/", strings.Replace(query, "%s", `<C:prop-filter name="COMPLETED"><C:is-not-defined/></C:prop-filter>`, 1), nil)
+	assert.Equal(suite.T(), 1, strings.Count(w.Body.String(), "BEGIN:VTODO"))
+	assert.Contains(suite.T(), w.Body.String(), "SUMMARY:Open")
+
+	w = suite.request("REPORT", "/caldav/tasks/", strings.Replace(query, "VTODO", "VEVENT", 1), nil)
+	assert.Equal(suite.T(), 0, strings.Count(w.Body.String(), "<D:response>"))
+
+	w = suite.request("REPORT", "/caldav/tasks/", `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/></D:prop>
+  <D:href>/caldav/tasks/`+open.Id.Hex()+`.ics</D:href>
+  <D:href>/caldav/tasks/missing.ics</D:href>
+</C:calendar-multiget>`, nil)
+	suite.Require().Equal(http.StatusMultiStatus, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "<D:getetag>")
+	assert.Contains(suite.T(), w.Body.String(), "<D:href>/caldav/tasks/missing.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>")
+
+	w = suite.request("REPORT", "/caldav/tasks/", `<D:sync-collection xmlns:D="DAV:"/>`, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func TestCalDAVSuite(t *testing.T) {
+	suite.Run(t, new(CalDAVTestSuite))
+}
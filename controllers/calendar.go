@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"example.com/todo-rest-api/codec"
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TaskFeed serves the tasks matching the list filters, e.g. ?list= or
+// ?tag=, as an iCalendar feed of VTODO components for calendar clients to
+// subscribe to.
+func (tc TaskController) TaskFeed(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	filter, ok := parseTaskFilter(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := tc.repo.List(ctx, filter, repository.ListOptions{})
+	if err != nil {
+		log.Println("Error fetching tasks:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	parents, err := tc.parentUIDs(ctx, c, tasks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := codec.WriteICS(c.Writer, tasks, parents); err != nil {
+		log.Println("Error writing calendar feed:", err)
+	}
+}
+
+// parentUIDs fetches the UIDs of the parents of tasks, for those parents
+// that were created by a calendar client and have their own.
+func (tc TaskController) parentUIDs(ctx context.Context, c *gin.Context, tasks []models.Task) (map[bson.ObjectID]string, error) {
+	uids := make(map[bson.ObjectID]string)
+
+	var ids []bson.ObjectID
+	for _, task := range tasks {
+		if task.ParentId != nil {
+			ids = append(ids, *task.ParentId)
+		}
+	}
+	if len(ids) == 0 {
+		return uids, nil
+	}
+
+	parents, err := tc.repo.List(ctx, repository.TaskFilter{Owner: currentUser(c), Ids: ids}, repository.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, parent := range parents {
+		if parent.Uid != "" {
+			uids[parent.Id] = parent.Uid
+		}
+	}
+
+	return uids, nil
+}
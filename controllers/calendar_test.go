@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CalendarTestSuite struct {
+	controllerSuite
+}
+
+func (suite *CalendarTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.router.GET("/api/tasks.ics", suite.controller.TaskFeed)
+}
+
+func (suite *CalendarTestSuite) TestFeed() {
+	due := time.Date(2025, 3, 12, 17, 0, 0, 0, time.UTC)
+	work, err := suite.repo.Insert(context.Background(), models.Task{Description: "Ship release", DueAt: &due, Tags: []string{"work"}})
+	suite.Require().NoError(err)
+	_, err = suite.repo.Insert(context.Background(), models.Task{Description: "Water plants", Completed: true})
+	suite.Require().NoError(err)
+
+	w := suite.request("GET", "/api/tasks.ics", nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), 2, strings.Count(w.Body.String(), "BEGIN:VTODO"))
+	assert.Contains(suite.T(), w.Body.String(), "UID:"+work.Id.Hex()+"@todo-rest-api\r\n")
+	assert.Contains(suite.T(), w.Body.String(), "DUE:20250312T170000Z\r\n")
+
+	w = suite.request("GET", "/api/tasks.ics?tag=work", nil)
+	assert.Equal(suite.T(), 1, strings.Count(w.Body.String(), "BEGIN:VTODO"))
+	assert.Contains(suite.T(), w.Body.String(), "SUMMARY:Ship release")
+
+	w = suite.request("GET", "/api/tasks.ics?list=inbox&status=done", nil)
+	assert.Equal(suite.T(), 1, strings.Count(w.Body.String(), "BEGIN:VTODO"))
+	assert.Contains(suite.T(), w.Body.String(), "STATUS:COMPLETED")
+
+	w = suite.request("GET", "/api/tasks.ics?list=bogus", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestCalendarSuite(t *testing.T) {
+	suite.Run(t, new(CalendarTestSuite))
+}
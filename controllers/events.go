@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// eventsKeepAlive is how often an idle event stream sends a comment, so
+// that proxies do not close it.
+const eventsKeepAlive = 15 * time.Second
+
+// eventsRetry is how long browsers wait before reconnecting to a stream
+// that broke off, in milliseconds.
+const eventsRetry = 3000
+
+// resetEvent tells a client that it missed events and has to fetch the
+// tasks again.
+const resetEvent = "reset"
+
+// pendingKey marks the context of a transaction, whose events wait for it
+// to commit.
+type pendingKey struct{}
+
+// event is a change to announce to webhooks and event streams.
+type event struct {
+	owner *bson.ObjectID
+	name  string
+	data  any
+}
+
+// deletedTask is the payload of task.deleted.
+type deletedTask struct {
+	Id bson.ObjectID `json:"id"`
+}
+
+// clearedTasks is the payload of tasks.cleared.
+type clearedTasks struct {
+	DeletedCount int             `json:"deletedCount"`
+	Ids          []bson.ObjectID `json:"ids"`
+}
+
+// decodeEvent reads the payload of an event: the task created or updated,
+// if any, and the ids of the tasks the event is about.
+func decodeEvent(e models.Event) (*models.Task, []bson.ObjectID, error) {
+	switch e.Name {
+	case webhook.EventTaskCreated, webhook.EventTaskUpdated:
+		var task models.Task
+		if err := json.Unmarshal(e.Data, &task); err != nil {
+			return nil, nil, err
+		}
+		return &task, []bson.ObjectID{task.Id}, nil
+	case webhook.EventTaskDeleted:
+		var deleted deletedTask
+		if err := json.Unmarshal(e.Data, &deleted); err != nil {
+			return nil, nil, err
+		}
+		return nil, []bson.ObjectID{deleted.Id}, nil
+	case webhook.EventTasksCleared:
+		var cleared clearedTasks
+		if err := json.Unmarshal(e.Data, &cleared); err != nil {
+			return nil, nil, err
+		}
+		return nil, cleared.Ids, nil
+	}
+
+	return nil, nil, nil
+}
+
+// publish announces a change to the tasks of owner. Inside
+// tc.transaction, the event is held back until the transaction commits.
+func (tc TaskController) publish(ctx context.Context, owner *bson.ObjectID, name string, data any) {
+	if tc.webhooks == nil && tc.events == nil {
+		return
+	}
+
+	if pending, ok := ctx.Value(pendingKey{}).(*[]event); ok {
+		*pending = append(*pending, event{owner: owner, name: name, data: data})
+		return
+	}
+
+	if tc.webhooks != nil {
+		tc.webhooks.Publish(owner, name, data)
+	}
+
+	if tc.events != nil {
+		body, err := json.Marshal(data)
+		if err == nil {
+			err = tc.events.Append(ctx, models.Event{OwnerId: owner, Name: name, Data: body, At: tc.now().UTC()})
+		}
+		if err != nil {
+			log.Println("Error appending event:", err)
+		}
+	}
+}
+
+// publishTasks announces name, e.g. task.updated, for each task of the
+// logged-in user with one of ids, as it is now. It is for changes written
+// to many tasks at once, which the storage does not return.
+func (tc TaskController) publishTasks(ctx context.Context, c *gin.Context, name string, ids []bson.ObjectID) {
+	if (tc.webhooks == nil && tc.events == nil) || len(ids) == 0 {
+		return
+	}
+
+	tasks, err := tc.repo.List(ctx, repository.TaskFilter{Owner: currentUser(c), Ids: ids}, repository.ListOptions{})
+	if err == nil {
+		tasks, err = tc.withSubtasks(ctx, c, tasks, false, tc.now())
+	}
+	if err != nil {
+		// The change is made either way; clients catch up on their next fetch.
+		log.Println("Error fetching tasks to announce:", err)
+		return
+	}
+
+	for _, task := range tasks {
+		tc.publish(ctx, task.OwnerId, name, task)
+	}
+}
+
+// transaction runs fn in a transaction of tc.tx. The events fn publishes
+// are only sent once the transaction commits, and never for one that is
+// rolled back.
+func (tc TaskController) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	var pending []event
+
+	err := tc.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		// A transaction may be retried, so every attempt starts over.
+		pending = nil
+		return fn(context.WithValue(ctx, pendingKey{}, &pending))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range pending {
+		tc.publish(ctx, e.owner, e.name, e.data)
+	}
+
+	return nil
+}
+
+// StreamEvents sends the task events of the logged-in user as Server-Sent
+// Events, from any instance sharing the storage. A client reconnecting
+// with Last-Event-ID gets the events it missed; when those are no longer
+// known, the stream starts with a reset event instead.
+func (tc TaskController) StreamEvents(c *gin.Context) {
+	tc.streamEvents(c, func(e models.Event) []byte { return e.Data })
+}
+
+// streamEvents is StreamEvents sending data(e) as the data of each event.
+func (tc TaskController) streamEvents(c *gin.Context, data func(models.Event) []byte) {
+	if tc.events == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"message": "Events are not supported by this storage"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	events, reset, err := tc.watchEvents(ctx, currentUser(c), c.GetHeader("Last-Event-ID"))
+	if err != nil {
+		log.Println("Error watching events:", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": "Unable to stream events"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", eventsRetry)
+	if reset {
+		// An empty id makes browsers forget the one they resumed from.
+		fmt.Fprintf(c.Writer, "id:\nevent: %s\ndata: {}\n\n", resetEvent)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", e.Id, e.Name, data(e))
+		case <-keepAlive.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		case <-ctx.Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// watchEvents watches the events of owner following the one with id after.
+// When that one is no longer known, it watches the events from now on and
+// reports that the caller missed some.
+func (tc TaskController) watchEvents(ctx context.Context, owner *bson.ObjectID, after string) (<-chan models.Event, bool, error) {
+	events, err := tc.events.Watch(ctx, owner, after)
+	if errors.Is(err, repository.ErrEventExpired) {
+		events, err = tc.events.Watch(ctx, owner, "")
+		return events, true, err
+	}
+
+	return events, false, err
+}
@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// sse is an event read from a stream.
+type sse struct {
+	id, name, data string
+}
+
+type EventsTestSuite struct {
+	controllerSuite
+	server *httptest.Server
+	// closeStreams ends the streams opened by the test.
+	closeStreams []context.CancelFunc
+}
+
+func (suite *EventsTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.controller.webhooks = nil
+	lc := NewListController(suite.store)
+
+	suite.router.POST("/api/task", suite.controller.CreateTask)
+	suite.router.DELETE("/api/task/:id", suite.controller.DeleteTask)
+	suite.router.POST("/api/task/:id/restore", suite.controller.RestoreTask)
+	suite.router.DELETE("/api/tasks", suite.controller.DeleteAllTasks)
+	suite.router.POST("/api/tasks/undo", suite.controller.UndoDeleteAll)
+	suite.router.POST("/api/tasks/batch", suite.controller.BatchTasks)
+	suite.router.POST("/api/trash/:id/restore", suite.controller.RestoreFromTrash)
+	suite.router.POST("/api/lists", lc.CreateList)
+	suite.router.DELETE("/api/lists/:id", lc.DeleteList)
+	suite.router.GET("/api/events", suite.controller.StreamEvents)
+	suite.server = httptest.NewServer(suite.router)
+	suite.closeStreams = nil
+}
+
+func (suite *EventsTestSuite) TearDownTest() {
+	for _, cancel := range suite.closeStreams {
+		cancel()
+	}
+	suite.server.Close()
+}
+
+// stream opens GET /api/events and returns the events it sends. The
+// stream is closed at the end of the test.
+func (suite *EventsTestSuite) stream(lastEventID string) <-chan sse {
+	ctx, cancel := context.WithCancel(context.Background())
+	suite.closeStreams = append(suite.closeStreams, cancel)
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", suite.server.URL+"/api/events", nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan sse, 10)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+
+		var event sse
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				event.id = value
+			case "event":
+				event.name = value
+			case "data":
+				event.data = value
+			case "":
+				if event.name != "" {
+					events <- event
+				}
+				event = sse{}
+			}
+		}
+	}()
+
+	return events
+}
+
+func (suite *EventsTestSuite) receive(events <-chan sse) sse {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		suite.FailNow("no event received")
+		return sse{}
+	}
+}
+
+func (suite *EventsTestSuite) post(url, body string) map[string]interface{} {
+	return suite.call("POST", url, body)
+}
+
+// call sends body to the test server and decodes the object answered.
+func (suite *EventsTestSuite) call(method, url, body string) map[string]interface{} {
+	req, _ := http.NewRequest(method, suite.server.URL+url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	var response map[string]interface{}
+	suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+	return response
+}
+
+// receiveAll receives n events and returns their names by the id of the
+// task they are about.
+func (suite *EventsTestSuite) receiveAll(events <-chan sse, n int) map[string]string {
+	names := map[string]string{}
+	for i := 0; i < n; i++ {
+		event := suite.receive(events)
+
+		var data struct {
+			Id string `json:"id"`
+		}
+		suite.Require().NoError(json.Unmarshal([]byte(event.data), &data))
+		names[data.Id] = event.name
+	}
+	return names
+}
+
+func (suite *EventsTestSuite) TestStream() {
+	events := suite.stream("")
+
+	task := suite.post("/api/task", `{"description": "Buy milk"}`)
+	created := suite.receive(events)
+	assert.Equal(suite.T(), "task.created", created.name)
+	assert.NotEmpty(suite.T(), created.id)
+
+	var data map[string]interface{}
+	suite.Require().NoError(json.Unmarshal([]byte(created.data), &data))
+	assert.Equal(suite.T(), task["id"], data["id"])
+	assert.Equal(suite.T(), "Buy milk", data["description"])
+
+	req, _ := http.NewRequest("DELETE", suite.server.URL+"/api/task/"+task["id"].(string), nil)
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	resp.Body.Close()
+
+	deleted := suite.receive(events)
+	assert.Equal(suite.T(), "task.deleted", deleted.name)
+	assert.JSONEq(suite.T(), `{"id": "`+task["id"].(string)+`"}`, deleted.data)
+}
+
+func (suite *EventsTestSuite) TestResume() {
+	events := suite.stream("")
+	suite.post("/api/task", `{"description": "Seen"}`)
+	seen := suite.receive(events)
+
+	suite.post("/api/task", `{"description": "Missed"}`)
+	suite.post("/api/task", `{"description": "Missed too"}`)
+
+	resumed := suite.stream(seen.id)
+	assert.Contains(suite.T(), suite.receive(resumed).data, `"Missed"`)
+	assert.Contains(suite.T(), suite.receive(resumed).data, `"Missed too"`)
+}
+
+func (suite *EventsTestSuite) TestResetWhenResumeUnknown() {
+	events := suite.stream("12345")
+	reset := suite.receive(events)
+	assert.Equal(suite.T(), resetEvent, reset.name)
+	assert.Empty(suite.T(), reset.id)
+
+	suite.post("/api/task", `{"description": "Live"}`)
+	assert.Equal(suite.T(), "task.created", suite.receive(events).name)
+}
+
+func (suite *EventsTestSuite) TestRolledBackBatchIsSilent() {
+	events := suite.stream("")
+
+	suite.post("/api/tasks/batch", `{"atomic": true, "operations": [
+		{"op": "create", "task": {"description": "New"}},
+		{"op": "create", "task": {"description": "Orphan", "parentId": "65f000000000000000000000"}}
+	]}`)
+	suite.post("/api/task", `{"description": "After"}`)
+
+	assert.Contains(suite.T(), suite.receive(events).data, `"After"`)
+}
+
+func (suite *EventsTestSuite) TestPromotedSubtasks() {
+	parent := suite.post("/api/task", `{"description": "Plan trip"}`)
+	child := suite.post("/api/task", `{"description": "Book flights", "parentId": "`+parent["id"].(string)+`"}`)
+
+	events := suite.stream("")
+	suite.call("DELETE", "/api/task/"+parent["id"].(string), "")
+
+	assert.Equal(suite.T(), map[string]string{
+		parent["id"].(string): "task.deleted",
+		child["id"].(string):  "task.updated",
+	}, suite.receiveAll(events, 2))
+}
+
+func (suite *EventsTestSuite) TestRestoreFromTrash() {
+	parent := suite.post("/api/task", `{"description": "Plan trip"}`)
+	child := suite.post("/api/task", `{"description": "Book flights", "parentId": "`+parent["id"].(string)+`"}`)
+	suite.call("DELETE", "/api/task/"+parent["id"].(string)+"?children=cascade", "")
+
+	events := suite.stream("")
+	suite.post("/api/trash/"+parent["id"].(string)+"/restore", "")
+
+	assert.Equal(suite.T(), map[string]string{
+		parent["id"].(string): "task.created",
+		child["id"].(string):  "task.created",
+	}, suite.receiveAll(events, 2))
+}
+
+func (suite *EventsTestSuite) TestRestoreVersion() {
+	task := suite.post("/api/task", `{"description": "Buy milk"}`)
+	suite.call("DELETE", "/api/task/"+task["id"].(string), "")
+
+	events := suite.stream("")
+	suite.post("/api/task/"+task["id"].(string)+"/restore?version=1", "")
+
+	restored := suite.receive(events)
+	assert.Equal(suite.T(), "task.created", restored.name)
+	assert.Contains(suite.T(), restored.data, `"Buy milk"`)
+}
+
+func (suite *EventsTestSuite) TestUndoDeleteAll() {
+	first := suite.post("/api/task", `{"description": "Buy milk"}`)
+	second := suite.post("/api/task", `{"description": "Pack"}`)
+	pending := suite.call("DELETE", "/api/tasks", "")
+	token := pending["token"].(string)
+	suite.call("DELETE", "/api/tasks?token="+token, "")
+
+	events := suite.stream("")
+	suite.post("/api/tasks/undo?token="+token, "")
+
+	assert.Equal(suite.T(), map[string]string{
+		first["id"].(string):  "task.created",
+		second["id"].(string): "task.created",
+	}, suite.receiveAll(events, 2))
+}
+
+func (suite *EventsTestSuite) TestDeleteListMovesTasksToInbox() {
+	list := suite.post("/api/lists", `{"name": "Groceries"}`)
+	task := suite.post("/api/task", `{"description": "Buy milk", "listId": "`+list["id"].(string)+`"}`)
+
+	events := suite.stream("")
+	suite.call("DELETE", "/api/lists/"+list["id"].(string), "")
+
+	moved := suite.receive(events)
+	assert.Equal(suite.T(), "task.updated", moved.name)
+	assert.Contains(suite.T(), moved.data, task["id"].(string))
+	assert.NotContains(suite.T(), moved.data, "listId")
+}
+
+func (suite *EventsTestSuite) TestDeleteListCascade() {
+	list := suite.post("/api/lists", `{"name": "Groceries"}`)
+	task := suite.post("/api/task", `{"description": "Buy milk", "listId": "`+list["id"].(string)+`"}`)
+	child := suite.post("/api/task", `{"description": "Oat milk", "parentId": "`+task["id"].(string)+`"}`)
+
+	events := suite.stream("")
+	suite.call("DELETE", "/api/lists/"+list["id"].(string)+"?tasks=cascade", "")
+
+	// The subtask is in the inbox, so it outlives its parent.
+	assert.Equal(suite.T(), map[string]string{
+		task["id"].(string):  "task.deleted",
+		child["id"].(string): "task.updated",
+	}, suite.receiveAll(events, 2))
+}
+
+func TestEventsTestSuite(t *testing.T) {
+	suite.Run(t, new(EventsTestSuite))
+}
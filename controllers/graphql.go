@@ -0,0 +1,941 @@
+package controllers
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/search"
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxGraphQLDepth bounds how deeply queries may nest, e.g. subtasks of
+// subtasks.
+const maxGraphQLDepth = 12
+
+//go:embed schema.graphql
+var graphQLSDL string
+
+// graphQLSchema serves every request. Its resolvers find the controller and
+// the request in their context, see graphQLScope.
+var graphQLSchema = graphql.MustParseSchema(graphQLSDL, &graphQLRoot{},
+	graphql.UseStringDescriptions(),
+	graphql.MaxDepth(maxGraphQLDepth),
+)
+
+// graphQLRequest is the body of POST /graphql.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQL runs a query or mutation and answers with its JSON result. A
+// request accepting text/event-stream gets the result as Server-Sent
+// Events instead, in the distinct connections mode of GraphQL over SSE,
+// which is how subscriptions are served.
+func (tc TaskController) GraphQL(c *gin.Context) {
+	var request graphQLRequest
+	if err := c.BindJSON(&request); err != nil || request.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid GraphQL request"})
+		return
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		tc.streamGraphQL(c, request)
+		return
+	}
+
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	response := graphQLSchema.Exec(tc.graphQLContext(ctx, c), request.Query, request.OperationName, request.Variables)
+	for _, err := range response.Errors {
+		// The library's wording assumes the GraphQL over WebSocket protocol.
+		if err.Message == "graphql-ws protocol header is missing" {
+			err.Message = "Subscriptions require Accept: text/event-stream"
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// streamGraphQL sends every result of the operation as a "next" event,
+// then "complete" once there are no more.
+func (tc TaskController) streamGraphQL(c *gin.Context, request graphQLRequest) {
+	ctx := repository.WithActor(c.Request.Context(), currentUser(c))
+
+	responses, err := graphQLSchema.Subscribe(tc.graphQLContext(ctx, c), request.Query, request.OperationName, request.Variables)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to run subscription"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case response, ok := <-responses:
+			if !ok {
+				fmt.Fprint(c.Writer, "event: complete\ndata:\n\n")
+				c.Writer.Flush()
+				return
+			}
+			data, err := json.Marshal(response)
+			if err != nil {
+				log.Println("Error encoding GraphQL response:", err)
+				return
+			}
+			fmt.Fprintf(c.Writer, "event: next\ndata: %s\n\n", data)
+		case <-keepAlive.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		case <-ctx.Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// graphQLScopeKey holds the graphQLScope of a request in its context.
+type graphQLScopeKey struct{}
+
+// graphQLScope is what resolvers share with the REST handlers: the
+// controller, and the request they act for.
+type graphQLScope struct {
+	tc TaskController
+	c  *gin.Context
+}
+
+func (tc TaskController) graphQLContext(ctx context.Context, c *gin.Context) context.Context {
+	return context.WithValue(ctx, graphQLScopeKey{}, graphQLScope{tc: tc, c: c})
+}
+
+func scopeOf(ctx context.Context) graphQLScope {
+	return ctx.Value(graphQLScopeKey{}).(graphQLScope)
+}
+
+// graphQLError is a failure that the REST API answers with status; the
+// status is passed on in the error's extensions.
+type graphQLError struct {
+	status  int
+	message string
+}
+
+func (e graphQLError) Error() string {
+	return e.message
+}
+
+func (e graphQLError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"status": e.status}
+}
+
+// parseGraphQLID turns an ID argument into an ObjectID.
+func parseGraphQLID(id graphql.ID) (bson.ObjectID, error) {
+	objectID, err := bson.ObjectIDFromHex(string(id))
+	if err != nil {
+		return objectID, graphQLError{http.StatusBadRequest, "Invalid ID format"}
+	}
+	return objectID, nil
+}
+
+// graphQLParams turns field arguments into request parameters, so that
+// they are read like the query string of the REST API. Nil arguments are
+// left out.
+func graphQLParams(args map[string]interface{}) params {
+	values := map[string]string{}
+	for key, arg := range args {
+		switch value := arg.(type) {
+		case *string:
+			if value != nil {
+				values[key] = *value
+			}
+		case *int32:
+			if value != nil {
+				values[key] = strconv.Itoa(int(*value))
+			}
+		}
+	}
+
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+// graphQLRoot resolves the fields of Query, Mutation and Subscription.
+type graphQLRoot struct{}
+
+type taskListArgs struct {
+	Status *string
+	Tag    *string
+	Q      *string
+	Sort   *string
+	Limit  *int32
+	Cursor *string
+}
+
+func (args taskListArgs) params(list *string) params {
+	// Enum values are the uppercase form of the REST API's.
+	var status *string
+	if args.Status != nil {
+		value := strings.ToLower(*args.Status)
+		status = &value
+	}
+
+	return graphQLParams(map[string]interface{}{
+		"status": status,
+		"list":   list,
+		"tag":    args.Tag,
+		"q":      args.Q,
+		"sort":   args.Sort,
+		"limit":  args.Limit,
+		"cursor": args.Cursor,
+	})
+}
+
+func (graphQLRoot) Task(ctx context.Context, args struct{ Id graphql.ID }) (*taskResolver, error) {
+	s := scopeOf(ctx)
+
+	objectID, err := parseGraphQLID(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	task, status, message := s.tc.lookupTask(ctx, s.c, objectID)
+	if status != http.StatusOK {
+		return nil, graphQLError{status, message}
+	}
+
+	tasks, err := s.tc.withSubtasks(ctx, s.c, []models.Task{task}, true, s.tc.now())
+	if err != nil {
+		return nil, graphQLError{http.StatusInternalServerError, "Unable to fetch tasks"}
+	}
+
+	return newTaskResolvers(tasks, true)[0], nil
+}
+
+func (graphQLRoot) Tasks(ctx context.Context, args struct {
+	taskListArgs
+	List *string
+}) (*taskPageResolver, error) {
+	return listTaskPage(ctx, args.params(args.List))
+}
+
+func (graphQLRoot) Lists(ctx context.Context) ([]*listResolver, error) {
+	s := scopeOf(ctx)
+
+	lists, err := s.tc.lists.List(ctx, currentUser(s.c))
+	if err != nil {
+		return nil, graphQLError{http.StatusInternalServerError, "Unable to fetch lists"}
+	}
+
+	resolvers := make([]*listResolver, 0, len(lists))
+	for _, list := range lists {
+		resolvers = append(resolvers, &listResolver{list})
+	}
+
+	return resolvers, nil
+}
+
+func (graphQLRoot) List(ctx context.Context, args struct{ Id graphql.ID }) (*listResolver, error) {
+	objectID, err := parseGraphQLID(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return findGraphQLList(ctx, objectID, http.StatusNotFound)
+}
+
+// findGraphQLList fetches a list of the logged-in user, failing with
+// missing, the status to report a list that is not there with.
+func findGraphQLList(ctx context.Context, id bson.ObjectID, missing int) (*listResolver, error) {
+	s := scopeOf(ctx)
+
+	list, err := s.tc.lists.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !ownedBy(s.c, list.OwnerId)) {
+		return nil, graphQLError{missing, "List not found"}
+	}
+	if err != nil {
+		return nil, graphQLError{http.StatusInternalServerError, "Unable to fetch list"}
+	}
+
+	return &listResolver{list}, nil
+}
+
+func (graphQLRoot) Tags(ctx context.Context) ([]*tagResolver, error) {
+	s := scopeOf(ctx)
+
+	counts, err := s.tc.repo.CountTags(ctx, repository.TaskFilter{Owner: currentUser(s.c)})
+	if err != nil {
+		log.Println("Error counting tags:", err)
+		return nil, graphQLError{http.StatusInternalServerError, "Unable to fetch tasks"}
+	}
+
+	tags := make([]*tagResolver, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, &tagResolver{name: name, count: int32(count)})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].count != tags[j].count {
+			return tags[i].count > tags[j].count
+		}
+		return tags[i].name < tags[j].name
+	})
+
+	return tags, nil
+}
+
+type taskInput struct {
+	Description string
+	Completed   *bool
+	DueAt       *graphql.Time
+	Priority    *int32
+	Tags        *[]string
+	Projects    *[]string
+	ListId      *graphql.ID
+	ParentId    *graphql.ID
+	Recurrence  *struct {
+		Rule     string
+		TimeZone *string
+		Start    *graphql.Time
+		Mode     *string
+	}
+}
+
+// task turns the input into the task that POST /api/task would bind.
+func (input taskInput) task() (models.Task, error) {
+	task := models.Task{Description: input.Description}
+
+	if input.Completed != nil {
+		task.Completed = *input.Completed
+	}
+	if input.DueAt != nil {
+		task.DueAt = &input.DueAt.Time
+	}
+	if input.Priority != nil {
+		task.Priority = int(*input.Priority)
+	}
+	if input.Tags != nil {
+		task.Tags = *input.Tags
+	}
+	if input.Projects != nil {
+		task.Projects = *input.Projects
+	}
+
+	for _, ref := range []struct {
+		id     *graphql.ID
+		target **bson.ObjectID
+	}{{input.ListId, &task.ListId}, {input.ParentId, &task.ParentId}} {
+		if ref.id == nil {
+			continue
+		}
+		objectID, err := parseGraphQLID(*ref.id)
+		if err != nil {
+			return task, err
+		}
+		*ref.target = &objectID
+	}
+
+	if r := input.Recurrence; r != nil {
+		task.Recurrence = &models.Recurrence{Rule: r.Rule}
+		if r.TimeZone != nil {
+			task.Recurrence.TimeZone = *r.TimeZone
+		}
+		if r.Start != nil {
+			task.Recurrence.Start = r.Start.Time
+		}
+		if r.Mode != nil {
+			task.Recurrence.Mode = *r.Mode
+		}
+	}
+
+	return task, nil
+}
+
+func (graphQLRoot) CreateTask(ctx context.Context, args struct{ Input taskInput }) (*taskResolver, error) {
+	s := scopeOf(ctx)
+
+	task, err := args.Input.task()
+	if err != nil {
+		return nil, err
+	}
+
+	task, status, message := s.tc.insertTask(ctx, s.c, task)
+	if status != http.StatusCreated {
+		return nil, graphQLError{status, message}
+	}
+
+	return newTaskResolvers([]models.Task{task}, false)[0], nil
+}
+
+func (graphQLRoot) DeleteTask(ctx context.Context, args struct {
+	Id       graphql.ID
+	Children string
+}) (*deletedTasksResolver, error) {
+	s := scopeOf(ctx)
+
+	objectID, err := parseGraphQLID(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, status, message := s.tc.lookupTask(ctx, s.c, objectID); status != http.StatusOK {
+		return nil, graphQLError{status, message}
+	}
+
+	deleted, err := s.tc.deleteTask(ctx, s.c, objectID, strings.ToLower(args.Children))
+	if err != nil {
+		return nil, graphQLError{http.StatusInternalServerError, "Failed to delete task"}
+	}
+	if len(deleted) == 0 {
+		return nil, graphQLError{http.StatusNotFound, "Task not found"}
+	}
+
+	return &deletedTasksResolver{deleted}, nil
+}
+
+func (graphQLRoot) RequestDeleteAllTasks(ctx context.Context, args struct {
+	Status   *string
+	List     *string
+	Tag      *string
+	Q        *string
+	Children string
+}) (*deletionRequestResolver, error) {
+	s := scopeOf(ctx)
+
+	query := taskListArgs{Status: args.Status, Tag: args.Tag, Q: args.Q}.params(args.List)
+	filter, message := readTaskFilter(query, currentUser(s.c))
+	if message != "" {
+		return nil, graphQLError{http.StatusBadRequest, message}
+	}
+
+	pending, status, message := s.tc.prepareDeleteAll(ctx, s.c, filter, strings.ToLower(args.Children))
+	if status != http.StatusAccepted {
+		return nil, graphQLError{status, message}
+	}
+
+	return &deletionRequestResolver{pending}, nil
+}
+
+func (graphQLRoot) DeleteAllTasks(ctx context.Context, args struct{ Token string }) (*deletedTasksResolver, error) {
+	s := scopeOf(ctx)
+
+	deletion, status, message := s.tc.executeDeleteAll(ctx, s.c, args.Token)
+	if status != http.StatusOK {
+		return nil, graphQLError{status, message}
+	}
+
+	return &deletedTasksResolver{deletion.TaskIds}, nil
+}
+
+func (graphQLRoot) TaskEvents(ctx context.Context, args struct{ After *string }) (<-chan *taskEventResolver, error) {
+	s := scopeOf(ctx)
+	if s.tc.events == nil {
+		return nil, graphQLError{http.StatusNotImplemented, "Events are not supported by this storage"}
+	}
+
+	after := ""
+	if args.After != nil {
+		after = *args.After
+	}
+
+	events, reset, err := s.tc.watchEvents(ctx, currentUser(s.c), after)
+	if err != nil {
+		log.Println("Error watching events:", err)
+		return nil, graphQLError{http.StatusServiceUnavailable, "Unable to stream events"}
+	}
+
+	resolvers := make(chan *taskEventResolver)
+	go func() {
+		defer close(resolvers)
+
+		if reset {
+			select {
+			case resolvers <- &taskEventResolver{name: resetEvent}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		for e := range events {
+			resolver, err := newTaskEventResolver(e)
+			if err != nil {
+				log.Println("Error decoding event:", err)
+				continue
+			}
+
+			select {
+			case resolvers <- resolver:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return resolvers, nil
+}
+
+// listTaskPage resolves a TaskPage the way GET /api/tasks lists tasks.
+func listTaskPage(ctx context.Context, query params) (*taskPageResolver, error) {
+	s := scopeOf(ctx)
+
+	filter, p, message := readTaskQuery(query, currentUser(s.c), "created", defaultPageSize)
+	if message != "" {
+		return nil, graphQLError{http.StatusBadRequest, message}
+	}
+
+	tasks, err := s.tc.repo.List(ctx, filter, p.query())
+	if err != nil {
+		log.Println("Error fetching tasks:", err)
+		return nil, graphQLError{http.StatusInternalServerError, "Unable to fetch tasks"}
+	}
+
+	tasks, hasMore := p.trim(tasks)
+	tasks, err = s.tc.withSubtasks(ctx, s.c, tasks, true, s.tc.now())
+	if err != nil {
+		log.Println("Error fetching subtasks:", err)
+		return nil, graphQLError{http.StatusInternalServerError, "Unable to fetch tasks"}
+	}
+
+	terms := search.Terms(filter.Text)
+	if len(terms) > 0 {
+		for i := range tasks {
+			tasks[i].Highlight = search.Highlight(tasks[i].Description, terms, snippetWidth)
+		}
+	}
+	page := &taskPageResolver{filter: filter, items: newTaskResolvers(tasks, true)}
+	if hasMore {
+		cursor := p.nextCursor()
+		page.nextCursor = &cursor
+	}
+
+	return page, nil
+}
+
+type taskPageResolver struct {
+	filter     repository.TaskFilter
+	items      []*taskResolver
+	nextCursor *string
+}
+
+func (r *taskPageResolver) Items() []*taskResolver {
+	return r.items
+}
+
+func (r *taskPageResolver) NextCursor() *string {
+	return r.nextCursor
+}
+
+// TotalCount is only counted when asked for, like ?count=true.
+func (r *taskPageResolver) TotalCount(ctx context.Context) (int32, error) {
+	total, err := scopeOf(ctx).tc.repo.Count(ctx, r.filter)
+	if err != nil {
+		log.Println("Error counting tasks:", err)
+		return 0, graphQLError{http.StatusInternalServerError, "Unable to fetch tasks"}
+	}
+
+	return int32(total), nil
+}
+
+type taskResolver struct {
+	task  models.Task
+	batch *taskBatch
+}
+
+// taskBatch is a set of tasks resolved together, e.g. the items of a page
+// or the subtasks of those. The parents, lists and subtasks of its tasks
+// are fetched for all of them at once, the first time one asks, rather
+// than with queries for each task.
+type taskBatch struct {
+	tasks []models.Task
+	// tree reports whether the tasks carry their subtasks in Children.
+	tree bool
+
+	parentsOnce sync.Once
+	parents     map[bson.ObjectID]*taskResolver
+	parentsErr  error
+
+	listsOnce sync.Once
+	lists     map[bson.ObjectID]models.List
+	listsErr  error
+
+	subtasksOnce sync.Once
+	subtasks     map[bson.ObjectID][]*taskResolver
+	subtasksErr  error
+}
+
+// newTaskResolvers resolves tasks as one batch. With tree, the tasks come
+// from withSubtasks in tree mode.
+func newTaskResolvers(tasks []models.Task, tree bool) []*taskResolver {
+	batch := &taskBatch{tasks: tasks, tree: tree}
+
+	resolvers := make([]*taskResolver, 0, len(tasks))
+	for _, task := range tasks {
+		resolvers = append(resolvers, &taskResolver{task: task, batch: batch})
+	}
+	return resolvers
+}
+
+// parent returns the parent of a task of the batch.
+func (b *taskBatch) parent(ctx context.Context, id bson.ObjectID) (*taskResolver, error) {
+	b.parentsOnce.Do(func() {
+		s := scopeOf(ctx)
+
+		ids := []bson.ObjectID{}
+		for _, task := range b.tasks {
+			if task.ParentId != nil {
+				ids = append(ids, *task.ParentId)
+			}
+		}
+
+		parents, err := s.tc.repo.List(ctx, repository.TaskFilter{Owner: currentUser(s.c), Ids: ids}, repository.ListOptions{})
+		if err == nil {
+			parents, err = s.tc.withSubtasks(ctx, s.c, parents, true, s.tc.now())
+		}
+		if err != nil {
+			b.parentsErr = err
+			return
+		}
+
+		b.parents = make(map[bson.ObjectID]*taskResolver, len(parents))
+		for _, parent := range newTaskResolvers(parents, true) {
+			b.parents[parent.task.Id] = parent
+		}
+	})
+
+	if b.parentsErr != nil {
+		log.Println("Error fetching tasks:", b.parentsErr)
+		return nil, graphQLError{http.StatusInternalServerError, "Unable to fetch tasks"}
+	}
+	parent, ok := b.parents[id]
+	if !ok {
+		return nil, graphQLError{http.StatusNotFound, "Task not found"}
+	}
+	return parent, nil
+}
+
+// list returns the list of a task of the batch.
+func (b *taskBatch) list(ctx context.Context, id bson.ObjectID) (*listResolver, error) {
+	b.listsOnce.Do(func() {
+		s := scopeOf(ctx)
+
+		lists, err := s.tc.lists.List(ctx, currentUser(s.c))
+		if err != nil {
+			b.listsErr = err
+			return
+		}
+
+		b.lists = make(map[bson.ObjectID]models.List, len(lists))
+		for _, list := range lists {
+			b.lists[list.Id] = list
+		}
+	})
+
+	if b.listsErr != nil {
+		log.Println("Error fetching lists:", b.listsErr)
+		return nil, graphQLError{http.StatusInternalServerError, "Unable to fetch list"}
+	}
+	list, ok := b.lists[id]
+	if !ok {
+		return nil, graphQLError{http.StatusNotFound, "List not found"}
+	}
+	return &listResolver{list}, nil
+}
+
+// subtasksOf returns the subtasks of a task of the batch. They make up a
+// batch of their own, together with those of the other tasks.
+func (b *taskBatch) subtasksOf(ctx context.Context, id bson.ObjectID) ([]*taskResolver, error) {
+	b.subtasksOnce.Do(func() {
+		var children []models.Task
+		if b.tree {
+			for _, task := range b.tasks {
+				children = append(children, task.Children...)
+			}
+		} else {
+			s := scopeOf(ctx)
+
+			ids := make([]bson.ObjectID, 0, len(b.tasks))
+			for _, task := range b.tasks {
+				ids = append(ids, task.Id)
+			}
+
+			var err error
+			children, err = s.tc.repo.List(ctx, repository.TaskFilter{Owner: currentUser(s.c), Parents: ids}, repository.ListOptions{})
+			if err == nil {
+				children, err = s.tc.withSubtasks(ctx, s.c, children, true, s.tc.now())
+			}
+			if err != nil {
+				b.subtasksErr = err
+				return
+			}
+		}
+
+		b.subtasks = make(map[bson.ObjectID][]*taskResolver)
+		for _, child := range newTaskResolvers(children, true) {
+			b.subtasks[*child.task.ParentId] = append(b.subtasks[*child.task.ParentId], child)
+		}
+	})
+
+	if b.subtasksErr != nil {
+		log.Println("Error fetching subtasks:", b.subtasksErr)
+		return nil, graphQLError{http.StatusInternalServerError, "Unable to fetch tasks"}
+	}
+	return append([]*taskResolver{}, b.subtasks[id]...), nil
+}
+
+func (r *taskResolver) Id() graphql.ID {
+	return graphql.ID(r.task.Id.Hex())
+}
+
+func (r *taskResolver) Description() string {
+	return r.task.Description
+}
+
+func (r *taskResolver) Completed() bool {
+	return r.task.Completed
+}
+
+func (r *taskResolver) CompletedAt() *graphql.Time {
+	return graphQLTime(r.task.CompletedAt)
+}
+
+func (r *taskResolver) DueAt() *graphql.Time {
+	return graphQLTime(r.task.DueAt)
+}
+
+func (r *taskResolver) Overdue() bool {
+	return r.task.Overdue
+}
+
+func (r *taskResolver) DueToday() bool {
+	return r.task.DueToday
+}
+
+func (r *taskResolver) Priority() *int32 {
+	if r.task.Priority == 0 {
+		return nil
+	}
+	priority := int32(r.task.Priority)
+	return &priority
+}
+
+func (r *taskResolver) Tags() []string {
+	return append([]string{}, r.task.Tags...)
+}
+
+func (r *taskResolver) Projects() []string {
+	return append([]string{}, r.task.Projects...)
+}
+
+func (r *taskResolver) Recurrence() *recurrenceResolver {
+	if r.task.Recurrence == nil {
+		return nil
+	}
+	return &recurrenceResolver{*r.task.Recurrence}
+}
+
+func (r *taskResolver) List(ctx context.Context) (*listResolver, error) {
+	if r.task.ListId == nil {
+		return nil, nil
+	}
+	return r.batch.list(ctx, *r.task.ListId)
+}
+
+func (r *taskResolver) Parent(ctx context.Context) (*taskResolver, error) {
+	if r.task.ParentId == nil {
+		return nil, nil
+	}
+	return r.batch.parent(ctx, *r.task.ParentId)
+}
+
+// Subtasks resolves like GET /api/task/:id/children.
+func (r *taskResolver) Subtasks(ctx context.Context) ([]*taskResolver, error) {
+	return r.batch.subtasksOf(ctx, r.task.Id)
+}
+
+func (r *taskResolver) Progress() *progressResolver {
+	if r.task.Progress == nil {
+		return nil
+	}
+	return &progressResolver{*r.task.Progress}
+}
+
+func (r *taskResolver) Next() *taskResolver {
+	if r.task.Next == nil {
+		return nil
+	}
+	return newTaskResolvers([]models.Task{*r.task.Next}, false)[0]
+}
+
+func (r *taskResolver) Highlight() *string {
+	if r.task.Highlight == "" {
+		return nil
+	}
+	return &r.task.Highlight
+}
+
+func (r *taskResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.task.Id.Timestamp()}
+}
+
+func graphQLTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
+
+type recurrenceResolver struct {
+	recurrence models.Recurrence
+}
+
+func (r *recurrenceResolver) Rule() string {
+	return r.recurrence.Rule
+}
+
+func (r *recurrenceResolver) TimeZone() *string {
+	if r.recurrence.TimeZone == "" {
+		return nil
+	}
+	return &r.recurrence.TimeZone
+}
+
+func (r *recurrenceResolver) Start() graphql.Time {
+	return graphql.Time{Time: r.recurrence.Start}
+}
+
+func (r *recurrenceResolver) Mode() *string {
+	if r.recurrence.Mode == "" {
+		return nil
+	}
+	return &r.recurrence.Mode
+}
+
+type progressResolver struct {
+	progress models.Progress
+}
+
+func (r *progressResolver) Done() int32 {
+	return int32(r.progress.Done)
+}
+
+func (r *progressResolver) Total() int32 {
+	return int32(r.progress.Total)
+}
+
+type listResolver struct {
+	list models.List
+}
+
+func (r *listResolver) Id() graphql.ID {
+	return graphql.ID(r.list.Id.Hex())
+}
+
+func (r *listResolver) Name() string {
+	return r.list.Name
+}
+
+func (r *listResolver) Tasks(ctx context.Context, args taskListArgs) (*taskPageResolver, error) {
+	list := r.list.Id.Hex()
+	return listTaskPage(ctx, args.params(&list))
+}
+
+type tagResolver struct {
+	name  string
+	count int32
+}
+
+func (r *tagResolver) Name() string {
+	return r.name
+}
+
+func (r *tagResolver) Count() int32 {
+	return r.count
+}
+
+type deletedTasksResolver struct {
+	ids []bson.ObjectID
+}
+
+func (r *deletedTasksResolver) Ids() []graphql.ID {
+	return graphQLIDs(r.ids)
+}
+
+type deletionRequestResolver struct {
+	pending pendingDeletion
+}
+
+func (r *deletionRequestResolver) Token() string {
+	return r.pending.Token
+}
+
+func (r *deletionRequestResolver) Count() int32 {
+	return int32(r.pending.Count)
+}
+
+func (r *deletionRequestResolver) ExpiresAt() graphql.Time {
+	return graphql.Time{Time: r.pending.ExpiresAt}
+}
+
+type taskEventResolver struct {
+	id   string
+	name string
+	task *models.Task
+	ids  []bson.ObjectID
+}
+
+func newTaskEventResolver(e models.Event) (*taskEventResolver, error) {
+	task, ids, err := decodeEvent(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskEventResolver{id: e.Id, name: e.Name, task: task, ids: ids}, nil
+}
+
+func (r *taskEventResolver) Id() string {
+	return r.id
+}
+
+func (r *taskEventResolver) Event() string {
+	return r.name
+}
+
+func (r *taskEventResolver) Task() *taskResolver {
+	if r.task == nil {
+		return nil
+	}
+	return newTaskResolvers([]models.Task{*r.task}, false)[0]
+}
+
+func (r *taskEventResolver) TaskIds() []graphql.ID {
+	return graphQLIDs(r.ids)
+}
+
+func graphQLIDs(ids []bson.ObjectID) []graphql.ID {
+	result := make([]graphql.ID, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, graphql.ID(id.Hex()))
+	}
+	return result
+}
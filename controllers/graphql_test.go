@@ -0,0 +1,320 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// graphQLResult is the response to a GraphQL request.
+type graphQLResult struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+type GraphQLTestSuite struct {
+	controllerSuite
+	server *httptest.Server
+	// closeStreams ends the subscriptions opened by the test.
+	closeStreams []context.CancelFunc
+}
+
+func (suite *GraphQLTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.controller.webhooks = nil
+
+	suite.router.POST("/graphql", suite.controller.GraphQL)
+	suite.server = httptest.NewServer(suite.router)
+	suite.closeStreams = nil
+}
+
+func (suite *GraphQLTestSuite) TearDownTest() {
+	for _, cancel := range suite.closeStreams {
+		cancel()
+	}
+	suite.server.Close()
+}
+
+func (suite *GraphQLTestSuite) exec(query string, variables map[string]interface{}) graphQLResult {
+	w := suite.request("POST", "/graphql", map[string]interface{}{"query": query, "variables": variables})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var result graphQLResult
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &result))
+	return result
+}
+
+// createTask adds a task through the createTask mutation and returns
+// its id.
+func (suite *GraphQLTestSuite) createTask(input map[string]interface{}) string {
+	result := suite.exec(`mutation($input: TaskInput!) { createTask(input: $input) { id } }`,
+		map[string]interface{}{"input": input})
+	suite.Require().Empty(result.Errors)
+	return result.Data["createTask"].(map[string]interface{})["id"].(string)
+}
+
+func (suite *GraphQLTestSuite) TestCreateAndGetTask() {
+	parent := suite.createTask(map[string]interface{}{"description": "Plan trip", "tags": []string{"Travel"}, "priority": 2})
+	suite.createTask(map[string]interface{}{"description": "Book flights", "parentId": parent})
+
+	result := suite.exec(`query($id: ID!) {
+		task(id: $id) { description tags priority progress { done total } subtasks { description parent { id } } }
+	}`, map[string]interface{}{"id": parent})
+	suite.Require().Empty(result.Errors)
+
+	task := result.Data["task"].(map[string]interface{})
+	assert.Equal(suite.T(), "Plan trip", task["description"])
+	assert.Equal(suite.T(), []interface{}{"travel"}, task["tags"])
+	assert.Equal(suite.T(), float64(2), task["priority"])
+	assert.Equal(suite.T(), map[string]interface{}{"done": float64(0), "total": float64(1)}, task["progress"])
+
+	subtasks := task["subtasks"].([]interface{})
+	suite.Require().Len(subtasks, 1)
+	assert.Equal(suite.T(), map[string]interface{}{
+		"description": "Book flights",
+		"parent":      map[string]interface{}{"id": parent},
+	}, subtasks[0])
+}
+
+func (suite *GraphQLTestSuite) TestTasksFilterAndPagination() {
+	suite.createTask(map[string]interface{}{"description": "Buy milk", "tags": []string{"shop"}})
+	suite.createTask(map[string]interface{}{"description": "Buy bread", "tags": []string{"shop"}})
+	suite.createTask(map[string]interface{}{"description": "Buy stamps", "completed": true, "tags": []string{"shop"}})
+	suite.createTask(map[string]interface{}{"description": "Call mom"})
+
+	query := `query($cursor: String) {
+		tasks(status: OPEN, tag: "shop", limit: 1, cursor: $cursor) { items { description } nextCursor totalCount }
+	}`
+
+	first := suite.exec(query, nil)
+	suite.Require().Empty(first.Errors)
+	page := first.Data["tasks"].(map[string]interface{})
+	assert.Equal(suite.T(), []interface{}{map[string]interface{}{"description": "Buy milk"}}, page["items"])
+	assert.Equal(suite.T(), float64(2), page["totalCount"])
+	suite.Require().NotNil(page["nextCursor"])
+
+	second := suite.exec(query, map[string]interface{}{"cursor": page["nextCursor"]})
+	suite.Require().Empty(second.Errors)
+	page = second.Data["tasks"].(map[string]interface{})
+	assert.Equal(suite.T(), []interface{}{map[string]interface{}{"description": "Buy bread"}}, page["items"])
+	assert.Nil(suite.T(), page["nextCursor"])
+
+	search := suite.exec(`{ tasks(q: "milk") { items { highlight } } }`, nil)
+	suite.Require().Empty(search.Errors)
+	items := search.Data["tasks"].(map[string]interface{})["items"].([]interface{})
+	suite.Require().Len(items, 1)
+	assert.Contains(suite.T(), items[0].(map[string]interface{})["highlight"], "milk")
+
+	tags := suite.exec(`{ tags { name count } }`, nil)
+	suite.Require().Empty(tags.Errors)
+	assert.Equal(suite.T(), []interface{}{map[string]interface{}{"name": "shop", "count": float64(3)}}, tags.Data["tags"])
+}
+
+func (suite *GraphQLTestSuite) TestListTasks() {
+	list, err := suite.controller.lists.Insert(context.Background(), models.List{Name: "Groceries"})
+	suite.Require().NoError(err)
+	suite.createTask(map[string]interface{}{"description": "Buy milk", "listId": list.Id.Hex()})
+	suite.createTask(map[string]interface{}{"description": "Call mom"})
+
+	result := suite.exec(`{ lists { name tasks { items { description list { name } } } } }`, nil)
+	suite.Require().Empty(result.Errors)
+	assert.Equal(suite.T(), []interface{}{map[string]interface{}{
+		"name": "Groceries",
+		"tasks": map[string]interface{}{"items": []interface{}{map[string]interface{}{
+			"description": "Buy milk",
+			"list":        map[string]interface{}{"name": "Groceries"},
+		}}},
+	}}, result.Data["lists"])
+}
+
+// countingTasks counts the queries made to the tasks it wraps.
+type countingTasks struct {
+	repository.TaskRepository
+	queries atomic.Int32
+}
+
+func (r *countingTasks) List(ctx context.Context, filter repository.TaskFilter, opts repository.ListOptions) ([]models.Task, error) {
+	r.queries.Add(1)
+	return r.TaskRepository.List(ctx, filter, opts)
+}
+
+func (suite *GraphQLTestSuite) TestNestedFieldsAreBatched() {
+	list, err := suite.controller.lists.Insert(context.Background(), models.List{Name: "Trip"})
+	suite.Require().NoError(err)
+
+	tasks := &countingTasks{TaskRepository: suite.controller.repo}
+	controller := *suite.controller
+	controller.repo = tasks
+	router := gin.New()
+	router.POST("/graphql", controller.GraphQL)
+
+	query := func() int32 {
+		tasks.queries.Store(0)
+
+		body, _ := json.Marshal(map[string]interface{}{"query": `{
+			tasks { items { list { name } parent { description } subtasks { list { name } parent { description } subtasks { description } } } }
+		}`})
+		req, _ := http.NewRequest("POST", "/graphql", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var result graphQLResult
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &result))
+		suite.Require().Empty(result.Errors)
+		return tasks.queries.Load()
+	}
+
+	addTrip := func() {
+		parent := suite.createTask(map[string]interface{}{"description": "Plan trip", "listId": list.Id.Hex()})
+		for i := 0; i < 2; i++ {
+			suite.createTask(map[string]interface{}{"description": "Book", "parentId": parent, "listId": list.Id.Hex()})
+		}
+	}
+
+	addTrip()
+	few := query()
+	for i := 0; i < 5; i++ {
+		addTrip()
+	}
+
+	// More tasks take no more queries.
+	assert.Equal(suite.T(), few, query())
+}
+
+func (suite *GraphQLTestSuite) TestCreateTaskValidation() {
+	cases := map[string]struct {
+		input   map[string]interface{}
+		message string
+	}{
+		"invalid parent": {map[string]interface{}{"description": "Orphan", "parentId": "nope"}, "Invalid ID format"},
+		"unknown parent": {map[string]interface{}{"description": "Orphan", "parentId": "65f000000000000000000000"}, "Parent task not found"},
+		"recurring without due date": {map[string]interface{}{
+			"description": "Water plants",
+			"recurrence":  map[string]interface{}{"rule": "FREQ=WEEKLY"},
+		}, "Recurring tasks need a due date"},
+	}
+
+	for name, tc := range cases {
+		suite.Run(name, func() {
+			result := suite.exec(`mutation($input: TaskInput!) { createTask(input: $input) { id } }`,
+				map[string]interface{}{"input": tc.input})
+			suite.Require().Len(result.Errors, 1)
+			assert.Equal(suite.T(), tc.message, result.Errors[0].Message)
+			assert.Equal(suite.T(), float64(http.StatusBadRequest), result.Errors[0].Extensions["status"])
+		})
+	}
+}
+
+func (suite *GraphQLTestSuite) TestDeleteTask() {
+	parent := suite.createTask(map[string]interface{}{"description": "Plan trip"})
+	child := suite.createTask(map[string]interface{}{"description": "Book flights", "parentId": parent})
+
+	result := suite.exec(`mutation($id: ID!) { deleteTask(id: $id, children: CASCADE) { ids } }`,
+		map[string]interface{}{"id": parent})
+	suite.Require().Empty(result.Errors)
+	assert.ElementsMatch(suite.T(), []interface{}{parent, child}, result.Data["deleteTask"].(map[string]interface{})["ids"])
+
+	result = suite.exec(`mutation($id: ID!) { deleteTask(id: $id) { ids } }`, map[string]interface{}{"id": parent})
+	suite.Require().Len(result.Errors, 1)
+	assert.Equal(suite.T(), "Task not found", result.Errors[0].Message)
+	assert.Equal(suite.T(), float64(http.StatusNotFound), result.Errors[0].Extensions["status"])
+}
+
+func (suite *GraphQLTestSuite) TestDeleteAllTasks() {
+	suite.createTask(map[string]interface{}{"description": "Buy milk"})
+	suite.createTask(map[string]interface{}{"description": "Buy stamps", "completed": true})
+
+	request := suite.exec(`mutation { requestDeleteAllTasks(status: DONE) { token count } }`, nil)
+	suite.Require().Empty(request.Errors)
+	pending := request.Data["requestDeleteAllTasks"].(map[string]interface{})
+	assert.Equal(suite.T(), float64(1), pending["count"])
+
+	confirm := `mutation($token: String!) { deleteAllTasks(token: $token) { ids } }`
+	deleted := suite.exec(confirm, map[string]interface{}{"token": pending["token"]})
+	suite.Require().Empty(deleted.Errors)
+	assert.Len(suite.T(), deleted.Data["deleteAllTasks"].(map[string]interface{})["ids"], 1)
+
+	// A token confirms only once.
+	again := suite.exec(confirm, map[string]interface{}{"token": pending["token"]})
+	suite.Require().Len(again.Errors, 1)
+
+	remaining := suite.exec(`{ tasks { items { description } } }`, nil)
+	assert.Equal(suite.T(), []interface{}{map[string]interface{}{"description": "Buy milk"}},
+		remaining.Data["tasks"].(map[string]interface{})["items"])
+}
+
+func (suite *GraphQLTestSuite) TestInvalidRequest() {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`{"variables": {}}`))
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	result := suite.exec(`{ task(id: "nope") { id } }`, nil)
+	suite.Require().Len(result.Errors, 1)
+	assert.Equal(suite.T(), "Invalid ID format", result.Errors[0].Message)
+}
+
+func (suite *GraphQLTestSuite) TestSubscriptionNeedsEventStream() {
+	result := suite.exec(`subscription { taskEvents { event } }`, nil)
+	suite.Require().Len(result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "text/event-stream")
+}
+
+func (suite *GraphQLTestSuite) TestSubscription() {
+	ctx, cancel := context.WithCancel(context.Background())
+	suite.closeStreams = append(suite.closeStreams, cancel)
+
+	body := `{"query": "subscription { taskEvents { event task { description } taskIds } }"}`
+	req, _ := http.NewRequestWithContext(ctx, "POST", suite.server.URL+"/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), "text/event-stream", resp.Header.Get("Content-Type"))
+
+	id := suite.createTask(map[string]interface{}{"description": "Buy milk"})
+
+	scanner := bufio.NewScanner(resp.Body)
+	var name, data string
+	for scanner.Scan() && data == "" {
+		field, value, _ := strings.Cut(scanner.Text(), ": ")
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			data = value
+		}
+	}
+	assert.Equal(suite.T(), "next", name)
+
+	var result graphQLResult
+	suite.Require().NoError(json.Unmarshal([]byte(data), &result))
+	suite.Require().Empty(result.Errors)
+	assert.Equal(suite.T(), map[string]interface{}{
+		"event":   "task.created",
+		"task":    map[string]interface{}{"description": "Buy milk"},
+		"taskIds": []interface{}{id},
+	}, result.Data["taskEvents"])
+}
+
+func TestGraphQLTestSuite(t *testing.T) {
+	suite.Run(t, new(GraphQLTestSuite))
+}
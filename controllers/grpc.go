@@ -0,0 +1,535 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/search"
+	"example.com/todo-rest-api/taskpb"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcCodes maps the HTTP statuses the controller's helpers fail with to
+// gRPC codes.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusGone:                codes.FailedPrecondition,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+// updateMaskPaths are the fields Update may change.
+var updateMaskPaths = []string{
+	"description", "completed", "due_at", "priority", "tags", "projects", "recurrence", "list_id", "parent_id",
+}
+
+// TaskService serves taskpb.TaskService on top of a TaskController, so that
+// gRPC clients get the same validation, history and events as REST ones.
+type TaskService struct {
+	taskpb.UnimplementedTaskServiceServer
+	tc TaskController
+}
+
+func NewTaskService(tc *TaskController) *TaskService {
+	return &TaskService{tc: *tc}
+}
+
+// NewGRPCServer returns a gRPC server offering TaskService to callers
+// authenticated by ac, along with server reflection.
+func NewGRPCServer(tc *TaskController, ac *AuthController) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(ac.UnaryAuth),
+		grpc.StreamInterceptor(ac.StreamAuth),
+	)
+	taskpb.RegisterTaskServiceServer(server, NewTaskService(tc))
+	reflection.Register(server)
+
+	return server
+}
+
+// grpcUserKey holds the id of the authenticated user in the context of a
+// call.
+type grpcUserKey struct{}
+
+// UnaryAuth rejects calls without a valid session token, sent as
+// "authorization: Bearer <token>" metadata like the API's bearer tokens.
+func (ac AuthController) UnaryAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := ac.authenticateCall(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamAuth is UnaryAuth for streaming calls.
+func (ac AuthController) StreamAuth(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := ac.authenticateCall(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticateCall returns ctx with the id of the user whose token the
+// call carries.
+func (ac AuthController) authenticateCall(ctx context.Context) (context.Context, error) {
+	var token string
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		if value := strings.TrimPrefix(header, "Bearer "); value != header {
+			token = strings.TrimSpace(value)
+		}
+	}
+
+	authCtx, cancel := ac.getContext()
+	defer cancel()
+
+	session, ok := ac.authenticate(authCtx, token)
+	if !ok {
+		return ctx, status.Error(codes.Unauthenticated, "Authentication required")
+	}
+
+	return context.WithValue(ctx, grpcUserKey{}, session.UserId), nil
+}
+
+// authenticatedStream is a stream whose context carries its caller.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcCaller stands in for the request a REST handler would pass to the
+// controller's helpers. It only carries the user the call was
+// authenticated as; without one, as for REST, nothing is scoped.
+func grpcCaller(ctx context.Context) *gin.Context {
+	c := &gin.Context{}
+	if userID, ok := ctx.Value(grpcUserKey{}).(bson.ObjectID); ok {
+		c.Set(userKey, userID)
+	}
+	return c
+}
+
+// getContext is TaskController.getContext for a call, which it ends along
+// with the caller.
+func (s *TaskService) getContext(ctx context.Context, c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(repository.WithActor(ctx, currentUser(c)), settings().RequestTimeout)
+}
+
+// grpcError turns an HTTP status and message of the controller's helpers
+// into the equivalent gRPC status.
+func grpcError(httpStatus int, message string) error {
+	code, ok := grpcCodes[httpStatus]
+	if !ok {
+		code = codes.Unknown
+	}
+	return status.Error(code, message)
+}
+
+func parseProtoID(id string) (bson.ObjectID, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return objectID, status.Error(codes.InvalidArgument, "Invalid ID format")
+	}
+	return objectID, nil
+}
+
+func (s *TaskService) Create(ctx context.Context, req *taskpb.CreateRequest) (*taskpb.Task, error) {
+	c := grpcCaller(ctx)
+	ctx, cancel := s.getContext(ctx, c)
+	defer cancel()
+
+	var task models.Task
+	if err := applyTaskFields(&task, req.GetTask(), updateMaskPaths); err != nil {
+		return nil, err
+	}
+
+	task, code, message := s.tc.insertTask(ctx, c, task)
+	if code != http.StatusCreated {
+		return nil, grpcError(code, message)
+	}
+
+	return taskToProto(task), nil
+}
+
+func (s *TaskService) Get(ctx context.Context, req *taskpb.GetRequest) (*taskpb.Task, error) {
+	c := grpcCaller(ctx)
+	ctx, cancel := s.getContext(ctx, c)
+	defer cancel()
+
+	objectID, err := parseProtoID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	task, code, message := s.tc.lookupTask(ctx, c, objectID)
+	if code != http.StatusOK {
+		return nil, grpcError(code, message)
+	}
+
+	tasks, err := s.tc.withSubtasks(ctx, c, []models.Task{task}, false, s.tc.now())
+	if err != nil {
+		log.Println("Error fetching subtasks:", err)
+		return nil, status.Error(codes.Internal, "Unable to fetch tasks")
+	}
+
+	return taskToProto(tasks[0]), nil
+}
+
+// List reads the tasks a page of maxPageSize at a time, sending each page
+// before fetching the next.
+func (s *TaskService) List(req *taskpb.ListRequest, stream taskpb.TaskService_ListServer) error {
+	c := grpcCaller(stream.Context())
+
+	query := filterParams(req.GetFilter(), map[string]string{"sort": req.GetSort()})
+	filter, p, message := readTaskQuery(query, currentUser(c), "created", maxPageSize)
+	if message != "" {
+		return status.Error(codes.InvalidArgument, message)
+	}
+
+	terms := search.Terms(filter.Text)
+	remaining := int(req.GetLimit())
+	if remaining < 0 {
+		return status.Error(codes.InvalidArgument, "Invalid limit parameter")
+	}
+	for {
+		tasks, hasMore, err := s.listPage(stream.Context(), c, filter, &p)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if len(terms) > 0 {
+				task.Highlight = search.Highlight(task.Description, terms, snippetWidth)
+			}
+			if err := stream.Send(taskToProto(task)); err != nil {
+				return err
+			}
+
+			if remaining--; remaining == 0 {
+				return nil
+			}
+		}
+
+		if !hasMore {
+			return nil
+		}
+		p = p.next()
+	}
+}
+
+func (s *TaskService) listPage(ctx context.Context, c *gin.Context, filter repository.TaskFilter, p *page) ([]models.Task, bool, error) {
+	ctx, cancel := s.getContext(ctx, c)
+	defer cancel()
+
+	tasks, err := s.tc.repo.List(ctx, filter, p.query())
+	if err != nil {
+		log.Println("Error fetching tasks:", err)
+		return nil, false, status.Error(codes.Internal, "Unable to fetch tasks")
+	}
+
+	tasks, hasMore := p.trim(tasks)
+	tasks, err = s.tc.withSubtasks(ctx, c, tasks, false, s.tc.now())
+	if err != nil {
+		log.Println("Error fetching subtasks:", err)
+		return nil, false, status.Error(codes.Internal, "Unable to fetch tasks")
+	}
+
+	return tasks, hasMore, nil
+}
+
+func (s *TaskService) Update(ctx context.Context, req *taskpb.UpdateRequest) (*taskpb.Task, error) {
+	c := grpcCaller(ctx)
+	ctx, cancel := s.getContext(ctx, c)
+	defer cancel()
+
+	objectID, err := parseProtoID(req.GetTask().GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = updateMaskPaths
+	}
+
+	task, code, message := s.tc.lookupTask(ctx, c, objectID)
+	if code != http.StatusOK {
+		return nil, grpcError(code, message)
+	}
+
+	if err := applyTaskFields(&task, req.GetTask(), paths); err != nil {
+		return nil, err
+	}
+	task.SyncCompletion(s.tc.now())
+
+	task, code, message = s.tc.updateTask(ctx, c, task)
+	if code != http.StatusOK {
+		return nil, grpcError(code, message)
+	}
+
+	return taskToProto(task), nil
+}
+
+func (s *TaskService) Delete(ctx context.Context, req *taskpb.DeleteRequest) (*taskpb.DeleteResponse, error) {
+	c := grpcCaller(ctx)
+	ctx, cancel := s.getContext(ctx, c)
+	defer cancel()
+
+	objectID, err := parseProtoID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, code, message := s.tc.lookupTask(ctx, c, objectID); code != http.StatusOK {
+		return nil, grpcError(code, message)
+	}
+
+	deleted, err := s.tc.deleteTask(ctx, c, objectID, childrenPolicy(req.GetChildren()))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to delete task")
+	}
+	if len(deleted) == 0 {
+		return nil, status.Error(codes.NotFound, "Task not found")
+	}
+
+	return &taskpb.DeleteResponse{TaskIds: hexIDs(deleted)}, nil
+}
+
+func (s *TaskService) DeleteAll(ctx context.Context, req *taskpb.DeleteAllRequest) (*taskpb.DeleteAllResponse, error) {
+	c := grpcCaller(ctx)
+	ctx, cancel := s.getContext(ctx, c)
+	defer cancel()
+
+	if req.GetToken() != "" {
+		deletion, code, message := s.tc.executeDeleteAll(ctx, c, req.GetToken())
+		if code != http.StatusOK {
+			return nil, grpcError(code, message)
+		}
+
+		return &taskpb.DeleteAllResponse{
+			ExpiresAt: timestamppb.New(deletion.ExpiresAt),
+			Count:     int32(len(deletion.TaskIds)),
+			TaskIds:   hexIDs(deletion.TaskIds),
+		}, nil
+	}
+
+	filter, message := readTaskFilter(filterParams(req.GetFilter(), nil), currentUser(c))
+	if message != "" {
+		return nil, status.Error(codes.InvalidArgument, message)
+	}
+
+	pending, code, message := s.tc.prepareDeleteAll(ctx, c, filter, childrenPolicy(req.GetChildren()))
+	if code != http.StatusAccepted {
+		return nil, grpcError(code, message)
+	}
+
+	return &taskpb.DeleteAllResponse{
+		Token:     pending.Token,
+		ExpiresAt: timestamppb.New(pending.ExpiresAt),
+		Count:     int32(pending.Count),
+	}, nil
+}
+
+func (s *TaskService) Watch(req *taskpb.WatchRequest, stream taskpb.TaskService_WatchServer) error {
+	if s.tc.events == nil {
+		return status.Error(codes.Unimplemented, "Events are not supported by this storage")
+	}
+
+	ctx := stream.Context()
+
+	events, reset, err := s.tc.watchEvents(ctx, currentUser(grpcCaller(ctx)), req.GetAfter())
+	if err != nil {
+		log.Println("Error watching events:", err)
+		return status.Error(codes.Unavailable, "Unable to stream events")
+	}
+
+	// Headers tell the client that changes from now on will reach it.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	if reset {
+		if err := stream.Send(&taskpb.TaskEvent{Event: resetEvent}); err != nil {
+			return err
+		}
+	}
+
+	for e := range events {
+		task, ids, err := decodeEvent(e)
+		if err != nil {
+			log.Println("Error decoding event:", err)
+			continue
+		}
+
+		event := &taskpb.TaskEvent{Id: e.Id, Event: e.Name, TaskIds: hexIDs(ids)}
+		if task != nil {
+			event.Task = taskToProto(*task)
+		}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// filterParams turns a filter into the query parameters of GET /api/tasks,
+// along with extra ones.
+func filterParams(filter *taskpb.TaskFilter, extra map[string]string) params {
+	values := map[string]string{
+		"list": filter.GetList(),
+		"tag":  filter.GetTag(),
+		"q":    filter.GetQ(),
+	}
+	switch filter.GetStatus() {
+	case taskpb.TaskStatus_TASK_STATUS_OPEN:
+		values["status"] = "open"
+	case taskpb.TaskStatus_TASK_STATUS_DONE:
+		values["status"] = "done"
+	}
+	for key, value := range extra {
+		values[key] = value
+	}
+
+	return func(key string) (string, bool) {
+		value := values[key]
+		return value, value != ""
+	}
+}
+
+func childrenPolicy(policy taskpb.ChildrenPolicy) string {
+	if policy == taskpb.ChildrenPolicy_CHILDREN_POLICY_CASCADE {
+		return childrenCascade
+	}
+	return childrenPromote
+}
+
+// applyTaskFields copies the fields of in named by paths to task.
+func applyTaskFields(task *models.Task, in *taskpb.Task, paths []string) error {
+	for _, path := range paths {
+		switch path {
+		case "description":
+			task.Description = in.GetDescription()
+		case "completed":
+			task.Completed = in.GetCompleted()
+		case "due_at":
+			task.DueAt = timeOf(in.GetDueAt())
+		case "priority":
+			task.Priority = int(in.GetPriority())
+		case "tags":
+			task.Tags = in.GetTags()
+		case "projects":
+			task.Projects = in.GetProjects()
+		case "recurrence":
+			task.Recurrence = nil
+			if r := in.GetRecurrence(); r != nil {
+				task.Recurrence = &models.Recurrence{Rule: r.GetRule(), TimeZone: r.GetTimeZone(), Mode: r.GetMode()}
+				if start := timeOf(r.GetStart()); start != nil {
+					task.Recurrence.Start = *start
+				}
+			}
+		case "list_id", "parent_id":
+			ref := &task.ListId
+			id := in.GetListId()
+			if path == "parent_id" {
+				ref, id = &task.ParentId, in.GetParentId()
+			}
+
+			*ref = nil
+			if id != "" {
+				objectID, err := parseProtoID(id)
+				if err != nil {
+					return err
+				}
+				*ref = &objectID
+			}
+		default:
+			return status.Errorf(codes.InvalidArgument, "Invalid update mask path %q", path)
+		}
+	}
+
+	return nil
+}
+
+func taskToProto(task models.Task) *taskpb.Task {
+	out := &taskpb.Task{
+		Id:          task.Id.Hex(),
+		Description: task.Description,
+		Completed:   task.Completed,
+		CompletedAt: timestampOf(task.CompletedAt),
+		DueAt:       timestampOf(task.DueAt),
+		Overdue:     task.Overdue,
+		DueToday:    task.DueToday,
+		Priority:    int32(task.Priority),
+		Tags:        task.Tags,
+		Projects:    task.Projects,
+		CreatedAt:   timestamppb.New(task.Id.Timestamp()),
+	}
+	if task.ListId != nil {
+		out.ListId = task.ListId.Hex()
+	}
+	if task.ParentId != nil {
+		out.ParentId = task.ParentId.Hex()
+	}
+	if r := task.Recurrence; r != nil {
+		out.Recurrence = &taskpb.Recurrence{
+			Rule:     r.Rule,
+			TimeZone: r.TimeZone,
+			Start:    timestamppb.New(r.Start),
+			Mode:     r.Mode,
+		}
+	}
+	if p := task.Progress; p != nil {
+		out.Progress = &taskpb.Progress{Done: int32(p.Done), Total: int32(p.Total)}
+	}
+	if task.Next != nil {
+		out.Next = taskToProto(*task.Next)
+	}
+
+	return out
+}
+
+func timestampOf(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timeOf(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func hexIDs(ids []bson.ObjectID) []string {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, id.Hex())
+	}
+	return result
+}
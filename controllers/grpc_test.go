@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/taskpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCTestSuite struct {
+	controllerSuite
+	auth   *AuthController
+	server *grpc.Server
+	conn   *grpc.ClientConn
+	client taskpb.TaskServiceClient
+	// ctx authenticates calls as ada@example.com.
+	ctx context.Context
+}
+
+func (suite *GRPCTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.controller.webhooks = nil
+	suite.auth = NewAuthController(suite.store)
+
+	listener := bufconn.Listen(1 << 20)
+	suite.server = NewGRPCServer(suite.controller, suite.auth)
+	go suite.server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	suite.Require().NoError(err)
+	suite.conn = conn
+	suite.client = taskpb.NewTaskServiceClient(conn)
+	suite.ctx = suite.login("ada@example.com")
+}
+
+func (suite *GRPCTestSuite) TearDownTest() {
+	suite.conn.Close()
+	suite.server.Stop()
+}
+
+// login registers a user and returns a context whose calls carry their
+// token.
+func (suite *GRPCTestSuite) login(email string) context.Context {
+	ctx := context.Background()
+	creds := credentials{Email: email, Password: "correct horse"}
+
+	_, status, message := suite.auth.register(ctx, creds)
+	suite.Require().Equal(201, status, message)
+	token, _, status, message := suite.auth.login(ctx, creds)
+	suite.Require().Equal(200, status, message)
+
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// createTask adds task through Create.
+func (suite *GRPCTestSuite) createTask(task *taskpb.Task) *taskpb.Task {
+	created, err := suite.client.Create(suite.ctx, &taskpb.CreateRequest{Task: task})
+	suite.Require().NoError(err)
+	return created
+}
+
+func (suite *GRPCTestSuite) list(req *taskpb.ListRequest) []string {
+	stream, err := suite.client.List(suite.ctx, req)
+	suite.Require().NoError(err)
+
+	var descriptions []string
+	for {
+		task, err := stream.Recv()
+		if err == io.EOF {
+			return descriptions
+		}
+		suite.Require().NoError(err)
+		descriptions = append(descriptions, task.Description)
+	}
+}
+
+func (suite *GRPCTestSuite) assertCode(code codes.Code, err error) {
+	suite.Require().Error(err)
+	assert.Equal(suite.T(), code, status.Code(err), err.Error())
+}
+
+func (suite *GRPCTestSuite) TestRequiresAuthentication() {
+	_, err := suite.client.Get(context.Background(), &taskpb.GetRequest{Id: "65f000000000000000000000"})
+	suite.assertCode(codes.Unauthenticated, err)
+
+	stream, err := suite.client.List(context.Background(), &taskpb.ListRequest{})
+	suite.Require().NoError(err)
+	_, err = stream.Recv()
+	suite.assertCode(codes.Unauthenticated, err)
+}
+
+func (suite *GRPCTestSuite) TestCreateAndGet() {
+	due := time.Date(2030, 5, 1, 9, 0, 0, 0, time.UTC)
+	created := suite.createTask(&taskpb.Task{
+		Description: "Water plants",
+		DueAt:       timestamppb.New(due),
+		Tags:        []string{"Home"},
+		Recurrence:  &taskpb.Recurrence{Rule: "FREQ=WEEKLY"},
+	})
+	assert.Equal(suite.T(), []string{"home"}, created.Tags)
+	assert.Equal(suite.T(), due, created.Recurrence.Start.AsTime())
+
+	got, err := suite.client.Get(suite.ctx, &taskpb.GetRequest{Id: created.Id})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Water plants", got.Description)
+	assert.Equal(suite.T(), due, got.DueAt.AsTime())
+
+	_, err = suite.client.Get(suite.ctx, &taskpb.GetRequest{Id: "nope"})
+	suite.assertCode(codes.InvalidArgument, err)
+
+	_, err = suite.client.Get(suite.ctx, &taskpb.GetRequest{Id: "65f000000000000000000000"})
+	suite.assertCode(codes.NotFound, err)
+
+	// Other users' tasks do not exist for them.
+	_, err = suite.client.Get(suite.login("bob@example.com"), &taskpb.GetRequest{Id: created.Id})
+	suite.assertCode(codes.NotFound, err)
+}
+
+func (suite *GRPCTestSuite) TestCreateValidation() {
+	_, err := suite.client.Create(suite.ctx, &taskpb.CreateRequest{Task: &taskpb.Task{
+		Description: "Orphan",
+		ParentId:    "65f000000000000000000000",
+	}})
+	suite.assertCode(codes.InvalidArgument, err)
+	assert.Equal(suite.T(), "Parent task not found", status.Convert(err).Message())
+
+	_, err = suite.client.Create(suite.ctx, &taskpb.CreateRequest{Task: &taskpb.Task{
+		Description: "Water plants",
+		Recurrence:  &taskpb.Recurrence{Rule: "FREQ=WEEKLY"},
+	}})
+	suite.assertCode(codes.InvalidArgument, err)
+	assert.Equal(suite.T(), "Recurring tasks need a due date", status.Convert(err).Message())
+}
+
+func (suite *GRPCTestSuite) TestList() {
+	suite.createTask(&taskpb.Task{Description: "Buy milk", Tags: []string{"shop"}})
+	suite.createTask(&taskpb.Task{Description: "Buy bread", Tags: []string{"shop"}})
+	suite.createTask(&taskpb.Task{Description: "Buy stamps", Tags: []string{"shop"}, Completed: true})
+	suite.createTask(&taskpb.Task{Description: "Call mom"})
+
+	assert.Equal(suite.T(), []string{"Buy milk", "Buy bread", "Buy stamps", "Call mom"}, suite.list(&taskpb.ListRequest{}))
+	assert.Equal(suite.T(), []string{"Buy bread", "Buy milk"}, suite.list(&taskpb.ListRequest{
+		Filter: &taskpb.TaskFilter{Status: taskpb.TaskStatus_TASK_STATUS_OPEN, Tag: "shop"},
+		Sort:   "description",
+	}))
+	assert.Equal(suite.T(), []string{"Call mom"}, suite.list(&taskpb.ListRequest{Sort: "-created", Limit: 1}))
+
+	stream, err := suite.client.List(suite.ctx, &taskpb.ListRequest{Sort: "bogus"})
+	suite.Require().NoError(err)
+	_, err = stream.Recv()
+	suite.assertCode(codes.InvalidArgument, err)
+}
+
+func (suite *GRPCTestSuite) TestListStreamsPastOnePage() {
+	for i := 0; i < maxPageSize+1; i++ {
+		suite.createTask(&taskpb.Task{Description: "Task"})
+	}
+
+	assert.Len(suite.T(), suite.list(&taskpb.ListRequest{}), maxPageSize+1)
+}
+
+func (suite *GRPCTestSuite) TestUpdate() {
+	created := suite.createTask(&taskpb.Task{Description: "Buy milk", Priority: 2, Tags: []string{"shop"}})
+
+	updated, err := suite.client.Update(suite.ctx, &taskpb.UpdateRequest{
+		Task:       &taskpb.Task{Id: created.Id, Description: "Buy oat milk", Completed: true},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"description", "completed"}},
+	})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Buy oat milk", updated.Description)
+	assert.True(suite.T(), updated.Completed)
+	assert.NotNil(suite.T(), updated.CompletedAt)
+	assert.Equal(suite.T(), int32(2), updated.Priority)
+	assert.Equal(suite.T(), []string{"shop"}, updated.Tags)
+
+	_, err = suite.client.Update(suite.ctx, &taskpb.UpdateRequest{
+		Task:       &taskpb.Task{Id: created.Id},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"owner"}},
+	})
+	suite.assertCode(codes.InvalidArgument, err)
+
+	_, err = suite.client.Update(suite.ctx, &taskpb.UpdateRequest{Task: &taskpb.Task{Id: "65f000000000000000000000"}})
+	suite.assertCode(codes.NotFound, err)
+}
+
+func (suite *GRPCTestSuite) TestDelete() {
+	parent := suite.createTask(&taskpb.Task{Description: "Plan trip"})
+	child := suite.createTask(&taskpb.Task{Description: "Book flights", ParentId: parent.Id})
+
+	deleted, err := suite.client.Delete(suite.ctx, &taskpb.DeleteRequest{
+		Id:       parent.Id,
+		Children: taskpb.ChildrenPolicy_CHILDREN_POLICY_CASCADE,
+	})
+	suite.Require().NoError(err)
+	assert.ElementsMatch(suite.T(), []string{parent.Id, child.Id}, deleted.TaskIds)
+
+	_, err = suite.client.Delete(suite.ctx, &taskpb.DeleteRequest{Id: parent.Id})
+	suite.assertCode(codes.NotFound, err)
+
+	_, err = suite.client.Delete(suite.ctx, &taskpb.DeleteRequest{Id: "nope"})
+	suite.assertCode(codes.InvalidArgument, err)
+}
+
+func (suite *GRPCTestSuite) TestDeleteAll() {
+	suite.createTask(&taskpb.Task{Description: "Buy milk"})
+	suite.createTask(&taskpb.Task{Description: "Buy stamps", Completed: true})
+
+	pending, err := suite.client.DeleteAll(suite.ctx, &taskpb.DeleteAllRequest{
+		Filter: &taskpb.TaskFilter{Status: taskpb.TaskStatus_TASK_STATUS_DONE},
+	})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int32(1), pending.Count)
+	suite.Require().NotEmpty(pending.Token)
+
+	deleted, err := suite.client.DeleteAll(suite.ctx, &taskpb.DeleteAllRequest{Token: pending.Token})
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), deleted.TaskIds, 1)
+
+	_, err = suite.client.DeleteAll(suite.ctx, &taskpb.DeleteAllRequest{Token: pending.Token})
+	assert.Error(suite.T(), err)
+
+	assert.Equal(suite.T(), []string{"Buy milk"}, suite.list(&taskpb.ListRequest{}))
+}
+
+func (suite *GRPCTestSuite) TestWatch() {
+	ctx, cancel := context.WithCancel(suite.ctx)
+	defer cancel()
+
+	stream, err := suite.client.Watch(ctx, &taskpb.WatchRequest{})
+	suite.Require().NoError(err)
+	// The stream is only watching once its headers are sent.
+	_, err = stream.Header()
+	suite.Require().NoError(err)
+
+	created := suite.createTask(&taskpb.Task{Description: "Buy milk"})
+	_, err = suite.client.Delete(suite.ctx, &taskpb.DeleteRequest{Id: created.Id})
+	suite.Require().NoError(err)
+
+	event, err := stream.Recv()
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "task.created", event.Event)
+	assert.Equal(suite.T(), "Buy milk", event.Task.Description)
+	assert.NotEmpty(suite.T(), event.Id)
+
+	event, err = stream.Recv()
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "task.deleted", event.Event)
+	assert.Equal(suite.T(), []string{created.Id}, event.TaskIds)
+
+	resumed, err := suite.client.Watch(ctx, &taskpb.WatchRequest{After: "12345"})
+	suite.Require().NoError(err)
+	event, err = resumed.Recv()
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), resetEvent, event.Event)
+}
+
+func TestGRPCTestSuite(t *testing.T) {
+	suite.Run(t, new(GRPCTestSuite))
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// parseID reads the :id path parameter. On failure it writes a 400
+// response and returns false.
+func parseID(c *gin.Context) (bson.ObjectID, bool) {
+	objectID, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return objectID, false
+	}
+
+	return objectID, true
+}
+
+// params looks up a request parameter and reports whether it was given,
+// like gin.Context.GetQuery.
+type params func(key string) (string, bool)
+
+// get returns the parameter key, or "" when it was not given.
+func (p params) get(key string) string {
+	value, _ := p(key)
+	return value
+}
+
+// parseTaskFilter reads the list filters from the query string, scoped to
+// the logged-in user. On failure it writes a 400 response and returns false.
+func parseTaskFilter(c *gin.Context) (repository.TaskFilter, bool) {
+	filter, message := readTaskFilter(c.GetQuery, currentUser(c))
+	if message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": message})
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// readTaskFilter is parseTaskFilter for any source of parameters, e.g. the
+// arguments of a GraphQL field. On failure it returns the message to answer
+// with, otherwise "".
+func readTaskFilter(query params, owner *bson.ObjectID) (repository.TaskFilter, string) {
+	filter := repository.TaskFilter{Owner: owner}
+
+	switch query.get("status") {
+	case "":
+	case "open":
+		completed := false
+		filter.Completed = &completed
+	case "done":
+		completed := true
+		filter.Completed = &completed
+	default:
+		return filter, "Invalid status filter"
+	}
+
+	switch list := query.get("list"); list {
+	case "":
+	case inboxID:
+		filter.Inbox = true
+	default:
+		listID, err := bson.ObjectIDFromHex(list)
+		if err != nil {
+			return filter, "Invalid list filter"
+		}
+		filter.List = &listID
+	}
+
+	filter.Tag = strings.ToLower(strings.TrimSpace(query.get("tag")))
+	filter.Text = strings.TrimSpace(query.get("q"))
+
+	return filter, ""
+}
+
+// parseTaskQuery reads both the filters and the page of a task listing.
+// Search results are ranked by relevance unless ?sort= says otherwise.
+func parseTaskQuery(c *gin.Context, defaultSort string, defaultLimit int) (repository.TaskFilter, page, bool) {
+	filter, p, message := readTaskQuery(c.GetQuery, currentUser(c), defaultSort, defaultLimit)
+	if message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": message})
+		return filter, p, false
+	}
+
+	return filter, p, true
+}
+
+// readTaskQuery is parseTaskQuery for any source of parameters. On failure
+// it returns the message to answer with, otherwise "".
+func readTaskQuery(query params, owner *bson.ObjectID, defaultSort string, defaultLimit int) (repository.TaskFilter, page, string) {
+	filter, message := readTaskFilter(query, owner)
+	if message != "" {
+		return filter, page{}, message
+	}
+
+	if filter.Text != "" {
+		defaultSort = "relevance"
+	}
+
+	p, message := readPage(query, defaultSort, defaultLimit)
+	if message != "" {
+		return filter, p, message
+	}
+
+	if p.options.Sort == repository.SortRelevance && filter.Text == "" {
+		return filter, p, "Sorting by relevance requires a search query"
+	}
+
+	return filter, p, ""
+}
+
+// applyMergePatch applies an RFC 7396 merge patch to the JSON form of task.
+func applyMergePatch(task models.Task, patch map[string]interface{}) (models.Task, error) {
+	current, err := json.Marshal(task)
+	if err != nil {
+		return task, err
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(current, &document); err != nil {
+		return task, err
+	}
+
+	merged, err := json.Marshal(mergePatch(document, patch))
+	if err != nil {
+		return task, err
+	}
+
+	var patched models.Task
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return task, err
+	}
+
+	return patched, nil
+}
+
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			existing, _ := target[key].(map[string]interface{})
+			target[key] = mergePatch(existing, nested)
+			continue
+		}
+
+		target[key] = value
+	}
+
+	return target
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// controllerSuite is embedded by the controller suites. Each test gets
+// fresh in-memory storage, a TaskController on it whose clock reads
+// suite.now, and an empty router for the suite to mount its routes on.
+type controllerSuite struct {
+	suite.Suite
+	store      repository.Store
+	repo       *repository.MemoryTaskRepository
+	controller *TaskController
+	router     *gin.Engine
+	now        time.Time
+}
+
+func (suite *controllerSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+
+	// Fresh in-memory storage for each test
+	suite.store = repository.NewMemoryStore()
+	suite.repo = suite.store.Tasks.(*repository.MemoryTaskRepository)
+	suite.controller = NewTaskControllerWithStore(suite.store)
+	suite.now = time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	suite.controller.now = func() time.Time { return suite.now }
+
+	suite.router = gin.New()
+}
+
+// serve runs req through the router.
+func (suite *controllerSuite) serve(req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// request sends body, unless nil, as JSON.
+func (suite *controllerSuite) request(method, url string, body interface{}) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		jsonData, _ := json.Marshal(body)
+		reader = bytes.NewReader(jsonData)
+	}
+
+	req, _ := http.NewRequest(method, url, reader)
+	req.Header.Set("Content-Type", "application/json")
+	return suite.serve(req)
+}
+
+// create posts body to /api/task and returns the task created.
+func (suite *controllerSuite) create(body gin.H) models.Task {
+	w := suite.request("POST", "/api/task", body)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var task models.Task
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &task))
+	return task
+}
+
+// tasks returns the tasks listed at url.
+func (suite *controllerSuite) tasks(url string) []models.Task {
+	w := suite.request("GET", url, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var tasks []models.Task
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &tasks))
+	return tasks
+}
+
+// stored returns every task in storage, bypassing the API.
+func (suite *controllerSuite) stored() []models.Task {
+	tasks, err := suite.repo.List(context.Background(), repository.TaskFilter{}, repository.ListOptions{})
+	suite.Require().NoError(err)
+	return tasks
+}
+
+// jsonObject decodes the body of w as a JSON object, or returns nil.
+func jsonObject(w *httptest.ResponseRecorder) map[string]interface{} {
+	var response map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return response
+}
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]interface{}{
+		"a": "b",
+		"c": map[string]interface{}{"d": "e", "f": "g"},
+	}
+	patch := map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{"f": nil},
+		"h": "i",
+	}
+
+	result := mergePatch(target, patch)
+
+	assert.Equal(t, map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{"d": "e"},
+		"h": "i",
+	}, result)
+}
+
+func TestMergePatchReplacesNonObject(t *testing.T) {
+	target := map[string]interface{}{"a": "b"}
+	patch := map[string]interface{}{"a": map[string]interface{}{"c": "d"}}
+
+	result := mergePatch(target, patch)
+
+	assert.Equal(t, map[string]interface{}{"a": map[string]interface{}{"c": "d"}}, result)
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// GetHistory lists the recorded changes of a task, oldest first. The
+// history outlives the task, so deleted tasks can still be inspected.
+func (tc TaskController) GetHistory(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	entries, err := tc.history.List(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch history"})
+		return
+	}
+
+	if len(entries) == 0 || !ownedBy(c, entries[0].OwnerId) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Task not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// RestoreTask brings a task back to the state recorded in
+// ?version=N, recreating it if it has been deleted since. The restore is
+// itself recorded as a new version.
+func (tc TaskController) RestoreTask(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	version, err := strconv.Atoi(c.Query("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid version"})
+		return
+	}
+
+	entry, err := tc.history.Get(ctx, objectID, version)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !ownedBy(c, entry.OwnerId)) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Version not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch history"})
+		return
+	}
+
+	if entry.Task == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Cannot restore a deleted version"})
+		return
+	}
+
+	task := *entry.Task
+	task.Id, task.OwnerId, task.DeletedAt = objectID, entry.OwnerId, nil
+
+	if err := tc.dropMissingRefs(ctx, c, &task); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch task"})
+		return
+	}
+	if status, message := tc.checkParent(ctx, c, task); status != http.StatusOK {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	ctx = repository.WithAction(ctx, models.ActionRestored)
+
+	// A task that is gone or in the trash comes back as a new one.
+	event := webhook.EventTaskCreated
+	current, err := tc.repo.Get(ctx, objectID)
+	switch {
+	case err == nil:
+		if current.DeletedAt == nil {
+			event = webhook.EventTaskUpdated
+		}
+		err = tc.repo.Update(ctx, task)
+	case errors.Is(err, repository.ErrNotFound):
+		_, err = tc.repo.Insert(ctx, task)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to restore task"})
+		return
+	}
+
+	tasks, err := tc.withSubtasks(ctx, c, []models.Task{task}, false, tc.now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	tc.publish(ctx, task.OwnerId, event, tasks[0])
+	c.JSON(http.StatusOK, tasks[0])
+}
+
+// dropMissingRefs clears the list and parent of a restored task when they
+// no longer exist or are in the trash, moving it to the inbox or the top
+// level instead.
+func (tc TaskController) dropMissingRefs(ctx context.Context, c *gin.Context, task *models.Task) error {
+	if task.ListId != nil {
+		list, err := tc.lists.Get(ctx, *task.ListId)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && !ownedBy(c, list.OwnerId)) {
+			task.ListId = nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if task.ParentId != nil {
+		parent, err := tc.repo.Get(ctx, *task.ParentId)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && (!ownedBy(c, parent.OwnerId) || parent.DeletedAt != nil)) {
+			task.ParentId = nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
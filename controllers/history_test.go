@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"example.com/todo-rest-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type HistoryTestSuite struct {
+	controllerSuite
+}
+
+func (suite *HistoryTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	lc := NewListController(suite.store)
+
+	suite.router.POST("/api/task", suite.controller.CreateTask)
+	suite.router.GET("/api/tasks", suite.controller.GetTasks)
+	suite.router.PATCH("/api/task/:id", suite.controller.UpdateTask)
+	suite.router.POST("/api/task/:id/toggle", suite.controller.ToggleTask)
+	suite.router.DELETE("/api/task/:id", suite.controller.DeleteTask)
+	suite.router.GET("/api/task/:id/history", suite.controller.GetHistory)
+	suite.router.POST("/api/task/:id/restore", suite.controller.RestoreTask)
+	suite.router.DELETE("/api/trash", suite.controller.EmptyTrash)
+	suite.router.POST("/api/lists", lc.CreateList)
+	suite.router.DELETE("/api/lists/:id", lc.DeleteList)
+}
+
+func (suite *HistoryTestSuite) history(id string) []models.HistoryEntry {
+	w := suite.request("GET", "/api/task/"+id+"/history", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var entries []models.HistoryEntry
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &entries))
+	return entries
+}
+
+func (suite *HistoryTestSuite) TestRecordsChanges() {
+	task := suite.create(gin.H{"description": "Draft"})
+	id := task.Id.Hex()
+
+	suite.request("PATCH", "/api/task/"+id, gin.H{"description": "Final"})
+	suite.request("PATCH", "/api/task/"+id, gin.H{"description": "Final"})
+	suite.request("POST", "/api/task/"+id+"/toggle", nil)
+
+	entries := suite.history(id)
+	suite.Require().Len(entries, 3)
+
+	actions := []string{}
+	for i, entry := range entries {
+		assert.Equal(suite.T(), i+1, entry.Version)
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(suite.T(), []string{models.ActionCreated, models.ActionUpdated, models.ActionCompleted}, actions)
+
+	suite.Require().Len(entries[1].Changes, 1)
+	assert.Equal(suite.T(), "description", entries[1].Changes[0].Field)
+	assert.JSONEq(suite.T(), `"Draft"`, string(entries[1].Changes[0].Before))
+	assert.JSONEq(suite.T(), `"Final"`, string(entries[1].Changes[0].After))
+}
+
+func (suite *HistoryTestSuite) TestRestoreVersion() {
+	task := suite.create(gin.H{"description": "Draft", "priority": 2})
+	id := task.Id.Hex()
+	suite.request("PATCH", "/api/task/"+id, gin.H{"description": "Final", "priority": 5})
+
+	w := suite.request("POST", "/api/task/"+id+"/restore?version=1", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), `"description":"Draft"`)
+	assert.Contains(suite.T(), w.Body.String(), `"priority":2`)
+
+	entries := suite.history(id)
+	suite.Require().Len(entries, 3)
+	assert.Equal(suite.T(), models.ActionRestored, entries[2].Action)
+}
+
+func (suite *HistoryTestSuite) TestRestoreDeletedTask() {
+	w := suite.request("POST", "/api/lists", gin.H{"name": "Gone soon"})
+	suite.Require().Equal(http.StatusCreated, w.Code)
+
+	var list models.List
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &list))
+
+	task := suite.create(gin.H{"description": "Keep me", "listId": list.Id.Hex()})
+	id := task.Id.Hex()
+
+	suite.request("DELETE", "/api/task/"+id, nil)
+	suite.request("DELETE", "/api/lists/"+list.Id.Hex(), nil)
+	suite.request("DELETE", "/api/trash", nil)
+
+	entries := suite.history(id)
+	suite.Require().Len(entries, 3)
+	assert.Equal(suite.T(), models.ActionDeleted, entries[1].Action)
+	assert.Equal(suite.T(), models.ActionPurged, entries[2].Action)
+
+	w = suite.request("POST", "/api/task/"+id+"/restore?version=3", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request("POST", "/api/task/"+id+"/restore?version=1", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(suite.T(), w.Body.String(), "listId")
+
+	w = suite.request("GET", "/api/tasks", nil)
+	assert.Contains(suite.T(), w.Body.String(), id)
+}
+
+func (suite *HistoryTestSuite) TestErrors() {
+	task := suite.create(gin.H{"description": "Task"})
+	id := task.Id.Hex()
+
+	w := suite.request("GET", "/api/task/507f1f77bcf86cd799439011/history", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request("POST", "/api/task/"+id+"/restore?version=abc", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request("POST", "/api/task/"+id+"/restore?version=7", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestHistoryTestSuite(t *testing.T) {
+	suite.Run(t, new(HistoryTestSuite))
+}
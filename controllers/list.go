@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// inboxID is the path id addressing the tasks that belong to no list.
+const inboxID = "inbox"
+
+type ListController struct {
+	lists repository.ListRepository
+	// tasks moves or deletes the tasks of a deleted list, announcing each
+	// like any other change to them.
+	tasks TaskController
+}
+
+func NewListController(s repository.Store) *ListController {
+	return &ListController{
+		lists: s.Lists,
+		tasks: *NewTaskControllerWithStore(s),
+	}
+}
+
+func (lc ListController) getContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	ctx := repository.WithActor(context.Background(), currentUser(c))
+	return context.WithTimeout(ctx, settings().RequestTimeout)
+}
+
+func (lc ListController) GetLists(c *gin.Context) {
+	ctx, cancel := lc.getContext(c)
+	defer cancel()
+
+	lists, err := lc.lists.List(ctx, currentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch lists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+func (lc ListController) GetList(c *gin.Context) {
+	ctx, cancel := lc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	list, ok := lc.getList(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (lc ListController) CreateList(c *gin.Context) {
+	ctx, cancel := lc.getContext(c)
+	defer cancel()
+
+	list, ok := bindList(c)
+	if !ok {
+		return
+	}
+	list.OwnerId = currentUser(c)
+
+	list, err := lc.lists.Insert(ctx, list)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create list"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+// ReplaceList handles PUT, e.g. to rename a list.
+func (lc ListController) ReplaceList(c *gin.Context) {
+	ctx, cancel := lc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	list, ok := bindList(c)
+	if !ok {
+		return
+	}
+
+	current, ok := lc.getList(ctx, c, objectID)
+	if !ok {
+		return
+	}
+	list.Id = objectID
+	list.OwnerId = current.OwnerId
+
+	err := lc.lists.Update(ctx, list)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "List not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// DeleteList removes a list. Its tasks are moved to the inbox, or deleted
+// along with it when called with ?tasks=cascade.
+func (lc ListController) DeleteList(c *gin.Context) {
+	ctx, cancel := lc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	mode := c.DefaultQuery("tasks", "inbox")
+	if mode != "inbox" && mode != "cascade" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid tasks mode"})
+		return
+	}
+
+	if _, ok := lc.getList(ctx, c, objectID); !ok {
+		return
+	}
+
+	err := lc.lists.Delete(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "List not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete list"})
+		return
+	}
+
+	filter := repository.TaskFilter{Owner: currentUser(c), List: &objectID}
+	if mode == "cascade" {
+		// Subtasks filed in other lists outlive their deleted parents.
+		deleted, err := lc.tasks.removeTasks(ctx, c, filter, childrenPromote, lc.tasks.now().UTC())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete list tasks"})
+			return
+		}
+		for _, id := range deleted {
+			lc.tasks.publish(ctx, currentUser(c), webhook.EventTaskDeleted, deletedTask{Id: id})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "List deleted successfully",
+			"deletedCount": len(deleted),
+		})
+		return
+	}
+
+	moved, err := lc.tasks.repo.List(ctx, filter, repository.ListOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to move list tasks"})
+		return
+	}
+
+	movedCount, err := lc.tasks.repo.SetList(ctx, filter, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to move list tasks"})
+		return
+	}
+
+	ids := make([]bson.ObjectID, len(moved))
+	for i, task := range moved {
+		ids[i] = task.Id
+	}
+	lc.tasks.publishTasks(ctx, c, webhook.EventTaskUpdated, ids)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "List deleted successfully",
+		"movedCount": movedCount,
+	})
+}
+
+// getList fetches a list of the logged-in user. Other users' lists are
+// reported as missing. On failure it writes a 404 or 500 response and
+// returns false.
+func (lc ListController) getList(ctx context.Context, c *gin.Context, id bson.ObjectID) (models.List, bool) {
+	list, err := lc.lists.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !ownedBy(c, list.OwnerId)) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "List not found"})
+		return list, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch list"})
+		return list, false
+	}
+
+	return list, true
+}
+
+func bindList(c *gin.Context) (models.List, bool) {
+	var list models.List
+
+	if err := c.BindJSON(&list); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return list, false
+	}
+
+	list.Name = strings.TrimSpace(list.Name)
+	if list.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "List name is required"})
+		return list, false
+	}
+
+	return list, true
+}
+
+// GetListTasks lists the tasks of one list, or of the inbox, with the same
+// query parameters as GetTasks.
+func (tc TaskController) GetListTasks(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	base, _, ok := tc.findList(ctx, c)
+	if !ok {
+		return
+	}
+
+	now, ok := tc.clock(c)
+	if !ok {
+		return
+	}
+
+	filter, p, ok := parseTaskQuery(c, "created", defaultPageSize)
+	if !ok {
+		return
+	}
+	filter.List, filter.Inbox = base.List, base.Inbox
+
+	tc.listTasks(ctx, c, filter, p, now)
+}
+
+// CreateListTask creates a task inside the list named in the path.
+func (tc TaskController) CreateListTask(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	base, _, ok := tc.findList(ctx, c)
+	if !ok {
+		return
+	}
+
+	var newTask models.Task
+
+	if err := c.BindJSON(&newTask); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+	newTask.ListId = base.List
+
+	tc.createTask(ctx, c, newTask)
+}
+
+// ShowList renders the web view of one list, or of the inbox.
+func (tc TaskController) ShowList(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	base, list, ok := tc.findList(ctx, c)
+	if !ok {
+		return
+	}
+
+	activeList := inboxID
+	if base.List != nil {
+		activeList = base.List.Hex()
+	}
+
+	tc.renderTasks(ctx, c, base, gin.H{"list": list, "activeList": activeList})
+}
+
+// findList resolves the :id path parameter to a task filter for that list;
+// "inbox" selects the tasks without a list. On failure it writes a 400 or
+// 404 response and returns false.
+func (tc TaskController) findList(ctx context.Context, c *gin.Context) (repository.TaskFilter, models.List, bool) {
+	if c.Param("id") == inboxID {
+		return repository.TaskFilter{Owner: currentUser(c), Inbox: true}, models.List{Name: "Inbox"}, true
+	}
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return repository.TaskFilter{}, models.List{}, false
+	}
+
+	list, err := tc.lists.Get(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !ownedBy(c, list.OwnerId)) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "List not found"})
+		return repository.TaskFilter{}, list, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch list"})
+		return repository.TaskFilter{}, list, false
+	}
+
+	return repository.TaskFilter{Owner: currentUser(c), List: &list.Id}, list, true
+}
+
+// checkList verifies that a task's list exists and belongs to the user. It
+// returns http.StatusOK, or the status and message to answer with.
+func (tc TaskController) checkList(ctx context.Context, c *gin.Context, listID *bson.ObjectID) (int, string) {
+	if listID == nil {
+		return http.StatusOK, ""
+	}
+
+	list, err := tc.lists.Get(ctx, *listID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !ownedBy(c, list.OwnerId)) {
+		return http.StatusBadRequest, "List not found"
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "Unable to fetch list"
+	}
+
+	return http.StatusOK, ""
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type ListControllerTestSuite struct {
+	controllerSuite
+}
+
+func (suite *ListControllerTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	lc := NewListController(suite.store)
+
+	suite.router.POST("/api/task", suite.controller.CreateTask)
+	suite.router.GET("/api/lists", lc.GetLists)
+	suite.router.POST("/api/lists", lc.CreateList)
+	suite.router.GET("/api/lists/:id", lc.GetList)
+	suite.router.PUT("/api/lists/:id", lc.ReplaceList)
+	suite.router.DELETE("/api/lists/:id", lc.DeleteList)
+	suite.router.GET("/api/lists/:id/tasks", suite.controller.GetListTasks)
+	suite.router.POST("/api/lists/:id/tasks", suite.controller.CreateListTask)
+}
+
+func (suite *ListControllerTestSuite) insertList(name string) models.List {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	list, err := suite.store.Lists.Insert(ctx, models.List{Name: name})
+	assert.NoError(suite.T(), err)
+	return list
+}
+
+func (suite *ListControllerTestSuite) insertTask(description string, listID *bson.ObjectID) models.Task {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	task, err := suite.store.Tasks.Insert(ctx, models.Task{Description: description, ListId: listID})
+	assert.NoError(suite.T(), err)
+	return task
+}
+
+func (suite *ListControllerTestSuite) TestCreateAndGetList() {
+	w := suite.request("POST", "/api/lists", map[string]string{"name": "  Groceries "})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var created models.List
+	err := json.Unmarshal(w.Body.Bytes(), &created)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Groceries", created.Name)
+	assert.False(suite.T(), created.Id.IsZero())
+
+	w = suite.request("GET", "/api/lists/"+created.Id.Hex(), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request("GET", "/api/lists", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var lists []models.List
+	err = json.Unmarshal(w.Body.Bytes(), &lists)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []models.List{created}, lists)
+}
+
+func (suite *ListControllerTestSuite) TestCreateListWithoutName() {
+	w := suite.request("POST", "/api/lists", map[string]string{"name": " "})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "List name is required", response["message"])
+}
+
+func (suite *ListControllerTestSuite) TestReplaceList() {
+	list := suite.insertList("Old name")
+
+	w := suite.request("PUT", "/api/lists/"+list.Id.Hex(), map[string]string{"name": "New name"})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request("PUT", "/api/lists/"+bson.NewObjectID().Hex(), map[string]string{"name": "New name"})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request("PUT", "/api/lists/invalid-id", map[string]string{"name": "New name"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ListControllerTestSuite) TestDeleteListMovesTasksToInbox() {
+	list := suite.insertList("Project")
+	task := suite.insertTask("Project task", &list.Id)
+
+	w := suite.request("DELETE", "/api/lists/"+list.Id.Hex(), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), float64(1), response["movedCount"])
+
+	stored, err := suite.store.Tasks.Get(context.Background(), task.Id)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), stored.ListId)
+
+	w = suite.request("GET", "/api/lists/"+list.Id.Hex(), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *ListControllerTestSuite) TestDeleteListCascade() {
+	list := suite.insertList("Project")
+	suite.insertTask("Project task", &list.Id)
+	inboxTask := suite.insertTask("Inbox task", nil)
+
+	w := suite.request("DELETE", "/api/lists/"+list.Id.Hex()+"?tasks=cascade", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), float64(1), response["deletedCount"])
+
+	tasks, err := suite.store.Tasks.List(context.Background(), repository.TaskFilter{}, repository.ListOptions{})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), tasks, 1)
+	assert.Equal(suite.T(), inboxTask.Id, tasks[0].Id)
+}
+
+func (suite *ListControllerTestSuite) TestDeleteListInvalidMode() {
+	list := suite.insertList("Project")
+
+	w := suite.request("DELETE", "/api/lists/"+list.Id.Hex()+"?tasks=archive", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ListControllerTestSuite) TestListScopedTasks() {
+	list := suite.insertList("Project")
+	suite.insertTask("Inbox task", nil)
+
+	w := suite.request("POST", "/api/lists/"+list.Id.Hex()+"/tasks", map[string]string{"description": "Project task"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var created models.Task
+	err := json.Unmarshal(w.Body.Bytes(), &created)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), &list.Id, created.ListId)
+
+	for url, description := range map[string]string{
+		"/api/lists/" + list.Id.Hex() + "/tasks": "Project task",
+		"/api/lists/inbox/tasks":                 "Inbox task",
+	} {
+		w = suite.request("GET", url, nil)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+		var tasks []models.Task
+		err = json.Unmarshal(w.Body.Bytes(), &tasks)
+		assert.NoError(suite.T(), err)
+		assert.Len(suite.T(), tasks, 1, url)
+		assert.Equal(suite.T(), description, tasks[0].Description, url)
+	}
+
+	w = suite.request("GET", "/api/lists/"+bson.NewObjectID().Hex()+"/tasks", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *ListControllerTestSuite) TestCreateTaskInUnknownList() {
+	w := suite.request("POST", "/api/task", map[string]string{
+		"description": "Orphan",
+		"listId":      bson.NewObjectID().Hex(),
+	})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "List not found", response["message"])
+}
+
+func TestListControllerSuite(t *testing.T) {
+	suite.Run(t, new(ListControllerTestSuite))
+}
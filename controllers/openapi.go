@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec describes every route of the app; see README.md.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// OpenAPIController serves the OpenAPI document of the app and checks
+// requests against it.
+type OpenAPIController struct {
+	doc *openapi3.T
+	// document is doc as JSON, as served.
+	document []byte
+}
+
+// NewOpenAPIController loads the embedded OpenAPI document. It panics if
+// the document is invalid, which the tests would have caught.
+func NewOpenAPIController() *OpenAPIController {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(openAPISpec)
+	if err != nil {
+		panic("Invalid OpenAPI document: " + err.Error())
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		panic("Invalid OpenAPI document: " + err.Error())
+	}
+
+	document, err := json.Marshal(doc)
+	if err != nil {
+		panic("Invalid OpenAPI document: " + err.Error())
+	}
+
+	return &OpenAPIController{doc: doc, document: document}
+}
+
+// Spec serves the OpenAPI document as JSON.
+func (oc OpenAPIController) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", oc.document)
+}
+
+// Docs shows the interactive documentation, which renders the document
+// served by Spec.
+func (oc OpenAPIController) Docs(c *gin.Context) {
+	c.HTML(http.StatusOK, "docs.gohtml", gin.H{})
+}
+
+// validationError is one way in which a request does not match the
+// OpenAPI document.
+type validationError struct {
+	// In is where the problem is: path, query, header, cookie or body.
+	In   string `json:"in"`
+	Name string `json:"name,omitempty"`
+	// Pointer is the JSON Pointer of the body value at fault.
+	Pointer string `json:"pointer,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// ValidateRequest is a middleware that answers requests which do not match
+// the OpenAPI document with a 400 listing what is wrong. Routes missing
+// from the document are let through.
+func (oc OpenAPIController) ValidateRequest(c *gin.Context) {
+	route, ok := oc.route(c)
+	if !ok {
+		c.Next()
+		return
+	}
+
+	pathParams := make(map[string]string, len(c.Params))
+	for _, param := range c.Params {
+		pathParams[param.Key] = param.Value
+	}
+
+	request := c.Request
+	if strings.HasPrefix(route.Path, "/api/v1/") {
+		request = readAsJSON(c.Request, route.Operation)
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:     request,
+		PathParams:  pathParams,
+		QueryParams: c.Request.URL.Query(),
+		Route:       route,
+		Options: &openapi3filter.Options{
+			MultiError: true,
+			// The auth middleware has already checked the session.
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			// Uploads are read by the import itself, which limits their
+			// size; validating them would buffer them whole.
+			ExcludeRequestBody: c.ContentType() == "multipart/form-data",
+		},
+	}
+
+	err := openapi3filter.ValidateRequest(context.Background(), input)
+	if request != c.Request {
+		// Validation read the body and put it back on the copy.
+		c.Request.Body, c.Request.GetBody, c.Request.ContentLength = request.Body, request.GetBody, request.ContentLength
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request",
+			"errors":  validationErrors(err),
+		})
+		return
+	}
+
+	c.Next()
+}
+
+// readAsJSON returns req as validation should see it on a route of
+// version 1. Those always read their body as JSON, whatever it is labelled
+// with, and scripts such as curl -d send it as a form; such a body is
+// checked as JSON rather than refused for its Content-Type.
+func readAsJSON(req *http.Request, operation *openapi3.Operation) *http.Request {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return req
+	}
+
+	content := operation.RequestBody.Value.Content
+	if content.Get(req.Header.Get("Content-Type")) != nil || content.Get("application/json") == nil {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// route finds the operation of the document that c was routed to. The
+// routes of version 1 served without a version are documented under
+// /api/v1.
+func (oc OpenAPIController) route(c *gin.Context) (*routers.Route, bool) {
+	path := openAPIPath(c.FullPath())
+	pathItem := oc.doc.Paths.Value(path)
+	if pathItem == nil && strings.HasPrefix(path, "/api/") {
+		path = "/api/v1/" + strings.TrimPrefix(path, "/api/")
+		pathItem = oc.doc.Paths.Value(path)
+	}
+	if pathItem == nil {
+		return nil, false
+	}
+
+	operation := pathItem.GetOperation(c.Request.Method)
+	if operation == nil {
+		return nil, false
+	}
+
+	return &routers.Route{
+		Spec:      oc.doc,
+		Path:      path,
+		PathItem:  pathItem,
+		Method:    c.Request.Method,
+		Operation: operation,
+	}, true
+}
+
+// openAPIPath turns a gin route such as /api/task/:id into the path of the
+// OpenAPI document, /api/task/{id}.
+func openAPIPath(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// validationErrors lists the problems reported by
+// openapi3filter.ValidateRequest.
+func validationErrors(err error) []validationError {
+	var result []validationError
+	for _, err := range flattenErrors(err) {
+		requestErr, ok := err.(*openapi3filter.RequestError)
+		if !ok {
+			result = append(result, validationError{In: "request", Reason: err.Error()})
+			continue
+		}
+
+		if param := requestErr.Parameter; param != nil {
+			result = append(result, validationError{In: param.In, Name: param.Name, Reason: reasonOf(requestErr)})
+			continue
+		}
+
+		found := false
+		for _, err := range flattenErrors(requestErr.Err) {
+			if schemaErr, ok := err.(*openapi3.SchemaError); ok {
+				result = append(result, validationError{
+					In:      "body",
+					Pointer: jsonPointer(schemaErr.JSONPointer()),
+					Reason:  schemaErr.Reason,
+				})
+				found = true
+			}
+		}
+		if !found {
+			result = append(result, validationError{In: "body", Reason: reasonOf(requestErr)})
+		}
+	}
+
+	return result
+}
+
+// flattenErrors lists the errors of nested openapi3.MultiErrors.
+func flattenErrors(err error) []error {
+	multi, ok := err.(openapi3.MultiError)
+	if !ok {
+		if err == nil {
+			return nil
+		}
+		return []error{err}
+	}
+
+	var errs []error
+	for _, err := range multi {
+		errs = append(errs, flattenErrors(err)...)
+	}
+	return errs
+}
+
+// reasonOf describes a RequestError without repeating where it is.
+func reasonOf(err *openapi3filter.RequestError) string {
+	var reasons []string
+	for _, err := range flattenErrors(err.Err) {
+		if schemaErr, ok := err.(*openapi3.SchemaError); ok {
+			reasons = append(reasons, schemaErr.Reason)
+		}
+	}
+	if len(reasons) > 0 {
+		return strings.Join(reasons, "; ")
+	}
+
+	if err.Err == nil {
+		return err.Reason
+	}
+	if err.Reason == "" {
+		return err.Err.Error()
+	}
+	return err.Reason + ": " + err.Err.Error()
+}
+
+// jsonPointer joins the tokens of a JSON Pointer (RFC 6901).
+func jsonPointer(tokens []string) string {
+	var pointer strings.Builder
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		pointer.WriteString("/" + token)
+	}
+
+	return pointer.String()
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// validationResponse is the body of a request rejected by ValidateRequest.
+type validationResponse struct {
+	Message string            `json:"message"`
+	Errors  []validationError `json:"errors"`
+}
+
+type OpenAPITestSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func (suite *OpenAPITestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+
+	oc := NewOpenAPIController()
+	suite.router = gin.New()
+	suite.router.LoadHTMLGlob("../templates/*.gohtml")
+	suite.router.GET("/api/openapi.json", oc.Spec)
+	suite.router.GET("/docs", oc.Docs)
+
+	// echo answers with the body it got, to show that validation leaves it
+	// to be read.
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/json", body)
+	}
+	validated := suite.router.Group("/api", oc.ValidateRequest)
+	validated.POST("/task", echo)
+	validated.GET("/tasks", echo)
+	validated.PATCH("/task/:id", echo)
+	validated.GET("/undocumented", echo)
+	validated.POST("/v2/tasks", echo)
+}
+
+func (suite *OpenAPITestSuite) request(method, url, contentType, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// rejected asserts that the request was answered with a 400 listing what
+// is wrong, and returns the list.
+func (suite *OpenAPITestSuite) rejected(w *httptest.ResponseRecorder) []validationError {
+	suite.Require().Equal(http.StatusBadRequest, w.Code, w.Body.String())
+
+	var response validationResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Invalid request", response.Message)
+	return response.Errors
+}
+
+func (suite *OpenAPITestSuite) TestSpec() {
+	w := suite.request("GET", "/api/openapi.json", "", "")
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("Content-Type"), "application/json")
+
+	var doc struct {
+		OpenAPI    string                            `json:"openapi"`
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(suite.T(), "3.0.3", doc.OpenAPI)
+	assert.Contains(suite.T(), doc.Paths["/api/v1/task/{id}"], "patch")
+	assert.Contains(suite.T(), doc.Paths["/api/v2/tasks/{id}"], "patch")
+	assert.Contains(suite.T(), doc.Components.Schemas, "Task")
+	assert.Contains(suite.T(), doc.Components.Schemas, "TaskV2")
+}
+
+func (suite *OpenAPITestSuite) TestDocs() {
+	w := suite.request("GET", "/docs", "", "")
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `src="/static/js/docs.js"`)
+	// Everything the page needs is served by the app itself.
+	assert.NotContains(suite.T(), w.Body.String(), "https://")
+}
+
+func (suite *OpenAPITestSuite) TestValidRequestPassesThrough() {
+	body := `{"description": "Buy milk", "dueAt": "2030-05-01T09:00:00Z", "listId": null}`
+	w := suite.request("POST", "/api/task", "application/json", body)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), body, w.Body.String())
+
+	w = suite.request("GET", "/api/tasks?status=open&limit=10&sort=-dueAt&count=true", "", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	w = suite.request("PATCH", "/api/task/65f000000000000000000000", "application/merge-patch+json", `{"dueAt": null}`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *OpenAPITestSuite) TestUndocumentedRoutePassesThrough() {
+	w := suite.request("GET", "/api/undocumented?limit=0", "", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *OpenAPITestSuite) TestInvalidQuery() {
+	errors := suite.rejected(suite.request("GET", "/api/tasks?limit=0&status=later", "", ""))
+
+	suite.Require().Len(errors, 2)
+	fields := map[string]string{}
+	for _, err := range errors {
+		assert.Equal(suite.T(), "query", err.In)
+		assert.NotEmpty(suite.T(), err.Reason)
+		fields[err.Name] = err.Reason
+	}
+	assert.Contains(suite.T(), fields, "limit")
+	assert.Contains(suite.T(), fields, "status")
+}
+
+func (suite *OpenAPITestSuite) TestInvalidPath() {
+	errors := suite.rejected(suite.request("PATCH", "/api/task/nope", "application/merge-patch+json", `{}`))
+
+	suite.Require().Len(errors, 1)
+	assert.Equal(suite.T(), "path", errors[0].In)
+	assert.Equal(suite.T(), "id", errors[0].Name)
+}
+
+func (suite *OpenAPITestSuite) TestInvalidBody() {
+	errors := suite.rejected(suite.request("POST", "/api/task", "application/json",
+		`{"description": "Buy milk", "dueAt": "tomorrow", "priority": "high", "tags": ["shop", 1]}`))
+
+	pointers := map[string]string{}
+	for _, err := range errors {
+		assert.Equal(suite.T(), "body", err.In)
+		pointers[err.Pointer] = err.Reason
+	}
+	assert.Len(suite.T(), pointers, 3)
+	assert.Contains(suite.T(), pointers, "/dueAt")
+	assert.Contains(suite.T(), pointers, "/priority")
+	assert.Contains(suite.T(), pointers, "/tags/1")
+}
+
+func (suite *OpenAPITestSuite) TestVersions() {
+	// /api/task is checked as /api/v1/task, which takes a description.
+	w := suite.request("POST", "/api/task", "application/json", `{"description": "Buy milk"}`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	w = suite.request("POST", "/api/v2/tasks", "application/json", `{"title": "Buy milk", "due": {"at": "2030-05-01T09:00:00Z"}}`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	errors := suite.rejected(suite.request("POST", "/api/v2/tasks", "application/json", `{"description": "Buy milk", "status": "later"}`))
+	reasons := map[string]string{}
+	for _, err := range errors {
+		assert.Equal(suite.T(), "body", err.In)
+		reasons[err.Pointer] = err.Reason
+	}
+	assert.Contains(suite.T(), reasons, "/status")
+	assert.Contains(suite.T(), reasons, "/title")
+}
+
+func (suite *OpenAPITestSuite) TestInvalidContentType() {
+	errors := suite.rejected(suite.request("POST", "/api/v2/tasks", "text/plain", `{"title": "Buy milk"}`))
+
+	suite.Require().Len(errors, 1)
+	assert.Equal(suite.T(), "body", errors[0].In)
+	assert.Contains(suite.T(), errors[0].Reason, "text/plain")
+
+	errors = suite.rejected(suite.request("POST", "/api/task", "application/json", ""))
+	suite.Require().Len(errors, 1)
+	assert.Equal(suite.T(), "body", errors[0].In)
+}
+
+func (suite *OpenAPITestSuite) TestVersion1ReadsAnyBodyAsJSON() {
+	// As sent by curl -d.
+	for _, contentType := range []string{"application/x-www-form-urlencoded", "text/plain", ""} {
+		w := suite.request("POST", "/api/task", contentType, `{"description": "Buy milk"}`)
+		assert.Equal(suite.T(), http.StatusOK, w.Code, contentType)
+		assert.JSONEq(suite.T(), `{"description": "Buy milk"}`, w.Body.String(), contentType)
+	}
+
+	errors := suite.rejected(suite.request("POST", "/api/task", "text/plain", "Buy milk"))
+	suite.Require().Len(errors, 1)
+	assert.Equal(suite.T(), "body", errors[0].In)
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	assert.Equal(t, "/api/task/{id}/toggle", openAPIPath("/api/task/:id/toggle"))
+	assert.Equal(t, "/caldav/tasks/{name}", openAPIPath("/caldav/tasks/:name"))
+	assert.Equal(t, "/static/{filepath}", openAPIPath("/static/*filepath"))
+	assert.Equal(t, "/api/tasks", openAPIPath("/api/tasks"))
+}
+
+func TestOpenAPITestSuite(t *testing.T) {
+	suite.Run(t, new(OpenAPITestSuite))
+}
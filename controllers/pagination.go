@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 500
+	viewPageSize    = 50
+	// snippetWidth is the approximate length of search highlights.
+	snippetWidth = 80
+)
+
+var sortFields = map[string]repository.SortField{
+	"created":     repository.SortCreated,
+	"dueAt":       repository.SortDueAt,
+	"priority":    repository.SortPriority,
+	"description": repository.SortDescription,
+	"relevance":   repository.SortRelevance,
+}
+
+// page is one window of a sorted task list.
+type page struct {
+	sort    string
+	options repository.ListOptions
+	// first and last are the positions of the tasks the page starts and
+	// ends with, once trimmed.
+	first, last *repository.Position
+	// hasPrev reports whether there are tasks before the page.
+	hasPrev bool
+}
+
+// pageCursor is the payload of the opaque ?cursor= token: the position
+// of the task a page follows or precedes. The sort is kept so a cursor
+// cannot be replayed against a different ordering.
+type pageCursor struct {
+	After  *repository.Position `json:"a,omitempty"`
+	Before *repository.Position `json:"b,omitempty"`
+	Sort   string               `json:"s"`
+}
+
+// readPage reads the sort, limit and cursor parameters. A leading "-" on
+// the sort field reverses the order. On failure it returns the message
+// to answer with, otherwise "".
+func readPage(query params, defaultSort string, defaultLimit int) (page, string) {
+	p := page{sort: defaultSort}
+	if sort, ok := query("sort"); ok {
+		p.sort = sort
+	}
+
+	field, ok := sortFields[strings.TrimPrefix(p.sort, "-")]
+	if !ok {
+		return p, "Invalid sort field"
+	}
+	p.options.Sort = field
+	p.options.Descending = strings.HasPrefix(p.sort, "-")
+
+	p.options.Limit = defaultLimit
+	if raw := query.get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return p, "Invalid limit parameter"
+		}
+		p.options.Limit = limit
+	}
+
+	if raw := query.get("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil || cursor.Sort != p.sort || cursor.After != nil && cursor.Before != nil {
+			return p, "Invalid cursor"
+		}
+		p.options.After, p.options.Before = cursor.After, cursor.Before
+	}
+
+	return p, ""
+}
+
+// query asks for one task more than the page holds, to detect a next page.
+func (p page) query() repository.ListOptions {
+	options := p.options
+	options.Limit++
+	return options
+}
+
+// trim drops the look-ahead task fetched by query and reports whether
+// there are more tasks after this page. It notes where the page starts
+// and ends for the cursors of the pages around it.
+func (p *page) trim(tasks []models.Task) ([]models.Task, bool) {
+	more := len(tasks) > p.options.Limit
+	if more && p.options.Before != nil {
+		// Paging backwards, the look-ahead task is the one in front.
+		tasks = tasks[1:]
+	} else if more {
+		tasks = tasks[:p.options.Limit]
+	}
+
+	p.first, p.last = nil, nil
+	if len(tasks) > 0 {
+		first := repository.PositionOf(tasks[0], p.options.Sort)
+		last := repository.PositionOf(tasks[len(tasks)-1], p.options.Sort)
+		p.first, p.last = &first, &last
+	}
+
+	if p.options.Before != nil {
+		p.hasPrev = more
+		return tasks, p.last != nil
+	}
+	p.hasPrev = p.options.After != nil
+	return tasks, more
+}
+
+// next returns the page following p, once p is trimmed.
+func (p page) next() page {
+	p.options.After, p.options.Before = p.last, nil
+	return p
+}
+
+func (p page) nextCursor() string {
+	return encodeCursor(pageCursor{After: p.last, Sort: p.sort})
+}
+
+// prevCursor returns the cursor of the previous page, or "" for the first
+// page.
+func (p page) prevCursor() string {
+	if !p.hasPrev || p.first == nil {
+		return ""
+	}
+	return encodeCursor(pageCursor{Before: p.first, Sort: p.sort})
+}
+
+// pageURL is the current request URL with its cursor replaced.
+func pageURL(c *gin.Context, cursor string) string {
+	u := *c.Request.URL
+	query := u.Query()
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (pageCursor, error) {
+	var cursor pageCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, err
+	}
+
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultOccurrences = 5
+	maxOccurrences     = 100
+)
+
+// GetOccurrences lists the next ?count=N due dates of a recurring task,
+// starting with the current one.
+func (tc TaskController) GetOccurrences(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	count := defaultOccurrences
+	if raw := c.Query("count"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxOccurrences {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid count parameter"})
+			return
+		}
+		count = parsed
+	}
+
+	task, ok := tc.findTask(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	if task.Recurrence == nil || task.DueAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Task is not recurring"})
+		return
+	}
+
+	c.JSON(http.StatusOK, task.Recurrence.Upcoming(*task.DueAt, count))
+}
+
+// checkRecurrence validates a task's recurrence and anchors new series at
+// the due date. It returns http.StatusOK, or the status and message to
+// answer with.
+func checkRecurrence(task *models.Task) (int, string) {
+	if task.Recurrence == nil {
+		return http.StatusOK, ""
+	}
+
+	if task.DueAt == nil {
+		return http.StatusBadRequest, "Recurring tasks need a due date"
+	}
+
+	if task.Recurrence.Start.IsZero() {
+		task.Recurrence.Start = *task.DueAt
+	}
+
+	switch err := task.Recurrence.Validate(); {
+	case errors.Is(err, models.ErrInvalidTimeZone):
+		return http.StatusBadRequest, "Invalid time zone"
+	case errors.Is(err, models.ErrInvalidMode):
+		return http.StatusBadRequest, "Invalid recurrence mode"
+	case err != nil:
+		return http.StatusBadRequest, "Invalid recurrence rule"
+	}
+
+	return http.StatusOK, ""
+}
+
+// insertNext stores the occurrence generated by completing a recurring
+// task, if any.
+func (tc TaskController) insertNext(ctx context.Context, next *models.Task) (*models.Task, error) {
+	if next == nil {
+		return nil, nil
+	}
+
+	inserted, err := tc.repo.Insert(ctx, *next)
+	if err != nil {
+		return nil, err
+	}
+
+	inserted.ComputeDueFlags(tc.now())
+	tc.publish(ctx, inserted.OwnerId, webhook.EventTaskCreated, inserted)
+	return &inserted, nil
+}
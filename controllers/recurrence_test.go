@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RecurrenceTestSuite struct {
+	controllerSuite
+}
+
+func (suite *RecurrenceTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.router.POST("/api/task", suite.controller.CreateTask)
+	suite.router.PATCH("/api/task/:id", suite.controller.UpdateTask)
+	suite.router.POST("/api/task/:id/toggle", suite.controller.ToggleTask)
+	suite.router.GET("/api/task/:id/occurrences", suite.controller.GetOccurrences)
+}
+
+func (suite *RecurrenceTestSuite) TestCreateAnchorsSeries() {
+	task := suite.create(gin.H{
+		"description": "Weekly review",
+		"dueAt":       "2025-03-14T16:00:00Z",
+		"recurrence":  gin.H{"rule": "FREQ=WEEKLY;BYDAY=FR", "timeZone": "Europe/Warsaw"},
+	})
+
+	suite.Require().NotNil(task.Recurrence)
+	assert.Equal(suite.T(), "2025-03-14T16:00:00Z", task.Recurrence.Start.Format(time.RFC3339))
+}
+
+func (suite *RecurrenceTestSuite) TestCreateValidation() {
+	for _, body := range []gin.H{
+		{"description": "No due date", "recurrence": gin.H{"rule": "FREQ=DAILY"}},
+		{"description": "Bad rule", "dueAt": "2025-03-14T16:00:00Z", "recurrence": gin.H{"rule": "FREQ=OFTEN"}},
+		{"description": "Bad zone", "dueAt": "2025-03-14T16:00:00Z", "recurrence": gin.H{"rule": "FREQ=DAILY", "timeZone": "Nowhere/Town"}},
+		{"description": "Bad mode", "dueAt": "2025-03-14T16:00:00Z", "recurrence": gin.H{"rule": "FREQ=DAILY", "mode": "loop"}},
+	} {
+		w := suite.request("POST", "/api/task", body)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func (suite *RecurrenceTestSuite) TestToggleGeneratesNextOccurrence() {
+	task := suite.create(gin.H{
+		"description": "Invoice",
+		"dueAt":       "2025-03-31T09:00:00Z",
+		"recurrence":  gin.H{"rule": "FREQ=MONTHLY;BYMONTHDAY=-1"},
+	})
+
+	w := suite.request("POST", "/api/task/"+task.Id.Hex()+"/toggle", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var toggled models.Task
+	err := json.Unmarshal(w.Body.Bytes(), &toggled)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), toggled.Completed)
+	assert.Nil(suite.T(), toggled.Recurrence)
+	suite.Require().NotNil(toggled.Next)
+	assert.Equal(suite.T(), "2025-04-30T09:00:00Z", toggled.Next.DueAt.Format(time.RFC3339))
+
+	stored, err := suite.repo.Get(context.Background(), toggled.Next.Id)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), stored.Completed)
+	assert.NotNil(suite.T(), stored.Recurrence)
+
+	// Reopening the done occurrence does not spawn another one.
+	w = suite.request("POST", "/api/task/"+task.Id.Hex()+"/toggle", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), `"next"`)
+
+	count, err := suite.repo.Count(context.Background(), repository.TaskFilter{})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(2), count)
+}
+
+func (suite *RecurrenceTestSuite) TestPatchCompletedRollsForward() {
+	task := suite.create(gin.H{
+		"description": "Stand-up",
+		"dueAt":       "2025-03-10T09:00:00Z",
+		"recurrence":  gin.H{"rule": "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", "mode": "roll"},
+	})
+
+	w := suite.request("PATCH", "/api/task/"+task.Id.Hex(), gin.H{"completed": true})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var rolled models.Task
+	err := json.Unmarshal(w.Body.Bytes(), &rolled)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), task.Id, rolled.Id)
+	assert.False(suite.T(), rolled.Completed)
+	assert.Equal(suite.T(), "2025-03-11T09:00:00Z", rolled.DueAt.Format(time.RFC3339))
+	assert.Nil(suite.T(), rolled.Next)
+}
+
+func (suite *RecurrenceTestSuite) TestGetOccurrences() {
+	task := suite.create(gin.H{
+		"description": "Weekly review",
+		"dueAt":       "2025-03-24T08:00:00Z",
+		"recurrence":  gin.H{"rule": "FREQ=WEEKLY", "timeZone": "Europe/Warsaw"},
+	})
+
+	w := suite.request("GET", "/api/task/"+task.Id.Hex()+"/occurrences?count=2", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	// 09:00 in Warsaw both before and after the switch to summer time
+	assert.JSONEq(suite.T(), `["2025-03-24T09:00:00+01:00", "2025-03-31T09:00:00+02:00"]`, w.Body.String())
+
+	w = suite.request("GET", "/api/task/"+task.Id.Hex()+"/occurrences?count=0", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	plain := suite.create(gin.H{"description": "Once"})
+	w = suite.request("GET", "/api/task/"+plain.Id.Hex()+"/occurrences", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestRecurrenceSuite(t *testing.T) {
+	suite.Run(t, new(RecurrenceTestSuite))
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"example.com/todo-rest-api/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Settings are the configurable values the controllers read on every use,
+// so that they can change while the app runs; see Configure.
+type Settings struct {
+	// RequestTimeout bounds the storage calls of one request.
+	RequestTimeout time.Duration
+	// TrashRetention is how long deleted tasks stay in the trash before
+	// PurgeTrash deletes them for good.
+	TrashRetention time.Duration
+	// ConfirmTTL is how long a DELETE /api/tasks confirmation token stays
+	// valid.
+	ConfirmTTL time.Duration
+	// UndoWindow is how long a confirmed bulk delete can be undone.
+	UndoWindow time.Duration
+	// LogLevel is debug, info, warn or error; see RequestLogger.
+	LogLevel string
+	// SecureCookies marks session cookies Secure even on plain HTTP
+	// requests, for a proxy in front that terminates TLS.
+	SecureCookies bool
+}
+
+// DefaultSettings are in effect until Configure is called: those of
+// config.Default.
+var DefaultSettings = SettingsFrom(config.Default())
+
+// SettingsFrom picks the settings of the controllers out of cfg.
+func SettingsFrom(cfg config.Config) Settings {
+	return Settings{
+		RequestTimeout: time.Duration(cfg.Server.RequestTimeout),
+		TrashRetention: time.Duration(cfg.Tasks.TrashRetention),
+		ConfirmTTL:     time.Duration(cfg.Tasks.ConfirmTTL),
+		UndoWindow:     time.Duration(cfg.Tasks.UndoWindow),
+		LogLevel:       cfg.Log.Level,
+		SecureCookies:  bool(cfg.Server.SecureCookies),
+	}
+}
+
+var current atomic.Pointer[Settings]
+
+// Configure replaces the settings of every controller. It is safe to call
+// while requests are being handled; those already running may see either.
+func Configure(s Settings) {
+	current.Store(&s)
+}
+
+func settings() Settings {
+	if s := current.Load(); s != nil {
+		return *s
+	}
+	return DefaultSettings
+}
+
+// RequestLogger logs requests in the format of gin.Logger, leaving out
+// those below the log level of the settings: at warn only failed requests
+// are logged, at error only those that failed on the server.
+func RequestLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		switch settings().LogLevel {
+		case "warn":
+			if p.StatusCode < http.StatusBadRequest {
+				return ""
+			}
+		case "error":
+			if p.StatusCode < http.StatusInternalServerError {
+				return ""
+			}
+		}
+
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			p.StatusCode,
+			p.Latency,
+			p.ClientIP,
+			p.Method,
+			p.Path,
+			p.ErrorMessage,
+		)
+	})
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigure(t *testing.T) {
+	defer Configure(DefaultSettings)
+
+	assert.Equal(t, DefaultSettings, settings())
+
+	s := DefaultSettings
+	s.RequestTimeout = time.Second
+	Configure(s)
+	assert.Equal(t, time.Second, settings().RequestTimeout)
+}
+
+func TestSettingsFrom(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.RequestTimeout = config.Duration(time.Second)
+	cfg.Server.SecureCookies = true
+	cfg.Tasks.TrashRetention = config.Duration(time.Hour)
+	cfg.Tasks.ConfirmTTL = config.Duration(time.Minute)
+	cfg.Tasks.UndoWindow = config.Duration(30 * time.Minute)
+	cfg.Log.Level = config.LevelWarn
+
+	assert.Equal(t, Settings{
+		RequestTimeout: time.Second,
+		TrashRetention: time.Hour,
+		ConfirmTTL:     time.Minute,
+		UndoWindow:     30 * time.Minute,
+		LogLevel:       "warn",
+		SecureCookies:  true,
+	}, SettingsFrom(cfg))
+}
+
+func TestRequestLogger(t *testing.T) {
+	defer Configure(DefaultSettings)
+
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	defaultWriter := gin.DefaultWriter
+	gin.DefaultWriter = &out
+	defer func() { gin.DefaultWriter = defaultWriter }()
+
+	router := gin.New()
+	router.Use(RequestLogger())
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	router.GET("/broken", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	logged := func(level string) string {
+		s := DefaultSettings
+		s.LogLevel = level
+		Configure(s)
+
+		out.Reset()
+		for _, url := range []string{"/ok", "/missing", "/broken"} {
+			req, _ := http.NewRequest("GET", url, nil)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		return out.String()
+	}
+
+	log := logged("info")
+	assert.Contains(t, log, `"/ok"`)
+	assert.Contains(t, log, `"/missing"`)
+
+	// The level applies to the next request, without a restart.
+	log = logged("warn")
+	assert.NotContains(t, log, `"/ok"`)
+	assert.Contains(t, log, `"/missing"`)
+	assert.Contains(t, log, `"/broken"`)
+
+	log = logged("error")
+	assert.NotContains(t, log, `"/missing"`)
+	assert.Contains(t, log, `"/broken"`)
+}
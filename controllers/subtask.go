@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Policies for the subtasks of a deleted task, chosen with ?children=.
+const (
+	// childrenPromote moves subtasks up to the deleted task's parent.
+	childrenPromote = "promote"
+	// childrenCascade deletes subtasks along with their parent.
+	childrenCascade = "cascade"
+)
+
+// GetChildren lists the direct subtasks of a task, oldest first. With
+// ?tree=true each one carries its own subtasks.
+func (tc TaskController) GetChildren(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	now, ok := tc.clock(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := tc.findTask(ctx, c, objectID); !ok {
+		return
+	}
+
+	children, err := tc.repo.List(ctx, repository.TaskFilter{
+		Owner:   currentUser(c),
+		Parents: []bson.ObjectID{objectID},
+	}, repository.ListOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	children, err = tc.withSubtasks(ctx, c, children, c.Query("tree") == "true", now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, children)
+}
+
+// withSubtasks computes the due flags and subtask progress of tasks. In
+// tree mode the subtasks are nested under Children as well.
+func (tc TaskController) withSubtasks(ctx context.Context, c *gin.Context, tasks []models.Task, tree bool, now time.Time) ([]models.Task, error) {
+	children, err := loadSubtasks(ctx, tc.repo, currentUser(c), tasks)
+	if err != nil {
+		return tasks, err
+	}
+
+	for i := range tasks {
+		tasks[i] = buildTask(tasks[i], children, tree, now, map[bson.ObjectID]bool{})
+	}
+
+	return tasks, nil
+}
+
+// buildTask fills in task from the subtasks in children. visiting holds the
+// tasks on the current path, so stored cycles cannot recurse forever.
+func buildTask(task models.Task, children map[bson.ObjectID][]models.Task, tree bool, now time.Time, visiting map[bson.ObjectID]bool) models.Task {
+	task.ComputeDueFlags(now)
+
+	visiting[task.Id] = true
+	defer delete(visiting, task.Id)
+
+	var progress models.Progress
+	for _, child := range children[task.Id] {
+		if visiting[child.Id] {
+			continue
+		}
+
+		child = buildTask(child, children, tree, now, visiting)
+
+		progress.Total++
+		if child.Completed {
+			progress.Done++
+		}
+		if child.Progress != nil {
+			progress.Done += child.Progress.Done
+			progress.Total += child.Progress.Total
+		}
+
+		if tree {
+			task.Children = append(task.Children, child)
+		}
+	}
+
+	if progress.Total > 0 {
+		task.Progress = &progress
+	}
+
+	return task
+}
+
+// loadSubtasks fetches every descendant of tasks, one level per query,
+// grouped by parent id in creation order.
+func loadSubtasks(ctx context.Context, repo repository.TaskRepository, owner *bson.ObjectID, tasks []models.Task) (map[bson.ObjectID][]models.Task, error) {
+	children := make(map[bson.ObjectID][]models.Task)
+	placed := make(map[bson.ObjectID]bool)
+	expanded := make(map[bson.ObjectID]bool)
+
+	var frontier []bson.ObjectID
+	for _, task := range tasks {
+		if !expanded[task.Id] {
+			expanded[task.Id] = true
+			frontier = append(frontier, task.Id)
+		}
+	}
+
+	for len(frontier) > 0 {
+		level, err := repo.List(ctx, repository.TaskFilter{Owner: owner, Parents: frontier}, repository.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for _, child := range level {
+			if !placed[child.Id] {
+				placed[child.Id] = true
+				children[*child.ParentId] = append(children[*child.ParentId], child)
+			}
+			if !expanded[child.Id] {
+				expanded[child.Id] = true
+				frontier = append(frontier, child.Id)
+			}
+		}
+	}
+
+	return children, nil
+}
+
+// checkParent verifies that a task's parent exists, belongs to the user
+// and is neither the task itself nor one of its subtasks. It returns
+// http.StatusOK, or the status and message to answer with.
+func (tc TaskController) checkParent(ctx context.Context, c *gin.Context, task models.Task) (int, string) {
+	seen := make(map[bson.ObjectID]bool)
+
+	for parentID := task.ParentId; parentID != nil && !seen[*parentID]; {
+		if *parentID == task.Id {
+			return http.StatusBadRequest, "A task cannot be its own subtask"
+		}
+		seen[*parentID] = true
+
+		parent, err := tc.repo.Get(ctx, *parentID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && (!ownedBy(c, parent.OwnerId) || parent.DeletedAt != nil)) {
+			return http.StatusBadRequest, "Parent task not found"
+		}
+		if err != nil {
+			return http.StatusInternalServerError, "Unable to fetch task"
+		}
+
+		parentID = parent.ParentId
+	}
+
+	return http.StatusOK, ""
+}
+
+// parseChildrenPolicy reads ?children=, defaulting to promote. On failure
+// it writes a 400 response and returns false.
+func parseChildrenPolicy(c *gin.Context) (string, bool) {
+	policy := c.DefaultQuery("children", childrenPromote)
+	if policy != childrenPromote && policy != childrenCascade {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid children policy"})
+		return policy, false
+	}
+
+	return policy, true
+}
+
+// removeTasks moves the tasks matching filter to the trash and returns the
+// ids of those moved. With the cascade policy their subtasks go too;
+// otherwise each subtask moves up to its nearest ancestor that is not being
+// deleted, which is announced as an update. Announcing the deleted tasks is
+// left to the caller.
+func (tc TaskController) removeTasks(ctx context.Context, c *gin.Context, filter repository.TaskFilter, policy string, now time.Time) ([]bson.ObjectID, error) {
+	tasks, err := tc.repo.List(ctx, filter, repository.ListOptions{})
+	if err != nil || len(tasks) == 0 {
+		return nil, err
+	}
+
+	ids := make([]bson.ObjectID, 0, len(tasks))
+	parents := make(map[bson.ObjectID]*bson.ObjectID, len(tasks))
+	var promoted []bson.ObjectID
+	for _, task := range tasks {
+		ids = append(ids, task.Id)
+		parents[task.Id] = task.ParentId
+	}
+
+	if policy == childrenCascade {
+		children, err := loadSubtasks(ctx, tc.repo, filter.Owner, tasks)
+		if err != nil {
+			return nil, err
+		}
+		for _, level := range children {
+			for _, child := range level {
+				if _, deleted := parents[child.Id]; !deleted {
+					parents[child.Id] = child.ParentId
+					ids = append(ids, child.Id)
+				}
+			}
+		}
+	} else {
+		children, err := tc.repo.List(ctx, repository.TaskFilter{Owner: filter.Owner, Parents: ids}, repository.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			if _, deleted := parents[child.Id]; !deleted {
+				promoted = append(promoted, child.Id)
+			}
+		}
+
+		for _, task := range tasks {
+			target := task.ParentId
+			for steps := 0; target != nil && steps < len(tasks); steps++ {
+				next, deleted := parents[*target]
+				if !deleted {
+					break
+				}
+				target = next
+			}
+
+			_, err := tc.repo.SetParent(ctx, repository.TaskFilter{
+				Owner:   filter.Owner,
+				Parents: []bson.ObjectID{task.Id},
+			}, target)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := tc.repo.SetDeleted(ctx, repository.TaskFilter{Owner: filter.Owner, Ids: ids}, &now); err != nil {
+		return nil, err
+	}
+
+	tc.publishTasks(ctx, c, webhook.EventTaskUpdated, promoted)
+	return ids, nil
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"example.com/todo-rest-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type SubtaskTestSuite struct {
+	controllerSuite
+}
+
+func (suite *SubtaskTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.router.POST("/api/task", suite.controller.CreateTask)
+	suite.router.GET("/api/tasks", suite.controller.GetTasks)
+	suite.router.PATCH("/api/task/:id", suite.controller.UpdateTask)
+	suite.router.POST("/api/task/:id/toggle", suite.controller.ToggleTask)
+	suite.router.GET("/api/task/:id/children", suite.controller.GetChildren)
+	suite.router.DELETE("/api/task/:id", suite.controller.DeleteTask)
+	suite.router.DELETE("/api/tasks", suite.controller.DeleteAllTasks)
+}
+
+// insert stores a task under parent (nil for the top level).
+func (suite *SubtaskTestSuite) insert(description string, parent *models.Task, completed bool) models.Task {
+	task := models.Task{Description: description, Completed: completed}
+	if parent != nil {
+		task.ParentId = &parent.Id
+	}
+
+	task, err := suite.repo.Insert(context.Background(), task)
+	suite.Require().NoError(err)
+	return task
+}
+
+func (suite *SubtaskTestSuite) remaining() []string {
+	descriptions := []string{}
+	for _, task := range suite.stored() {
+		descriptions = append(descriptions, task.Description)
+	}
+	return descriptions
+}
+
+func (suite *SubtaskTestSuite) TestCreateSubtask() {
+	parent := suite.insert("Release", nil, false)
+
+	w := suite.request("POST", "/api/task", gin.H{"description": "Tag", "parentId": parent.Id.Hex()})
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var child models.Task
+	err := json.Unmarshal(w.Body.Bytes(), &child)
+	assert.NoError(suite.T(), err)
+	suite.Require().NotNil(child.ParentId)
+	assert.Equal(suite.T(), parent.Id, *child.ParentId)
+
+	w = suite.request("POST", "/api/task", gin.H{"description": "Orphan", "parentId": bson.NewObjectID().Hex()})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *SubtaskTestSuite) TestProgressRollsUp() {
+	parent := suite.insert("Release", nil, false)
+	build := suite.insert("Build", &parent, true)
+	suite.insert("Compile", &build, true)
+	suite.insert("Link", &build, false)
+	suite.insert("Publish", &parent, false)
+
+	w := suite.request("GET", "/api/tasks", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var tasks []models.Task
+	err := json.Unmarshal(w.Body.Bytes(), &tasks)
+	assert.NoError(suite.T(), err)
+	suite.Require().Len(tasks, 5)
+
+	assert.Equal(suite.T(), &models.Progress{Done: 2, Total: 4}, tasks[0].Progress)
+	assert.Equal(suite.T(), &models.Progress{Done: 1, Total: 2}, tasks[1].Progress)
+	assert.Nil(suite.T(), tasks[2].Progress)
+	assert.Empty(suite.T(), tasks[0].Children)
+}
+
+func (suite *SubtaskTestSuite) TestTreeMode() {
+	parent := suite.insert("Release", nil, false)
+	build := suite.insert("Build", &parent, false)
+	suite.insert("Compile", &build, false)
+	suite.insert("Standalone", nil, false)
+
+	w := suite.request("GET", "/api/tasks?tree=true&count=true", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "2", w.Header().Get("X-Total-Count"))
+
+	var tasks []models.Task
+	err := json.Unmarshal(w.Body.Bytes(), &tasks)
+	assert.NoError(suite.T(), err)
+	suite.Require().Len(tasks, 2)
+
+	assert.Equal(suite.T(), "Release", tasks[0].Description)
+	suite.Require().Len(tasks[0].Children, 1)
+	assert.Equal(suite.T(), "Build", tasks[0].Children[0].Description)
+	suite.Require().Len(tasks[0].Children[0].Children, 1)
+	assert.Equal(suite.T(), "Compile", tasks[0].Children[0].Children[0].Description)
+	assert.Empty(suite.T(), tasks[1].Children)
+}
+
+func (suite *SubtaskTestSuite) TestGetChildren() {
+	parent := suite.insert("Release", nil, false)
+	build := suite.insert("Build", &parent, true)
+	suite.insert("Compile", &build, false)
+
+	w := suite.request("GET", "/api/task/"+parent.Id.Hex()+"/children", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var children []models.Task
+	err := json.Unmarshal(w.Body.Bytes(), &children)
+	assert.NoError(suite.T(), err)
+	suite.Require().Len(children, 1)
+	assert.Equal(suite.T(), "Build", children[0].Description)
+	assert.Equal(suite.T(), &models.Progress{Done: 0, Total: 1}, children[0].Progress)
+
+	w = suite.request("GET", "/api/task/"+bson.NewObjectID().Hex()+"/children", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *SubtaskTestSuite) TestRejectsCycles() {
+	parent := suite.insert("Release", nil, false)
+	child := suite.insert("Build", &parent, false)
+	grandchild := suite.insert("Compile", &child, false)
+
+	w := suite.request("PATCH", "/api/task/"+parent.Id.Hex(), gin.H{"parentId": grandchild.Id.Hex()})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request("PATCH", "/api/task/"+parent.Id.Hex(), gin.H{"parentId": parent.Id.Hex()})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Moving a subtask back to the top level is fine.
+	w = suite.request("PATCH", "/api/task/"+grandchild.Id.Hex(), gin.H{"parentId": nil})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), "parentId")
+}
+
+func (suite *SubtaskTestSuite) TestDeletePromotesChildren() {
+	parent := suite.insert("Release", nil, false)
+	child := suite.insert("Build", &parent, false)
+	grandchild := suite.insert("Compile", &child, false)
+
+	w := suite.request("DELETE", "/api/task/"+child.Id.Hex(), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	stored, err := suite.repo.Get(context.Background(), grandchild.Id)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(stored.ParentId)
+	assert.Equal(suite.T(), parent.Id, *stored.ParentId)
+	assert.Equal(suite.T(), []string{"Release", "Compile"}, suite.remaining())
+}
+
+func (suite *SubtaskTestSuite) TestDeleteCascade() {
+	parent := suite.insert("Release", nil, false)
+	child := suite.insert("Build", &parent, false)
+	suite.insert("Compile", &child, false)
+	suite.insert("Standalone", nil, false)
+
+	w := suite.request("DELETE", "/api/task/"+parent.Id.Hex()+"?children=cascade", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), []string{"Standalone"}, suite.remaining())
+
+	w = suite.request("DELETE", "/api/task/"+child.Id.Hex()+"?children=orphan", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *SubtaskTestSuite) TestDeleteAllPromotesPastDeletedAncestors() {
+	parent := suite.insert("Release", nil, true)
+	child := suite.insert("Build", &parent, true)
+	grandchild := suite.insert("Compile", &child, false)
+
+	w := deleteAll(suite.T(), suite.router, "/api/tasks?status=done")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"deletedCount":2`)
+
+	stored, err := suite.repo.Get(context.Background(), grandchild.Id)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), stored.ParentId)
+}
+
+func TestSubtaskSuite(t *testing.T) {
+	suite.Run(t, new(SubtaskTestSuite))
+}
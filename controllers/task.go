@@ -2,162 +2,561 @@ package controllers
 
 import (
 	"context"
-	"time"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
 	"net/http"
-    "log"
+	"strconv"
+	"time"
 
 	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/search"
+	"example.com/todo-rest-api/webhook"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
-const (
-	defaultTimeout = 5 * time.Second
-	dbName         = "todo-app-go"
-	collectionName = "tasks"
-)
+// dbName is the database of NewTaskController and NewStore.
+const dbName = "todo-app-go"
 
 type TaskController struct {
-	collection *mongo.Collection
+	repo      repository.TaskRepository
+	lists     repository.ListRepository
+	history   repository.HistoryRepository
+	deletions repository.DeletionRepository
+	// tx makes batches atomic; nil when the storage cannot.
+	tx repository.Transactor
+	// webhooks delivers task events; nil when the storage has no webhooks.
+	webhooks *webhook.Dispatcher
+	// events is the stream of GET /api/events; nil when the storage has
+	// none.
+	events repository.EventRepository
+	now    func() time.Time
 }
 
 func NewTaskController(c *mongo.Client) *TaskController {
-	return &TaskController{
-		collection: c.Database(dbName).Collection(collectionName),
-	}
+	return NewTaskControllerWithDB(c, dbName)
 }
 
 func NewTaskControllerWithDB(c *mongo.Client, database string) *TaskController {
+	return NewTaskControllerWithStore(NewStoreWithDB(c, database))
+}
+
+// NewTaskControllerWithRepository builds a controller on top of any task
+// storage, e.g. repository.NewMemoryTaskRepository for tests or embedding.
+// Lists, history, pending deletes, webhooks and events are kept in memory;
+// use NewTaskControllerWithStore to persist them.
+func NewTaskControllerWithRepository(r repository.TaskRepository) *TaskController {
+	s := repository.Store{
+		Tasks:      r,
+		Lists:      repository.NewMemoryListRepository(),
+		History:    repository.NewMemoryHistoryRepository(),
+		Deletions:  repository.NewMemoryDeletionRepository(),
+		Webhooks:   repository.NewMemoryWebhookRepository(),
+		Deliveries: repository.NewMemoryDeliveryRepository(),
+		Events:     repository.NewMemoryEventRepository(),
+	}
+	if _, ok := r.(*repository.MemoryTaskRepository); ok {
+		s.Transactor = repository.NewMemoryTransactor(s)
+	}
+
+	return NewTaskControllerWithStore(s)
+}
+
+// NewTaskControllerWithStore builds a controller on top of s. Every change
+// made through it is recorded in s.History and sent to s.Webhooks and
+// s.Events.
+func NewTaskControllerWithStore(s repository.Store) *TaskController {
+	var dispatcher *webhook.Dispatcher
+	if s.Webhooks != nil && s.Deliveries != nil {
+		dispatcher = webhook.NewDispatcher(s.Webhooks, s.Deliveries)
+	}
+
 	return &TaskController{
-		collection: c.Database(database).Collection(collectionName),
+		repo:      repository.NewHistoryTaskRepository(s.Tasks, s.History),
+		lists:     s.Lists,
+		history:   s.History,
+		deletions: s.Deletions,
+		tx:        s.Transactor,
+		webhooks:  dispatcher,
+		events:    s.Events,
+		now:       time.Now,
 	}
 }
 
-func (tc TaskController) getContext() (context.Context, context.CancelFunc) {
-	return context.WithTimeout(context.Background(), defaultTimeout)
+// NewStore opens the MongoDB-backed repositories in the default database.
+func NewStore(c *mongo.Client) repository.Store {
+	return NewStoreWithDB(c, dbName)
+}
+
+// NewStoreWithDB opens the MongoDB-backed repositories in database and
+// makes sure their indexes exist.
+func NewStoreWithDB(c *mongo.Client, database string) repository.Store {
+	return newStore(repository.NewMongoStore(c.Database(database)))
+}
+
+// NewStoreWithCollection is NewStoreWithDB keeping the tasks in the
+// collection named tasks.
+func NewStoreWithCollection(c *mongo.Client, database, tasks string) repository.Store {
+	return newStore(repository.NewMongoStoreWithTasks(c.Database(database), tasks))
+}
+
+func newStore(store repository.Store) repository.Store {
+	ctx, cancel := context.WithTimeout(context.Background(), settings().RequestTimeout)
+	defer cancel()
+
+	if err := store.EnsureIndexes(ctx); err != nil {
+		log.Println("Failed to create indexes:", err)
+	}
+
+	return store
+}
+
+// getContext returns the context for a request's storage calls, tagged with
+// the logged-in user so that changes are attributed in the task history.
+func (tc TaskController) getContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	ctx := repository.WithActor(context.Background(), currentUser(c))
+	return context.WithTimeout(ctx, settings().RequestTimeout)
 }
 
 func (tc TaskController) GetTasks(c *gin.Context) {
-	ctx, cancel := tc.getContext()
+	ctx, cancel := tc.getContext(c)
 	defer cancel()
 
-	cursor, err := tc.collection.Find(ctx, bson.M{})
+	now, ok := tc.clock(c)
+	if !ok {
+		return
+	}
+
+	filter, p, ok := parseTaskQuery(c, "created", defaultPageSize)
+	if !ok {
+		return
+	}
 
+	tc.listTasks(ctx, c, filter, p, now)
+}
+
+// listTasks writes one page of tasks. The next page is advertised in a
+// Link header and, with ?count=true, the total in X-Total-Count. With
+// ?tree=true the page holds top-level tasks with their subtasks nested.
+func (tc TaskController) listTasks(ctx context.Context, c *gin.Context, filter repository.TaskFilter, p page, now time.Time) {
+	tree := c.Query("tree") == "true"
+	if tree {
+		filter.TopLevel = true
+	}
+
+	tasks, err := tc.repo.List(ctx, filter, p.query())
 	if err != nil {
+		log.Println("Error fetching tasks:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
 		return
 	}
-	defer cursor.Close(ctx)
 
-	var tasks []models.Task
+	if c.Query("count") == "true" {
+		total, err := tc.repo.Count(ctx, filter)
+		if err != nil {
+			log.Println("Error counting tasks:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+			return
+		}
+		c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	}
 
-	if err = cursor.All(ctx, &tasks); err != nil {
-        log.Println("Error fetching tasks:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error decoding tasks"})
-        return
+	tasks, hasMore := p.trim(tasks)
+	if hasMore {
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, pageURL(c, p.nextCursor())))
+	}
+
+	tasks, err = tc.withSubtasks(ctx, c, tasks, tree, now)
+	if err != nil {
+		log.Println("Error fetching subtasks:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	terms := search.Terms(filter.Text)
+	for i := range tasks {
+		if len(terms) > 0 {
+			tasks[i].Highlight = search.Highlight(tasks[i].Description, terms, snippetWidth)
+		}
 	}
 
 	c.JSON(http.StatusOK, tasks)
 }
 
 func (tc TaskController) CreateTask(c *gin.Context) {
-	ctx, cancel := tc.getContext()
+	ctx, cancel := tc.getContext(c)
 	defer cancel()
 
 	var newTask models.Task
 
 	if err := c.BindJSON(&newTask); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
-        return
-    }
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+
+	tc.createTask(ctx, c, newTask)
+}
+
+func (tc TaskController) createTask(ctx context.Context, c *gin.Context, newTask models.Task) {
+	newTask, status, message := tc.insertTask(ctx, c, newTask)
+	if status != http.StatusCreated {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
 
-	result, err := tc.collection.InsertOne(ctx, newTask)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create task"})
-        return
-	}
-	
-	if oid, ok := result.InsertedID.(bson.ObjectID); ok {
-        newTask.Id = oid
-    }
-	
 	c.JSON(http.StatusCreated, newTask)
+}
+
+// insertTask checks and stores a new task of the logged-in user. It
+// returns the HTTP status to answer with and, on failure, the message to
+// show.
+func (tc TaskController) insertTask(ctx context.Context, c *gin.Context, newTask models.Task) (models.Task, int, string) {
+	if status, message := tc.checkTask(ctx, c, &newTask); status != http.StatusOK {
+		return newTask, status, message
+	}
+
+	newTask.OwnerId = currentUser(c)
+	newTask.DeletedAt = nil
+	newTask.SyncCompletion(tc.now())
+	next := newTask.Advance(tc.now())
+
+	newTask, err := tc.repo.Insert(ctx, newTask)
+	if err != nil {
+		return newTask, http.StatusInternalServerError, "Failed to create task"
+	}
+
+	newTask.Next, err = tc.insertNext(ctx, next)
+	if err != nil {
+		return newTask, http.StatusInternalServerError, "Failed to create next occurrence"
+	}
+
+	newTask.ComputeDueFlags(tc.now())
+	tc.publish(ctx, newTask.OwnerId, webhook.EventTaskCreated, newTask)
+	return newTask, http.StatusCreated, ""
+}
+
+// ReplaceTask handles PUT: the request body becomes the new task in full.
+func (tc TaskController) ReplaceTask(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var task models.Task
+
+	if err := c.BindJSON(&task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+
+	current, ok := tc.findTask(ctx, c, objectID)
+	if !ok {
+		return
+	}
+	task.Id = objectID
+	task.OwnerId = current.OwnerId
+	task.Uid, task.ResourceName = current.Uid, current.ResourceName
+	task.DeletedAt = nil
+	task.SyncCompletion(tc.now())
+
+	tc.saveTask(ctx, c, task)
+}
+
+// UpdateTask handles PATCH using JSON Merge Patch (RFC 7396) semantics.
+func (tc TaskController) UpdateTask(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var patch map[string]interface{}
+
+	body, err := c.GetRawData()
+	if err != nil || json.Unmarshal(body, &patch) != nil || patch == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+
+	current, ok := tc.findTask(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	task, err := applyMergePatch(current, patch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patch document"})
+		return
+	}
+	task.Id = objectID
+	task.OwnerId = current.OwnerId
+	task.Uid, task.ResourceName = current.Uid, current.ResourceName
+	task.DeletedAt = nil
+	task.SyncCompletion(tc.now())
+
+	tc.saveTask(ctx, c, task)
+}
+
+// ToggleTask flips the completion state of a task.
+func (tc TaskController) ToggleTask(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
 
+	task, ok := tc.findTask(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	task.Completed = !task.Completed
+	task.SyncCompletion(tc.now())
+
+	tc.saveTask(ctx, c, task)
+}
+
+// findTask fetches a task of the logged-in user. Other users' tasks are
+// reported as missing. On failure it writes a 404 or 500 response and
+// returns false.
+func (tc TaskController) findTask(ctx context.Context, c *gin.Context, id bson.ObjectID) (models.Task, bool) {
+	task, status, message := tc.lookupTask(ctx, c, id)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"message": message})
+		return task, false
+	}
+
+	return task, true
+}
+
+// lookupTask is findTask without the response: it returns the HTTP status
+// to answer with and, on failure, the message to show.
+func (tc TaskController) lookupTask(ctx context.Context, c *gin.Context, id bson.ObjectID) (models.Task, int, string) {
+	task, err := tc.repo.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (!ownedBy(c, task.OwnerId) || task.DeletedAt != nil)) {
+		return task, http.StatusNotFound, "Task not found"
+	}
+	if err != nil {
+		return task, http.StatusInternalServerError, "Unable to fetch task"
+	}
+
+	return task, http.StatusOK, ""
 }
 
+func (tc TaskController) saveTask(ctx context.Context, c *gin.Context, task models.Task) {
+	task, status, message := tc.updateTask(ctx, c, task)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"message": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// updateTask checks and stores a changed task, returning it as the API
+// shows it. It returns the HTTP status to answer with and, on failure, the
+// message to show.
+func (tc TaskController) updateTask(ctx context.Context, c *gin.Context, task models.Task) (models.Task, int, string) {
+	if status, message := tc.checkTask(ctx, c, &task); status != http.StatusOK {
+		return task, status, message
+	}
+
+	next := task.Advance(tc.now())
+
+	err := tc.repo.Update(ctx, task)
+	if errors.Is(err, repository.ErrNotFound) {
+		return task, http.StatusNotFound, "Task not found"
+	}
+	if err != nil {
+		return task, http.StatusInternalServerError, "Failed to update task"
+	}
+
+	tasks, err := tc.withSubtasks(ctx, c, []models.Task{task}, false, tc.now())
+	if err != nil {
+		return task, http.StatusInternalServerError, "Unable to fetch tasks"
+	}
+
+	tasks[0].Next, err = tc.insertNext(ctx, next)
+	if err != nil {
+		return task, http.StatusInternalServerError, "Failed to create next occurrence"
+	}
+
+	tc.publish(ctx, task.OwnerId, webhook.EventTaskUpdated, tasks[0])
+	return tasks[0], http.StatusOK, ""
+}
+
+// checkTask validates the references and recurrence of a task about to be
+// stored. It returns http.StatusOK, or the status and message to answer
+// with.
+func (tc TaskController) checkTask(ctx context.Context, c *gin.Context, task *models.Task) (int, string) {
+	if status, message := tc.checkList(ctx, c, task.ListId); status != http.StatusOK {
+		return status, message
+	}
+
+	if status, message := tc.checkParent(ctx, c, *task); status != http.StatusOK {
+		return status, message
+	}
+
+	task.NormalizeTags()
+
+	return checkRecurrence(task)
+}
+
+// DeleteTask deletes a task. Its subtasks move up to its parent, or are
+// deleted along with it when called with ?children=cascade.
 func (tc TaskController) DeleteTask(c *gin.Context) {
-	ctx, cancel := tc.getContext()
+	ctx, cancel := tc.getContext(c)
 	defer cancel()
 
-	id := c.Param("id")
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	policy, ok := parseChildrenPolicy(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := tc.findTask(ctx, c, objectID); !ok {
+		return
+	}
 
-	objectID, err := bson.ObjectIDFromHex(id)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
-        return
-    }
+	deleted, err := tc.deleteTask(ctx, c, objectID, policy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete task"})
+		return
+	}
+	if len(deleted) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Task not found"})
+		return
+	}
 
-	result, err := tc.collection.DeleteOne(ctx, bson.M{"_id": objectID})
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete task"})
-        return
-    }
+	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+}
 
-	if result.DeletedCount == 0 {
-        c.JSON(http.StatusNotFound, gin.H{"message": "Task not found"})
-        return
-    }
+// deleteTask moves a task of the logged-in user to the trash, following
+// policy for its subtasks, and announces every task deleted.
+func (tc TaskController) deleteTask(ctx context.Context, c *gin.Context, id bson.ObjectID, policy string) ([]bson.ObjectID, error) {
+	deleted, err := tc.removeTasks(ctx, c, repository.TaskFilter{
+		Owner: currentUser(c),
+		Ids:   []bson.ObjectID{id},
+	}, policy, tc.now().UTC())
+	if err != nil {
+		return nil, err
+	}
 
-    c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+	for _, id := range deleted {
+		tc.publish(ctx, currentUser(c), webhook.EventTaskDeleted, deletedTask{Id: id})
+	}
 
+	return deleted, nil
 }
 
 func (tc TaskController) ShowAllTasks(c *gin.Context) {
-    ctx, cancel := tc.getContext()
-    defer cancel()
-
-    cursor, err := tc.collection.Find(ctx, bson.M{})
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
-        return
-    }
-    defer cursor.Close(ctx)
-
-    var tasks []models.Task
-    if err = cursor.All(ctx, &tasks); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"message": "Error decoding tasks"})
-        return
-    }
-
-    viewTasks := make([]models.ViewTask, 0, len(tasks))
-    for _, task := range tasks {
-        viewTasks = append(viewTasks, models.ViewTask{
-            Id:          task.Id.Hex(),
-            Description: task.Description,
-        })
-    }
-
-    data := gin.H{
-        "tasks":        viewTasks,
-        "tasksCounter": len(tasks),
-    }
-
-    c.HTML(http.StatusOK, "index.gohtml", data)
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	tc.renderTasks(ctx, c, repository.TaskFilter{}, gin.H{"activeList": ""})
 }
 
+// renderTasks renders one page of the tasks matching base, narrowed further
+// by the query string, on top of the template data already in data.
+func (tc TaskController) renderTasks(ctx context.Context, c *gin.Context, base repository.TaskFilter, data gin.H) {
+	filter, p, ok := parseTaskQuery(c, "dueAt", viewPageSize)
+	if !ok {
+		return
+	}
+	filter.List, filter.Inbox = base.List, base.Inbox
+	base.Owner = filter.Owner
+
+	tasks, err := tc.repo.List(ctx, filter, p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+	tasks, hasMore := p.trim(tasks)
+
+	now := tc.now()
+	tasks, err = tc.withSubtasks(ctx, c, tasks, false, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	completed := false
+	base.Completed = &completed
+	pending, err := tc.repo.Count(ctx, base)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	lists, err := tc.lists.List(ctx, currentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch lists"})
+		return
+	}
+
+	terms := search.Terms(filter.Text)
+	viewTasks := make([]models.ViewTask, 0, len(tasks))
+	for _, task := range tasks {
+		viewTask := newViewTask(task, now)
+		if len(terms) > 0 {
+			viewTask.Highlight = template.HTML(search.Highlight(task.Description, terms, 0))
+		}
+		viewTasks = append(viewTasks, viewTask)
+	}
+
+	data["tasks"] = viewTasks
+	data["groups"] = groupViewTasks(viewTasks)
+	data["tasksCounter"] = pending
+	data["query"] = filter.Text
+	data["lists"] = lists
+
+	if cursor := p.prevCursor(); cursor != "" {
+		data["prevPage"] = pageURL(c, cursor)
+	}
+	if hasMore {
+		data["nextPage"] = pageURL(c, p.nextCursor())
+	}
+
+	c.HTML(http.StatusOK, "index.gohtml", data)
+}
+
+// DeleteAllTasks deletes in two steps. Without ?token= it only counts the
+// tasks that would go and answers with a short-lived confirmation token;
+// repeating the call with ?token= deletes them. See bulkdelete.go.
 func (tc TaskController) DeleteAllTasks(c *gin.Context) {
-    ctx, cancel := tc.getContext()
-    defer cancel()
-
-    result, err := tc.collection.DeleteMany(ctx, bson.M{})
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete tasks"})
-        return
-    }
-
-    c.JSON(http.StatusOK, gin.H{
-        "message":      "All tasks deleted successfully",
-        "deletedCount": result.DeletedCount,
-    })
-}
\ No newline at end of file
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	if token := c.Query("token"); token != "" {
+		tc.confirmDeleteAll(ctx, c, token)
+		return
+	}
+
+	filter, ok := parseTaskFilter(c)
+	if !ok {
+		return
+	}
+
+	policy, ok := parseChildrenPolicy(c)
+	if !ok {
+		return
+	}
+
+	tc.requestDeleteAll(ctx, c, filter, policy)
+}
@@ -4,58 +4,33 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"html"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type TaskControllerTestSuite struct {
 	suite.Suite
 	controller *TaskController
-	client     *mongo.Client
-	collection *mongo.Collection
-}
-
-func (suite *TaskControllerTestSuite) SetupSuite() {
-	// Connect to test MongoDB
-	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://localhost:27017"))
-	if err != nil {
-		suite.T().Fatalf("Failed to connect to MongoDB: %v", err)
-	}
-
-	suite.client = client
-	suite.collection = client.Database("todo-app-go-test").Collection("tasks")
-	suite.controller = NewTaskControllerWithDB(client, "todo-app-go-test")
-
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	err = client.Ping(ctx, nil)
-	if err != nil {
-		suite.T().Fatalf("Failed to ping MongoDB: %v", err)
-	}
-}
-
-func (suite *TaskControllerTestSuite) TearDownSuite() {
-	if suite.client != nil {
-		suite.client.Disconnect(context.Background())
-	}
+	repo       *repository.MemoryTaskRepository
 }
 
 func (suite *TaskControllerTestSuite) SetupTest() {
-	// Clean up collection before each test
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	suite.collection.Drop(ctx)
+	// Fresh in-memory storage for each test
+	suite.repo = repository.NewMemoryTaskRepository()
+	suite.controller = NewTaskControllerWithRepository(suite.repo)
 }
 
 func (suite *TaskControllerTestSuite) TestCreateTask() {
@@ -107,16 +82,18 @@ func (suite *TaskControllerTestSuite) TestGetTasks() {
 	gin.SetMode(gin.TestMode)
 	
 	// Insert test data
-	testTasks := []interface{}{
-		models.Task{Id: bson.NewObjectID(), Description: "Task 1"},
-		models.Task{Id: bson.NewObjectID(), Description: "Task 2"},
+	testTasks := []models.Task{
+		{Id: bson.NewObjectID(), Description: "Task 1"},
+		{Id: bson.NewObjectID(), Description: "Task 2"},
 	}
 	
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	
-	_, err := suite.collection.InsertMany(ctx, testTasks)
-	assert.NoError(suite.T(), err)
+	for _, task := range testTasks {
+		_, err := suite.repo.Insert(ctx, task)
+		assert.NoError(suite.T(), err)
+	}
 	
 	req, _ := http.NewRequest("GET", "/api/tasks", nil)
 	w := httptest.NewRecorder()
@@ -127,7 +104,7 @@ func (suite *TaskControllerTestSuite) TestGetTasks() {
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 	
 	var response []models.Task
-	err = json.Unmarshal(w.Body.Bytes(), &response)
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), response, 2)
 }
@@ -141,7 +118,7 @@ func (suite *TaskControllerTestSuite) TestDeleteTask() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	
-	_, err := suite.collection.InsertOne(ctx, testTask)
+	_, err := suite.repo.Insert(ctx, testTask)
 	assert.NoError(suite.T(), err)
 	
 	req, _ := http.NewRequest("DELETE", "/api/task/"+testTask.Id.Hex(), nil)
@@ -197,16 +174,18 @@ func (suite *TaskControllerTestSuite) TestDeleteAllTasks() {
 	gin.SetMode(gin.TestMode)
 	
 	// Insert test data
-	testTasks := []interface{}{
-		models.Task{Id: bson.NewObjectID(), Description: "Task 1"},
-		models.Task{Id: bson.NewObjectID(), Description: "Task 2"},
+	testTasks := []models.Task{
+		{Id: bson.NewObjectID(), Description: "Task 1"},
+		{Id: bson.NewObjectID(), Description: "Task 2"},
 	}
 	
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	
-	_, err := suite.collection.InsertMany(ctx, testTasks)
-	assert.NoError(suite.T(), err)
+	for _, task := range testTasks {
+		_, err := suite.repo.Insert(ctx, task)
+		assert.NoError(suite.T(), err)
+	}
 	
 	req, _ := http.NewRequest("DELETE", "/api/tasks", nil)
 	w := httptest.NewRecorder()
@@ -214,6 +193,17 @@ func (suite *TaskControllerTestSuite) TestDeleteAllTasks() {
 	router.DELETE("/api/tasks", suite.controller.DeleteAllTasks)
 	router.ServeHTTP(w, req)
 	
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+	
+	var confirmation map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &confirmation)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), float64(2), confirmation["count"])
+	
+	req, _ = http.NewRequest("DELETE", "/api/tasks?token="+confirmation["token"].(string), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 	
 	var response map[string]interface{}
@@ -223,6 +213,638 @@ func (suite *TaskControllerTestSuite) TestDeleteAllTasks() {
 	assert.Equal(suite.T(), float64(2), response["deletedCount"])
 }
 
+func (suite *TaskControllerTestSuite) TestReplaceTask() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	testTask, err := suite.repo.Insert(ctx, models.Task{Description: "Old description"})
+	assert.NoError(suite.T(), err)
+
+	jsonData, _ := json.Marshal(map[string]string{"description": "New description"})
+
+	req, _ := http.NewRequest("PUT", "/api/task/"+testTask.Id.Hex(), bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.PUT("/api/task/:id", suite.controller.ReplaceTask)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.Task
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), testTask.Id, response.Id)
+	assert.Equal(suite.T(), "New description", response.Description)
+
+	stored, err := suite.repo.Get(ctx, testTask.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "New description", stored.Description)
+}
+
+func (suite *TaskControllerTestSuite) TestReplaceTaskNotFound() {
+	gin.SetMode(gin.TestMode)
+
+	jsonData, _ := json.Marshal(map[string]string{"description": "New description"})
+
+	req, _ := http.NewRequest("PUT", "/api/task/"+bson.NewObjectID().Hex(), bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.PUT("/api/task/:id", suite.controller.ReplaceTask)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Task not found", response["message"])
+}
+
+func (suite *TaskControllerTestSuite) TestUpdateTask() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	testTask, err := suite.repo.Insert(ctx, models.Task{Description: "Old description"})
+	assert.NoError(suite.T(), err)
+
+	req, _ := http.NewRequest("PATCH", "/api/task/"+testTask.Id.Hex(), bytes.NewBufferString(`{"description": "Patched"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.PATCH("/api/task/:id", suite.controller.UpdateTask)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.Task
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), testTask.Id, response.Id)
+	assert.Equal(suite.T(), "Patched", response.Description)
+}
+
+func (suite *TaskControllerTestSuite) TestUpdateTaskInvalidID() {
+	gin.SetMode(gin.TestMode)
+
+	req, _ := http.NewRequest("PATCH", "/api/task/invalid-id", bytes.NewBufferString(`{"description": "Patched"}`))
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.PATCH("/api/task/:id", suite.controller.UpdateTask)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Invalid ID format", response["message"])
+}
+
+func (suite *TaskControllerTestSuite) TestUpdateTaskNotFound() {
+	gin.SetMode(gin.TestMode)
+
+	req, _ := http.NewRequest("PATCH", "/api/task/"+bson.NewObjectID().Hex(), bytes.NewBufferString(`{"description": "Patched"}`))
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.PATCH("/api/task/:id", suite.controller.UpdateTask)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskControllerTestSuite) TestUpdateTaskInvalidPatch() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	testTask, err := suite.repo.Insert(ctx, models.Task{Description: "Old description"})
+	assert.NoError(suite.T(), err)
+
+	req, _ := http.NewRequest("PATCH", "/api/task/"+testTask.Id.Hex(), bytes.NewBufferString(`["not", "an", "object"]`))
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.PATCH("/api/task/:id", suite.controller.UpdateTask)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TaskControllerTestSuite) TestToggleTask() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	testTask, err := suite.repo.Insert(ctx, models.Task{Description: "Task to toggle"})
+	assert.NoError(suite.T(), err)
+
+	router := gin.New()
+	router.POST("/api/task/:id/toggle", suite.controller.ToggleTask)
+
+	req, _ := http.NewRequest("POST", "/api/task/"+testTask.Id.Hex()+"/toggle", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.Task
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), response.Completed)
+	assert.NotNil(suite.T(), response.CompletedAt)
+
+	// Toggling again reopens the task
+	req, _ = http.NewRequest("POST", "/api/task/"+testTask.Id.Hex()+"/toggle", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	stored, err := suite.repo.Get(ctx, testTask.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), stored.Completed)
+	assert.Nil(suite.T(), stored.CompletedAt)
+}
+
+func (suite *TaskControllerTestSuite) TestToggleTaskNotFound() {
+	gin.SetMode(gin.TestMode)
+
+	req, _ := http.NewRequest("POST", "/api/task/"+bson.NewObjectID().Hex()+"/toggle", nil)
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.POST("/api/task/:id/toggle", suite.controller.ToggleTask)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasksByStatus() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.Insert(ctx, models.Task{Description: "Open task"})
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.Insert(ctx, models.Task{Description: "Done task", Completed: true})
+	assert.NoError(suite.T(), err)
+
+	router := gin.New()
+	router.GET("/api/tasks", suite.controller.GetTasks)
+
+	for status, description := range map[string]string{"open": "Open task", "done": "Done task"} {
+		req, _ := http.NewRequest("GET", "/api/tasks?status="+status, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+		var response []models.Task
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(suite.T(), err)
+		assert.Len(suite.T(), response, 1)
+		assert.Equal(suite.T(), description, response[0].Description)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/tasks?status=unknown", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TaskControllerTestSuite) TestShowAllTasksCountsOpenTasks() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.Insert(ctx, models.Task{Description: "Open task"})
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.Insert(ctx, models.Task{Description: "Done task", Completed: true})
+	assert.NoError(suite.T(), err)
+
+	req, _ := http.NewRequest("GET", "/view/tasks", nil)
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.LoadHTMLGlob("../templates/*.gohtml")
+	router.GET("/view/tasks", suite.controller.ShowAllTasks)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "You have 1 pending tasks.")
+	assert.Contains(suite.T(), w.Body.String(), `class="task completed"`)
+}
+
+func (suite *TaskControllerTestSuite) TestAgendaEndpoints() {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	suite.controller.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	due := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	for _, task := range []models.Task{
+		{Description: "Overdue", DueAt: due(-26 * time.Hour)},
+		{Description: "Overdue today", DueAt: due(-2 * time.Hour)},
+		{Description: "Later today", DueAt: due(3 * time.Hour)},
+		{Description: "In three days", DueAt: due(72 * time.Hour)},
+		{Description: "Next month", DueAt: due(30 * 24 * time.Hour)},
+		{Description: "Done", DueAt: due(-48 * time.Hour), Completed: true},
+		{Description: "No due date"},
+	} {
+		_, err := suite.repo.Insert(ctx, task)
+		assert.NoError(suite.T(), err)
+	}
+
+	router := gin.New()
+	router.GET("/api/tasks/today", suite.controller.TodayTasks)
+	router.GET("/api/tasks/overdue", suite.controller.OverdueTasks)
+	router.GET("/api/tasks/upcoming", suite.controller.UpcomingTasks)
+
+	expected := map[string][]string{
+		"/api/tasks/today":           {"Overdue today", "Later today"},
+		"/api/tasks/overdue":         {"Overdue", "Overdue today"},
+		"/api/tasks/upcoming":        {"Later today", "In three days"},
+		"/api/tasks/upcoming?days=1": {"Later today"},
+	}
+
+	for url, descriptions := range expected {
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code, url)
+
+		var response []models.Task
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(suite.T(), err)
+
+		actual := make([]string, 0, len(response))
+		for _, task := range response {
+			actual = append(actual, task.Description)
+		}
+		assert.Equal(suite.T(), descriptions, actual, url)
+	}
+
+	for _, url := range []string{"/api/tasks/upcoming?days=0", "/api/tasks/today?tz=Mars/Olympus"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, url)
+	}
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasksComputesDueFlags() {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	suite.controller.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	yesterday := now.Add(-24 * time.Hour)
+	_, err := suite.repo.Insert(ctx, models.Task{Description: "Overdue", DueAt: &yesterday})
+	assert.NoError(suite.T(), err)
+
+	req, _ := http.NewRequest("GET", "/api/tasks", nil)
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.GET("/api/tasks", suite.controller.GetTasks)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response []models.Task
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), response, 1)
+	assert.True(suite.T(), response[0].Overdue)
+	assert.False(suite.T(), response[0].DueToday)
+}
+
+func (suite *TaskControllerTestSuite) TestShowAllTasksGroupsByDueDate() {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	suite.controller.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	yesterday := now.Add(-24 * time.Hour)
+	tonight := now.Add(6 * time.Hour)
+	for _, task := range []models.Task{
+		{Description: "Someday"},
+		{Description: "Tonight", DueAt: &tonight},
+		{Description: "Yesterday", DueAt: &yesterday},
+	} {
+		_, err := suite.repo.Insert(ctx, task)
+		assert.NoError(suite.T(), err)
+	}
+
+	req, _ := http.NewRequest("GET", "/view/tasks", nil)
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.LoadHTMLGlob("../templates/*.gohtml")
+	router.GET("/view/tasks", suite.controller.ShowAllTasks)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	overdue := strings.Index(body, `id="group_overdue"`)
+	today := strings.Index(body, `id="group_today"`)
+	later := strings.Index(body, `id="group_later"`)
+	assert.True(suite.T(), overdue >= 0 && overdue < today && today < later)
+	assert.True(suite.T(), strings.Index(body, "Yesterday") < today)
+	assert.True(suite.T(), strings.Index(body, "Tonight") < later)
+	assert.True(suite.T(), strings.Index(body, "Someday") > later)
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasksPagination() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		_, err := suite.repo.Insert(ctx, models.Task{Description: "Task " + string(rune('A'+i))})
+		assert.NoError(suite.T(), err)
+	}
+
+	router := gin.New()
+	router.GET("/api/tasks", suite.controller.GetTasks)
+
+	var descriptions []string
+	url := "/api/tasks?limit=2&count=true"
+	for pages := 0; url != ""; pages++ {
+		assert.Less(suite.T(), pages, 3)
+
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.Equal(suite.T(), "5", w.Header().Get("X-Total-Count"))
+
+		var response []models.Task
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(suite.T(), err)
+		for _, task := range response {
+			descriptions = append(descriptions, task.Description)
+		}
+
+		url = ""
+		if link := w.Header().Get("Link"); link != "" {
+			assert.True(suite.T(), strings.HasSuffix(link, `>; rel="next"`))
+			url = link[1:strings.Index(link, ">")]
+		}
+	}
+
+	assert.Equal(suite.T(), []string{"Task A", "Task B", "Task C", "Task D", "Task E"}, descriptions)
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasksCursorSurvivesDeletes() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var ids []bson.ObjectID
+	for i := 0; i < 4; i++ {
+		task, err := suite.repo.Insert(ctx, models.Task{Description: "Task " + string(rune('A'+i))})
+		assert.NoError(suite.T(), err)
+		ids = append(ids, task.Id)
+	}
+
+	router := gin.New()
+	router.GET("/api/tasks", suite.controller.GetTasks)
+
+	req, _ := http.NewRequest("GET", "/api/tasks?limit=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	link := w.Header().Get("Link")
+	suite.Require().NotEmpty(link)
+
+	// Removing a task already seen does not shift the next page.
+	assert.NoError(suite.T(), suite.repo.Delete(ctx, ids[0]))
+
+	req, _ = http.NewRequest("GET", link[1:strings.Index(link, ">")], nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response []models.Task
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Require().Len(response, 2)
+	assert.Equal(suite.T(), "Task C", response[0].Description)
+	assert.Equal(suite.T(), "Task D", response[1].Description)
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasksSorted() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.Add(24 * time.Hour)
+	for _, task := range []models.Task{
+		{Description: "No due date", Priority: 2},
+		{Description: "Tomorrow", DueAt: &tomorrow},
+		{Description: "Now", DueAt: &now, Priority: 1},
+	} {
+		_, err := suite.repo.Insert(ctx, task)
+		assert.NoError(suite.T(), err)
+	}
+
+	router := gin.New()
+	router.GET("/api/tasks", suite.controller.GetTasks)
+
+	expected := map[string][]string{
+		"dueAt":        {"Now", "Tomorrow", "No due date"},
+		"-dueAt":       {"Tomorrow", "Now", "No due date"},
+		"priority":     {"Now", "No due date", "Tomorrow"},
+		"-description": {"Tomorrow", "Now", "No due date"},
+		"-created":     {"Now", "Tomorrow", "No due date"},
+	}
+
+	for sort, descriptions := range expected {
+		req, _ := http.NewRequest("GET", "/api/tasks?sort="+sort, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code, sort)
+
+		var response []models.Task
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(suite.T(), err)
+
+		actual := make([]string, 0, len(response))
+		for _, task := range response {
+			actual = append(actual, task.Description)
+		}
+		assert.Equal(suite.T(), descriptions, actual, sort)
+	}
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasksInvalidPage() {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/api/tasks", suite.controller.GetTasks)
+
+	position := repository.Position{Id: bson.NewObjectID()}
+	cursor := encodeCursor(pageCursor{After: &position, Sort: "created"})
+	both := encodeCursor(pageCursor{After: &position, Before: &position, Sort: "created"})
+	for _, url := range []string{
+		"/api/tasks?sort=color",
+		"/api/tasks?limit=0",
+		"/api/tasks?limit=100000",
+		"/api/tasks?cursor=not-a-cursor",
+		"/api/tasks?sort=dueAt&cursor=" + cursor,
+		"/api/tasks?cursor=" + both,
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, url)
+	}
+}
+
+func (suite *TaskControllerTestSuite) TestShowAllTasksPaginates() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < viewPageSize+1; i++ {
+		_, err := suite.repo.Insert(ctx, models.Task{Description: "Task"})
+		assert.NoError(suite.T(), err)
+	}
+
+	router := gin.New()
+	router.LoadHTMLGlob("../templates/*.gohtml")
+	router.GET("/view/tasks", suite.controller.ShowAllTasks)
+
+	req, _ := http.NewRequest("GET", "/view/tasks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), viewPageSize, strings.Count(w.Body.String(), `class="toggle"`))
+	assert.Contains(suite.T(), w.Body.String(), fmt.Sprintf("You have %d pending tasks.", viewPageSize+1))
+	assert.Contains(suite.T(), w.Body.String(), "Next")
+	assert.NotContains(suite.T(), w.Body.String(), "Previous")
+
+	follow := func(label string) *httptest.ResponseRecorder {
+		link := regexp.MustCompile(`<a href="([^"]+)">[^<]*(<i[^>]*></i> )?` + label).FindStringSubmatch(w.Body.String())
+		suite.Require().NotNil(link, label)
+
+		req, _ := http.NewRequest("GET", html.UnescapeString(link[1]), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w = follow("Next")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), 1, strings.Count(w.Body.String(), `class="toggle"`))
+	assert.Contains(suite.T(), w.Body.String(), "Previous")
+	assert.NotContains(suite.T(), w.Body.String(), "Next")
+
+	w = follow("Previous")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), viewPageSize, strings.Count(w.Body.String(), `class="toggle"`))
+	assert.Contains(suite.T(), w.Body.String(), "Next")
+	assert.NotContains(suite.T(), w.Body.String(), "Previous")
+}
+
+func (suite *TaskControllerTestSuite) TestSearchTasks() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, description := range []string{"Buy bread", "Buy milk and eggs for the weekend", "Milk"} {
+		_, err := suite.repo.Insert(ctx, models.Task{Description: description})
+		assert.NoError(suite.T(), err)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/tasks?q=milk", nil)
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.GET("/api/tasks", suite.controller.GetTasks)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response []models.Task
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), response, 2)
+
+	// The shorter description ranks first
+	assert.Equal(suite.T(), "Milk", response[0].Description)
+	assert.Greater(suite.T(), response[0].Score, response[1].Score)
+	assert.Equal(suite.T(), "<mark>Milk</mark>", response[0].Highlight)
+	assert.Contains(suite.T(), response[1].Highlight, "<mark>milk</mark>")
+}
+
+func (suite *TaskControllerTestSuite) TestSortByRelevanceRequiresQuery() {
+	gin.SetMode(gin.TestMode)
+
+	req, _ := http.NewRequest("GET", "/api/tasks?sort=relevance", nil)
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.GET("/api/tasks", suite.controller.GetTasks)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TaskControllerTestSuite) TestShowAllTasksSearch() {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, description := range []string{"Buy bread", "Buy <b>milk</b>"} {
+		_, err := suite.repo.Insert(ctx, models.Task{Description: description})
+		assert.NoError(suite.T(), err)
+	}
+
+	req, _ := http.NewRequest("GET", "/view/tasks?q=milk", nil)
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.LoadHTMLGlob("../templates/*.gohtml")
+	router.GET("/view/tasks", suite.controller.ShowAllTasks)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.NotContains(suite.T(), body, "Buy bread")
+	assert.Contains(suite.T(), body, "Buy &lt;b&gt;<mark>milk</mark>&lt;/b&gt;")
+	assert.Contains(suite.T(), body, `value="milk"`)
+}
+
 func TestTaskControllerSuite(t *testing.T) {
 	suite.Run(t, new(TaskControllerTestSuite))
 }
@@ -0,0 +1,389 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"example.com/todo-rest-api/codec"
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Import and export file formats, chosen with ?format=.
+const (
+	formatJSON    = "json"
+	formatCSV     = "csv"
+	formatTodoTxt = "todotxt"
+)
+
+// formatNames are the names of the formats in messages.
+var formatNames = map[string]string{
+	formatJSON:    "JSON",
+	formatCSV:     "CSV",
+	formatTodoTxt: "todo.txt",
+}
+
+// Policies for imported tasks whose id already exists, chosen with
+// ?duplicates=.
+const (
+	// duplicatesSkip keeps the existing task and ignores the row.
+	duplicatesSkip = "skip"
+	// duplicatesOverwrite replaces the existing task with the row.
+	duplicatesOverwrite = "overwrite"
+	// duplicatesAppend imports the row as a new task with a fresh id.
+	duplicatesAppend = "append"
+)
+
+// maxImportSize caps the size of an uploaded file.
+const maxImportSize = 10 << 20
+
+// maxImportOverhead is how much larger than the file the multipart body
+// of an upload may be, for its boundaries and headers.
+const maxImportOverhead = 64 << 10
+
+// importTimeout bounds an import, which writes every row one by one and
+// takes much longer than a request.
+const importTimeout = 5 * time.Minute
+
+// errDryRun rolls back the transaction of a dry-run import.
+var errDryRun = errors.New("dry run")
+
+// importReport summarizes an import. Rejected rows are listed with the
+// reason; every other row was created, updated or skipped.
+type importReport struct {
+	DryRun   bool          `json:"dryRun"`
+	Created  int           `json:"created"`
+	Updated  int           `json:"updated"`
+	Skipped  int           `json:"skipped"`
+	Rejected []rejectedRow `json:"rejected"`
+}
+
+type rejectedRow struct {
+	Row     int    `json:"row"`
+	Id      string `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+// importRow is a parsed row on its way into storage.
+type importRow struct {
+	number int
+	task   models.Task
+	// fileID is the id the row had in the file, which the parentId of
+	// other rows refers to.
+	fileID bson.ObjectID
+	// existing is the stored task the row overwrites, if any.
+	existing *models.Task
+}
+
+// ExportTasks downloads the tasks matching the list filters as JSON or,
+// with ?format=csv or ?format=todotxt, as CSV or todo.txt.
+func (tc TaskController) ExportTasks(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	format := c.DefaultQuery("format", formatJSON)
+	if format != formatJSON && format != formatCSV && format != formatTodoTxt {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid export format"})
+		return
+	}
+
+	filter, ok := parseTaskFilter(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := tc.repo.List(ctx, filter, repository.ListOptions{})
+	if err != nil {
+		log.Println("Error fetching tasks:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	switch format {
+	case formatCSV:
+		c.Header("Content-Disposition", `attachment; filename="tasks.csv"`)
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		if err := codec.WriteCSV(c.Writer, tasks); err != nil {
+			log.Println("Error writing CSV export:", err)
+		}
+		return
+	case formatTodoTxt:
+		c.Header("Content-Disposition", `attachment; filename="todo.txt"`)
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		if err := codec.WriteTodoTxt(c.Writer, tasks); err != nil {
+			log.Println("Error writing todo.txt export:", err)
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="tasks.json"`)
+
+	for i := range tasks {
+		tasks[i].ComputeDueFlags(tc.now())
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// ImportTasks reads the tasks of an uploaded JSON, CSV or todo.txt file,
+// in the form field "file". Rows are imported one by one and those that fail are
+// reported with the reason. With ?dryRun=true nothing is kept: the report
+// previews what the import would do.
+func (tc TaskController) ImportTasks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(repository.WithActor(context.Background(), currentUser(c)), importTimeout)
+	defer cancel()
+
+	policy := c.DefaultQuery("duplicates", duplicatesSkip)
+	if policy != duplicatesSkip && policy != duplicatesOverwrite && policy != duplicatesAppend {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid duplicates policy"})
+		return
+	}
+
+	dryRun := c.Query("dryRun") == "true"
+	if dryRun && tc.tx == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"message": "Dry runs are not supported by this storage"})
+		return
+	}
+
+	rows, ok := readImport(c)
+	if !ok {
+		return
+	}
+
+	var report importReport
+	run := func(ctx context.Context) error {
+		// A transaction may be retried, so every attempt starts over.
+		report = tc.importRows(ctx, c, rows, policy)
+		report.DryRun = dryRun
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	}
+
+	var err error
+	if dryRun {
+		err = tc.transaction(ctx, run)
+	} else {
+		err = run(ctx)
+	}
+	if err != nil && !errors.Is(err, errDryRun) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to import tasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// readImport decodes the uploaded file. The format comes from ?format=,
+// falling back to the file name's extension. On failure it writes a 400
+// or 413 response and returns false.
+func readImport(c *gin.Context) ([]codec.Row, bool) {
+	// Stop reading the body before parsing buffers all of it.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+maxImportOverhead)
+
+	header, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "File too large"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing file"})
+		return nil, false
+	}
+
+	if header.Size > maxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "File too large"})
+		return nil, false
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".csv":
+			format = formatCSV
+		case ".txt":
+			format = formatTodoTxt
+		default:
+			format = formatJSON
+		}
+	}
+
+	read := codec.ReadJSON
+	switch format {
+	case formatJSON:
+	case formatCSV:
+		read = codec.ReadCSV
+	case formatTodoTxt:
+		read = codec.ReadTodoTxt
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid import format"})
+		return nil, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing file"})
+		return nil, false
+	}
+	defer file.Close()
+
+	rows, err := read(io.LimitReader(file, maxImportSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid " + formatNames[format] + " file"})
+		return nil, false
+	}
+
+	return rows, true
+}
+
+// importRows stores the parsed rows. Subtasks are imported after their
+// parents, and rows that receive a new id keep their subtasks attached.
+// References to lists or parents that do not exist are dropped, so such
+// tasks land in the inbox or at the top level.
+func (tc TaskController) importRows(ctx context.Context, c *gin.Context, rows []codec.Row, policy string) importReport {
+	report := importReport{Rejected: []rejectedRow{}}
+	reject := func(row int, id bson.ObjectID, message string) {
+		rejected := rejectedRow{Row: row, Message: message}
+		if !id.IsZero() {
+			rejected.Id = id.Hex()
+		}
+		report.Rejected = append(report.Rejected, rejected)
+	}
+
+	// ids maps the ids of the file to those the tasks are stored under.
+	ids := make(map[bson.ObjectID]bson.ObjectID)
+	var pending []importRow
+
+	for _, row := range rows {
+		if row.Err != nil {
+			reject(row.Number, row.Task.Id, rowMessage(row.Err))
+			continue
+		}
+
+		item := importRow{number: row.Number, task: row.Task, fileID: row.Task.Id}
+		if item.fileID.IsZero() {
+			pending = append(pending, item)
+			continue
+		}
+
+		if _, seen := ids[item.fileID]; seen {
+			reject(row.Number, item.fileID, "Duplicate ID in file")
+			continue
+		}
+
+		existing, err := tc.repo.Get(ctx, item.fileID)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+		case err != nil:
+			reject(row.Number, item.fileID, "Unable to fetch task")
+			continue
+		case !ownedBy(c, existing.OwnerId) || policy == duplicatesAppend:
+			// Another user's ids are never touched nor revealed. Copies get
+			// their own calendar identity too.
+			item.task.Id = bson.NewObjectID()
+			item.task.Uid = ""
+		case policy == duplicatesSkip:
+			ids[item.fileID] = item.fileID
+			report.Skipped++
+			continue
+		default:
+			item.existing = &existing
+		}
+
+		ids[item.fileID] = item.task.Id
+		pending = append(pending, item)
+	}
+
+	for _, item := range parentsFirst(pending) {
+		task := item.task
+		if task.ParentId != nil {
+			if id, ok := ids[*task.ParentId]; ok {
+				task.ParentId = &id
+			}
+		}
+
+		if err := tc.dropMissingRefs(ctx, c, &task); err != nil {
+			reject(item.number, item.fileID, "Unable to fetch task")
+			continue
+		}
+
+		if item.existing == nil {
+			if _, status, message := tc.insertTask(ctx, c, task); status != http.StatusCreated {
+				reject(item.number, item.fileID, message)
+				continue
+			}
+			report.Created++
+			continue
+		}
+
+		task.OwnerId = item.existing.OwnerId
+		task.Uid, task.ResourceName = item.existing.Uid, item.existing.ResourceName
+		task.DeletedAt = nil
+		task.SyncCompletion(tc.now())
+		if _, status, message := tc.updateTask(ctx, c, task); status != http.StatusOK {
+			reject(item.number, item.fileID, message)
+			continue
+		}
+		report.Updated++
+	}
+
+	return report
+}
+
+// parentsFirst orders rows so that every row comes after the row of its
+// parent, otherwise keeping the file's order. Cycles are cut where they
+// are first entered.
+func parentsFirst(rows []importRow) []importRow {
+	index := make(map[bson.ObjectID]int, len(rows))
+	for i, row := range rows {
+		if !row.fileID.IsZero() {
+			index[row.fileID] = i
+		}
+	}
+
+	ordered := make([]importRow, 0, len(rows))
+	placed := make([]bool, len(rows))
+	visiting := make([]bool, len(rows))
+
+	var place func(i int)
+	place = func(i int) {
+		if placed[i] || visiting[i] {
+			return
+		}
+		visiting[i] = true
+
+		if parentID := rows[i].task.ParentId; parentID != nil {
+			if parent, ok := index[*parentID]; ok {
+				place(parent)
+			}
+		}
+
+		placed[i] = true
+		ordered = append(ordered, rows[i])
+	}
+
+	for i := range rows {
+		place(i)
+	}
+
+	return ordered
+}
+
+// rowMessage describes why a row could not be parsed.
+func rowMessage(err error) string {
+	var fieldErr *codec.FieldError
+	if errors.As(err, &fieldErr) {
+		return "Invalid " + fieldErr.Field
+	}
+
+	return "Invalid row format"
+}
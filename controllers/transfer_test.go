@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type TransferTestSuite struct {
+	controllerSuite
+	parent models.Task
+	child  models.Task
+}
+
+func (suite *TransferTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.router.GET("/api/tasks/export", suite.controller.ExportTasks)
+	suite.router.POST("/api/tasks/import", suite.controller.ImportTasks)
+
+	due := suite.now.Add(time.Hour)
+	var err error
+	suite.parent, err = suite.repo.Insert(context.Background(), models.Task{Description: "Parent", DueAt: &due, Tags: []string{"home"}})
+	suite.Require().NoError(err)
+	suite.child, err = suite.repo.Insert(context.Background(), models.Task{Description: "Child", ParentId: &suite.parent.Id})
+	suite.Require().NoError(err)
+}
+
+func (suite *TransferTestSuite) export(format string) string {
+	w := suite.request("GET", "/api/tasks/export?format="+format, nil)
+
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Header().Get("Content-Disposition"), "tasks."+format)
+	return w.Body.String()
+}
+
+func (suite *TransferTestSuite) upload(query, filename, content string) (*httptest.ResponseRecorder, importReport) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", filename)
+	_, _ = part.Write([]byte(content))
+	_ = writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/tasks/import"+query, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := suite.serve(req)
+
+	var report importReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	return w, report
+}
+
+func (suite *TransferTestSuite) TestExportFormats() {
+	var exported []models.Task
+	suite.Require().NoError(json.Unmarshal([]byte(suite.export("json")), &exported))
+	suite.Require().Len(exported, 2)
+	assert.Equal(suite.T(), []string{"home"}, exported[0].Tags)
+
+	csv := suite.export("csv")
+	assert.True(suite.T(), strings.HasPrefix(csv, "id,description,parentId,"))
+	assert.Contains(suite.T(), csv, suite.child.Id.Hex()+",Child,"+suite.parent.Id.Hex())
+
+	w := suite.request("GET", "/api/tasks/export?format=xml", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TransferTestSuite) TestRoundTripIntoEmptyStore() {
+	for _, format := range []string{"json", "csv"} {
+		suite.SetupTest()
+		exported := suite.export(format)
+		parent := suite.parent
+
+		suite.SetupTest()
+		_, err := suite.repo.DeleteAll(context.Background(), repository.TaskFilter{})
+		suite.Require().NoError(err)
+
+		w, report := suite.upload("", "tasks."+format, exported)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(suite.T(), 2, report.Created, format)
+		assert.Empty(suite.T(), report.Rejected, format)
+
+		tasks := suite.stored()
+		suite.Require().Len(tasks, 2)
+		assert.Equal(suite.T(), parent.Id, tasks[0].Id)
+		assert.Equal(suite.T(), parent.DueAt.UTC(), tasks[0].DueAt.UTC())
+		assert.Equal(suite.T(), []string{"home"}, tasks[0].Tags)
+		assert.Equal(suite.T(), &parent.Id, tasks[1].ParentId)
+	}
+}
+
+func (suite *TransferTestSuite) TestTodoTxt() {
+	w := suite.request("GET", "/api/tasks/export?format=todotxt", nil)
+
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Header().Get("Content-Disposition"), "todo.txt")
+	exported := w.Body.String()
+	assert.Contains(suite.T(), exported, "Parent @home due:2025-03-10T09:00:00Z id:"+suite.parent.Id.Hex())
+	assert.Contains(suite.T(), exported, "Child id:"+suite.child.Id.Hex()+" parent:"+suite.parent.Id.Hex())
+
+	_, err := suite.repo.DeleteAll(context.Background(), repository.TaskFilter{})
+	suite.Require().NoError(err)
+
+	w, report := suite.upload("", "todo.txt", exported+"(A) Call Mom +Family @phone rec:1w\n")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), 3, report.Created)
+
+	tasks := suite.stored()
+	suite.Require().Len(tasks, 3)
+	assert.Equal(suite.T(), suite.parent.Id, tasks[0].Id)
+	assert.Equal(suite.T(), &suite.parent.Id, tasks[1].ParentId)
+	assert.Equal(suite.T(), "Call Mom", tasks[2].Description)
+	assert.Equal(suite.T(), 1, tasks[2].Priority)
+	assert.Equal(suite.T(), []string{"Family"}, tasks[2].Projects)
+	assert.Equal(suite.T(), []models.Extension{{Key: "rec", Value: "1w"}}, tasks[2].Extensions)
+}
+
+func (suite *TransferTestSuite) TestDuplicatePolicies() {
+	exported := suite.export("json")
+
+	w, report := suite.upload("", "tasks.json", exported)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), 2, report.Skipped)
+	assert.Len(suite.T(), suite.stored(), 2)
+
+	edited := strings.Replace(exported, `"Parent"`, `"Renamed"`, 1)
+	_, report = suite.upload("?duplicates=overwrite", "tasks.json", edited)
+	assert.Equal(suite.T(), 2, report.Updated)
+	assert.Equal(suite.T(), "Renamed", suite.stored()[0].Description)
+
+	_, report = suite.upload("?duplicates=append", "tasks.json", exported)
+	assert.Equal(suite.T(), 2, report.Created)
+
+	tasks := suite.stored()
+	suite.Require().Len(tasks, 4)
+	// The appended child hangs under the appended parent, not the original.
+	assert.Equal(suite.T(), &tasks[2].Id, tasks[3].ParentId)
+
+	w, _ = suite.upload("?duplicates=replace", "tasks.json", exported)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TransferTestSuite) TestDryRun() {
+	w, report := suite.upload("?dryRun=true", "new.json", `[{"description": "Preview"}, {"description": "Again"}]`)
+
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.True(suite.T(), report.DryRun)
+	assert.Equal(suite.T(), 2, report.Created)
+	assert.Len(suite.T(), suite.stored(), 2)
+}
+
+func (suite *TransferTestSuite) TestRejectedRows() {
+	missing := bson.NewObjectID().Hex()
+	csv := "description,dueAt,parentId,recurrenceRule\n" +
+		"Fine,,,\n" +
+		"Bad date,tomorrow,,\n" +
+		"No due date,,,FREQ=DAILY\n" +
+		"Orphan,," + missing + ",\n"
+
+	w, report := suite.upload("", "tasks.csv", csv)
+
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), 2, report.Created)
+	assert.Equal(suite.T(), []rejectedRow{
+		{Row: 3, Message: "Invalid dueAt"},
+		{Row: 4, Message: "Recurring tasks need a due date"},
+	}, report.Rejected)
+
+	// The orphan lands at the top level.
+	tasks := suite.stored()
+	assert.Nil(suite.T(), tasks[len(tasks)-1].ParentId)
+
+	w, _ = suite.upload("", "tasks.json", "not json")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TransferTestSuite) TestFileTooLarge() {
+	before := len(suite.stored())
+
+	w, _ := suite.upload("", "tasks.txt", strings.Repeat("x", maxImportSize+maxImportOverhead))
+
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	assert.Len(suite.T(), suite.stored(), before)
+}
+
+func TestTransferSuite(t *testing.T) {
+	suite.Run(t, new(TransferTestSuite))
+}
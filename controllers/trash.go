@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// purgeInterval is how often PurgeTrash looks for expired tasks.
+const purgeInterval = time.Hour
+
+// GetTrash lists the tasks in the trash.
+func (tc TaskController) GetTrash(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	tasks, err := tc.repo.List(ctx, repository.TaskFilter{
+		Owner:   currentUser(c),
+		Trashed: true,
+	}, repository.ListOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// RestoreFromTrash takes a task out of the trash, along with the subtasks
+// that were deleted together with it. A list or parent that is gone by now
+// is dropped, moving the task to the inbox or the top level.
+func (tc TaskController) RestoreFromTrash(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	task, err := tc.repo.Get(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (!ownedBy(c, task.OwnerId) || task.DeletedAt == nil)) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Task not found in trash"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch task"})
+		return
+	}
+
+	subtasks, err := trashedSubtasks(ctx, tc.repo, currentUser(c), task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	task.DeletedAt = nil
+	if err := tc.dropMissingRefs(ctx, c, &task); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch task"})
+		return
+	}
+
+	ctx = repository.WithAction(ctx, models.ActionRestored)
+
+	if err := tc.repo.Update(ctx, task); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to restore task"})
+		return
+	}
+
+	if len(subtasks) > 0 {
+		_, err := tc.repo.SetDeleted(ctx, repository.TaskFilter{
+			Owner:   currentUser(c),
+			Trashed: true,
+			Ids:     subtasks,
+		}, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to restore task"})
+			return
+		}
+	}
+
+	tasks, err := tc.withSubtasks(ctx, c, []models.Task{task}, false, tc.now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	// Clients dropped the tasks when they were deleted, so they come back
+	// as new ones.
+	tc.publish(ctx, task.OwnerId, webhook.EventTaskCreated, tasks[0])
+	tc.publishTasks(ctx, c, webhook.EventTaskCreated, subtasks)
+
+	c.JSON(http.StatusOK, tasks[0])
+}
+
+// EmptyTrash deletes every task in the trash for good.
+func (tc TaskController) EmptyTrash(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	deletedCount, err := tc.repo.DeleteAll(ctx, repository.TaskFilter{
+		Owner:   currentUser(c),
+		Trashed: true,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to empty trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Trash emptied successfully",
+		"deletedCount": deletedCount,
+	})
+}
+
+// PurgeTrash deletes, for every user, the tasks that have been in the
+// trash for longer than Settings.TrashRetention. It checks once an hour
+// until ctx is done and is meant to run in its own goroutine.
+func (tc TaskController) PurgeTrash(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := tc.purgeTrash(ctx, settings().TrashRetention); err != nil {
+			log.Println("Error purging trash:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (tc TaskController) purgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, settings().RequestTimeout)
+	defer cancel()
+
+	cutoff := tc.now().Add(-retention)
+
+	return tc.repo.DeleteAll(ctx, repository.TaskFilter{
+		Trashed:       true,
+		DeletedBefore: &cutoff,
+	})
+}
+
+// trashedSubtasks returns the ids of the descendants of task that went to
+// the trash at the same moment it did.
+func trashedSubtasks(ctx context.Context, repo repository.TaskRepository, owner *bson.ObjectID, task models.Task) ([]bson.ObjectID, error) {
+	var ids []bson.ObjectID
+	seen := map[bson.ObjectID]bool{task.Id: true}
+
+	for frontier := []bson.ObjectID{task.Id}; len(frontier) > 0; {
+		children, err := repo.List(ctx, repository.TaskFilter{
+			Owner:   owner,
+			Trashed: true,
+			Parents: frontier,
+		}, repository.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for _, child := range children {
+			if seen[child.Id] || !child.DeletedAt.Equal(*task.DeletedAt) {
+				continue
+			}
+			seen[child.Id] = true
+			ids = append(ids, child.Id)
+			frontier = append(frontier, child.Id)
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TrashTestSuite struct {
+	controllerSuite
+}
+
+func (suite *TrashTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.router.POST("/api/task", suite.controller.CreateTask)
+	suite.router.GET("/api/tasks", suite.controller.GetTasks)
+	suite.router.PATCH("/api/task/:id", suite.controller.UpdateTask)
+	suite.router.DELETE("/api/task/:id", suite.controller.DeleteTask)
+	suite.router.DELETE("/api/tasks", suite.controller.DeleteAllTasks)
+	suite.router.GET("/api/trash", suite.controller.GetTrash)
+	suite.router.POST("/api/trash/:id/restore", suite.controller.RestoreFromTrash)
+	suite.router.DELETE("/api/trash", suite.controller.EmptyTrash)
+}
+
+func (suite *TrashTestSuite) TestDeleteMovesToTrash() {
+	task := suite.create(gin.H{"description": "Misclicked"})
+	suite.create(gin.H{"description": "Untouched"})
+
+	w := suite.request("DELETE", "/api/task/"+task.Id.Hex(), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	assert.Len(suite.T(), suite.tasks("/api/tasks"), 1)
+
+	trash := suite.tasks("/api/trash")
+	suite.Require().Len(trash, 1)
+	assert.Equal(suite.T(), task.Id, trash[0].Id)
+	suite.Require().NotNil(trash[0].DeletedAt)
+	assert.True(suite.T(), suite.now.Equal(*trash[0].DeletedAt))
+
+	// Trashed tasks can no longer be edited or deleted again.
+	w = suite.request("PATCH", "/api/task/"+task.Id.Hex(), gin.H{"description": "Edited"})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = suite.request("DELETE", "/api/task/"+task.Id.Hex(), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TrashTestSuite) TestRestore() {
+	parent := suite.create(gin.H{"description": "Parent"})
+	child := suite.create(gin.H{"description": "Child", "parentId": parent.Id.Hex()})
+
+	suite.request("DELETE", "/api/task/"+parent.Id.Hex()+"?children=cascade", nil)
+	assert.Empty(suite.T(), suite.tasks("/api/tasks"))
+
+	w := suite.request("POST", "/api/trash/"+parent.Id.Hex()+"/restore", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(suite.T(), w.Body.String(), "deletedAt")
+	assert.Contains(suite.T(), w.Body.String(), `"progress":{"done":0,"total":1}`)
+
+	assert.Len(suite.T(), suite.tasks("/api/tasks"), 2)
+	assert.Empty(suite.T(), suite.tasks("/api/trash"))
+
+	w = suite.request("POST", "/api/trash/"+child.Id.Hex()+"/restore", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TrashTestSuite) TestRestoreWithoutTrashedParent() {
+	parent := suite.create(gin.H{"description": "Parent"})
+	child := suite.create(gin.H{"description": "Child", "parentId": parent.Id.Hex()})
+
+	deleteAll(suite.T(), suite.router, "/api/tasks?children=cascade")
+
+	w := suite.request("POST", "/api/trash/"+child.Id.Hex()+"/restore", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(suite.T(), w.Body.String(), "parentId")
+}
+
+func (suite *TrashTestSuite) TestEmptyTrash() {
+	task := suite.create(gin.H{"description": "Gone"})
+	suite.create(gin.H{"description": "Kept"})
+	suite.request("DELETE", "/api/task/"+task.Id.Hex(), nil)
+
+	w := suite.request("DELETE", "/api/trash", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"deletedCount":1`)
+
+	assert.Empty(suite.T(), suite.tasks("/api/trash"))
+	assert.Len(suite.T(), suite.tasks("/api/tasks"), 1)
+
+	w = suite.request("POST", "/api/trash/"+task.Id.Hex()+"/restore", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TrashTestSuite) TestPurgeAfterRetention() {
+	first := suite.create(gin.H{"description": "First"})
+	second := suite.create(gin.H{"description": "Second"})
+
+	suite.request("DELETE", "/api/task/"+first.Id.Hex(), nil)
+	suite.now = suite.now.Add(24 * time.Hour)
+	suite.request("DELETE", "/api/task/"+second.Id.Hex(), nil)
+
+	suite.now = suite.now.Add(24 * time.Hour)
+	purged, err := suite.controller.purgeTrash(context.Background(), 36*time.Hour)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(1), purged)
+
+	trash := suite.tasks("/api/trash")
+	suite.Require().Len(trash, 1)
+	assert.Equal(suite.T(), second.Id, trash[0].Id)
+}
+
+func TestTrashTestSuite(t *testing.T) {
+	suite.Run(t, new(TrashTestSuite))
+}
@@ -0,0 +1,555 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// apiV2 is where version 2 of the API is served; the links of its tasks
+// point there.
+const apiV2 = "/api/v2"
+
+// Deprecate is a middleware for the routes of a deprecated API version.
+// Their responses say since when the version is deprecated (RFC 9745) and
+// when it goes away (RFC 8594).
+func Deprecate(since, sunset time.Time) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunsetDate)
+		c.Next()
+	}
+}
+
+// Version 2 of the API runs the handlers of version 1, translating
+// between the two: request bodies are turned into what version 1 expects
+// before the handler runs, and the tasks it answers with into TaskV2s.
+// Errors are the same in both versions.
+
+// requestTranslator rewrites a version 2 request body for version 1. On
+// failure it returns the message to answer with.
+type requestTranslator func(body []byte) ([]byte, string)
+
+// responseTranslator rewrites a successful version 1 response body for
+// version 2.
+type responseTranslator func(body []byte) (any, error)
+
+// translateV2 adapts a version 1 handler to version 2; either translator
+// may be nil to leave that side alone.
+func translateV2(handler gin.HandlerFunc, request requestTranslator, response responseTranslator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if request != nil {
+			body, err := c.GetRawData()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+				return
+			}
+
+			translated, message := request(body)
+			if message != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"message": message})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(translated))
+			c.Request.ContentLength = int64(len(translated))
+		}
+
+		if response == nil {
+			handler(c)
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		handler(c)
+		c.Writer = writer.ResponseWriter
+
+		if writer.status < 200 || writer.status >= 300 {
+			c.Writer.WriteHeader(writer.status)
+			c.Writer.Write(writer.body.Bytes())
+			return
+		}
+
+		translated, err := response(writer.body.Bytes())
+		if err != nil {
+			log.Println("Error translating response to v2:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to translate response"})
+			return
+		}
+
+		c.JSON(writer.status, translated)
+	}
+}
+
+// TaskV2 adapts a version 1 handler answering with a task.
+func TaskV2(handler gin.HandlerFunc) gin.HandlerFunc {
+	return translateV2(handler, nil, taskResponseV2)
+}
+
+// TasksV2 adapts a version 1 handler answering with a list of tasks.
+func TasksV2(handler gin.HandlerFunc) gin.HandlerFunc {
+	return translateV2(handler, nil, tasksResponseV2)
+}
+
+// CreateV2 adapts a version 1 handler that reads a task and answers with
+// it, such as CreateTask and ReplaceTask.
+func CreateV2(handler gin.HandlerFunc) gin.HandlerFunc {
+	return translateV2(handler, taskRequestV2, taskResponseV2)
+}
+
+// PatchV2 adapts UpdateTask.
+func PatchV2(handler gin.HandlerFunc) gin.HandlerFunc {
+	return translateV2(handler, patchRequestV2, taskResponseV2)
+}
+
+// BatchV2 adapts BatchTasks.
+func BatchV2(handler gin.HandlerFunc) gin.HandlerFunc {
+	return translateV2(handler, batchRequestV2, batchResponseV2)
+}
+
+// HistoryV2 adapts GetHistory.
+func HistoryV2(handler gin.HandlerFunc) gin.HandlerFunc {
+	return translateV2(handler, nil, historyResponseV2)
+}
+
+// bufferedWriter holds back the response of a handler so that translateV2
+// can rewrite it. Headers go straight to the underlying writer.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// GetTask answers with one task of the logged-in user, with its due flags
+// and progress. With ?tree=true it carries its subtasks. Only version 2
+// serves it.
+func (tc TaskController) GetTask(c *gin.Context) {
+	ctx, cancel := tc.getContext(c)
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	now, ok := tc.clock(c)
+	if !ok {
+		return
+	}
+
+	task, ok := tc.findTask(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	tasks, err := tc.withSubtasks(ctx, c, []models.Task{task}, c.Query("tree") == "true", now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch tasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks[0])
+}
+
+// StreamEventsV2 is StreamEvents with the tasks of task.created and
+// task.updated shown as TaskV2s.
+func (tc TaskController) StreamEventsV2(c *gin.Context) {
+	tc.streamEvents(c, func(e models.Event) []byte {
+		if e.Name != webhook.EventTaskCreated && e.Name != webhook.EventTaskUpdated {
+			return e.Data
+		}
+
+		task, _, err := decodeEvent(e)
+		if err != nil {
+			return e.Data
+		}
+		data, err := json.Marshal(newTaskV2(*task))
+		if err != nil {
+			return e.Data
+		}
+		return data
+	})
+}
+
+// newTaskV2 shows a task the way version 2 of the API does.
+func newTaskV2(task models.Task) models.TaskV2 {
+	v2 := models.TaskV2{
+		Id:          task.Id,
+		Title:       task.Description,
+		Status:      models.StatusOpen,
+		CompletedAt: task.CompletedAt,
+		CreatedAt:   task.Id.Timestamp().UTC(),
+		Tags:        nonNil(task.Tags),
+		Projects:    nonNil(task.Projects),
+		Extensions:  nonNil(task.Extensions),
+		Recurrence:  task.Recurrence,
+		ListId:      task.ListId,
+		ParentId:    task.ParentId,
+		Uid:         task.Uid,
+		DeletedAt:   task.DeletedAt,
+		Progress:    task.Progress,
+		Links:       taskLinksV2(task),
+	}
+
+	if task.Completed {
+		v2.Status = models.StatusDone
+	}
+	if task.DueAt != nil {
+		v2.Due = &models.Due{At: *task.DueAt, Overdue: task.Overdue, Today: task.DueToday}
+	}
+	if task.Priority != 0 {
+		priority := task.Priority
+		v2.Priority = &priority
+	}
+	if task.Score != 0 || task.Highlight != "" {
+		v2.Match = &models.Match{Score: task.Score, Highlight: task.Highlight}
+	}
+
+	for _, child := range task.Children {
+		v2.Subtasks = append(v2.Subtasks, newTaskV2(child))
+	}
+	if task.Next != nil {
+		next := newTaskV2(*task.Next)
+		v2.Next = &next
+	}
+
+	return v2
+}
+
+func taskLinksV2(task models.Task) models.TaskLinks {
+	self := apiV2 + "/tasks/" + task.Id.Hex()
+	links := models.TaskLinks{
+		Self:     self,
+		Subtasks: self + "/subtasks",
+		History:  self + "/history",
+	}
+
+	if task.ParentId != nil {
+		links.Parent = apiV2 + "/tasks/" + task.ParentId.Hex()
+	}
+	if task.ListId != nil {
+		links.List = apiV2 + "/lists/" + task.ListId.Hex()
+	}
+
+	return links
+}
+
+// nonNil turns a nil slice into an empty one, which JSON shows as [].
+func nonNil[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
+
+// taskFromV2 reads a task sent to version 2. Computed fields are ignored.
+// On failure it returns the message to answer with.
+func taskFromV2(v2 models.TaskV2) (models.Task, string) {
+	if strings.TrimSpace(v2.Title) == "" {
+		return models.Task{}, "Title is required"
+	}
+
+	task := models.Task{
+		Description: v2.Title,
+		Tags:        v2.Tags,
+		Projects:    v2.Projects,
+		Extensions:  v2.Extensions,
+		Recurrence:  v2.Recurrence,
+		ListId:      v2.ListId,
+		ParentId:    v2.ParentId,
+	}
+
+	switch v2.Status {
+	case "", models.StatusOpen:
+	case models.StatusDone:
+		task.Completed = true
+	default:
+		return task, "Invalid status"
+	}
+
+	if v2.Due != nil {
+		due := v2.Due.At
+		task.DueAt = &due
+	}
+	if v2.Priority != nil {
+		task.Priority = *v2.Priority
+	}
+
+	return task, ""
+}
+
+// patchFromV2 turns a version 2 merge patch into one of version 1. Fields
+// version 2 does not know are dropped, like version 1 ignores them. On
+// failure it returns the message to answer with.
+func patchFromV2(patch map[string]interface{}) (map[string]interface{}, string) {
+	v1 := map[string]interface{}{}
+
+	for field, value := range patch {
+		switch field {
+		case "title":
+			v1["description"] = value
+		case "status":
+			switch value {
+			case models.StatusOpen:
+				v1["completed"] = false
+			case models.StatusDone:
+				v1["completed"] = true
+			default:
+				return nil, "Invalid status"
+			}
+		case "due":
+			switch due := value.(type) {
+			case nil:
+				v1["dueAt"] = nil
+			case map[string]interface{}:
+				at, ok := due["at"]
+				if !ok {
+					return nil, "Invalid due date"
+				}
+				v1["dueAt"] = at
+			default:
+				return nil, "Invalid due date"
+			}
+		case "priority", "tags", "projects", "extensions", "recurrence", "listId", "parentId":
+			v1[field] = value
+		}
+	}
+
+	return v1, ""
+}
+
+// taskRequestV2 translates the body of POST and PUT.
+func taskRequestV2(body []byte) ([]byte, string) {
+	var v2 models.TaskV2
+	if err := json.Unmarshal(body, &v2); err != nil {
+		return nil, "Invalid JSON format"
+	}
+
+	task, message := taskFromV2(v2)
+	if message != "" {
+		return nil, message
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, "Invalid JSON format"
+	}
+	return data, ""
+}
+
+// patchRequestV2 translates the body of PATCH.
+func patchRequestV2(body []byte) ([]byte, string) {
+	var patch map[string]interface{}
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return nil, "Invalid JSON format"
+	}
+
+	v1, message := patchFromV2(patch)
+	if message != "" {
+		return nil, message
+	}
+
+	data, err := json.Marshal(v1)
+	if err != nil {
+		return nil, "Invalid JSON format"
+	}
+	return data, ""
+}
+
+// batchOperationV2 is a batchOperation holding a TaskV2 and a version 2
+// patch.
+type batchOperationV2 struct {
+	Op       string                 `json:"op"`
+	Id       string                 `json:"id,omitempty"`
+	Task     *models.TaskV2         `json:"task,omitempty"`
+	Patch    map[string]interface{} `json:"patch,omitempty"`
+	Add      []string               `json:"add,omitempty"`
+	Remove   []string               `json:"remove,omitempty"`
+	Children string                 `json:"children,omitempty"`
+}
+
+// batchRequestV2 translates the body of POST /tasks/batch. A task or patch
+// that does not translate fails the whole request, with the number of its
+// operation.
+func batchRequestV2(body []byte) ([]byte, string) {
+	var v2 struct {
+		Atomic     bool               `json:"atomic"`
+		Operations []batchOperationV2 `json:"operations"`
+	}
+	if err := json.Unmarshal(body, &v2); err != nil {
+		return nil, "Invalid JSON format"
+	}
+
+	request := batchRequest{Atomic: v2.Atomic, Operations: make([]batchOperation, len(v2.Operations))}
+	for i, op := range v2.Operations {
+		request.Operations[i] = batchOperation{
+			Op:       op.Op,
+			Id:       op.Id,
+			Add:      op.Add,
+			Remove:   op.Remove,
+			Children: op.Children,
+		}
+
+		var message string
+		if op.Task != nil {
+			var task models.Task
+			task, message = taskFromV2(*op.Task)
+			request.Operations[i].Task = &task
+		}
+		if op.Patch != nil && message == "" {
+			request.Operations[i].Patch, message = patchFromV2(op.Patch)
+		}
+		if message != "" {
+			return nil, "Operation " + strconv.Itoa(i+1) + ": " + message
+		}
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, "Invalid JSON format"
+	}
+	return data, ""
+}
+
+// taskResponseV2 translates a response holding one task.
+func taskResponseV2(body []byte) (any, error) {
+	var task models.Task
+	if err := json.Unmarshal(body, &task); err != nil {
+		return nil, err
+	}
+
+	return newTaskV2(task), nil
+}
+
+// tasksResponseV2 translates a response holding a list of tasks.
+func tasksResponseV2(body []byte) (any, error) {
+	var tasks []models.Task
+	if err := json.Unmarshal(body, &tasks); err != nil {
+		return nil, err
+	}
+
+	v2 := make([]models.TaskV2, len(tasks))
+	for i, task := range tasks {
+		v2[i] = newTaskV2(task)
+	}
+	return v2, nil
+}
+
+// batchResponseV2 translates the results of a batch.
+func batchResponseV2(body []byte) (any, error) {
+	var response struct {
+		Results []batchResult `json:"results"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	type resultV2 struct {
+		Status  int            `json:"status"`
+		Message string         `json:"message,omitempty"`
+		Task    *models.TaskV2 `json:"task,omitempty"`
+	}
+
+	results := make([]resultV2, len(response.Results))
+	for i, result := range response.Results {
+		results[i] = resultV2{Status: result.Status, Message: result.Message}
+		if result.Task != nil {
+			task := newTaskV2(*result.Task)
+			results[i].Task = &task
+		}
+	}
+	return gin.H{"results": results}, nil
+}
+
+// historyResponseV2 translates a task history, naming the changed fields
+// the way TaskV2 does.
+func historyResponseV2(body []byte) (any, error) {
+	var entries []models.HistoryEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		for j, change := range entries[i].Changes {
+			entries[i].Changes[j] = fieldChangeV2(change)
+		}
+	}
+	return entries, nil
+}
+
+// fieldChangeV2 renames a field of Task to the one of TaskV2 holding it,
+// converting the values along.
+func fieldChangeV2(change models.FieldChange) models.FieldChange {
+	convert := func(value json.RawMessage, fn func(json.RawMessage) any) json.RawMessage {
+		if value == nil {
+			return nil
+		}
+		converted, err := json.Marshal(fn(value))
+		if err != nil {
+			return value
+		}
+		return converted
+	}
+
+	switch change.Field {
+	case "description":
+		change.Field = "title"
+	case "completed":
+		change.Field = "status"
+		status := func(value json.RawMessage) any {
+			if string(value) == "true" {
+				return models.StatusDone
+			}
+			return models.StatusOpen
+		}
+		change.Before = convert(change.Before, status)
+		change.After = convert(change.After, status)
+	case "dueAt":
+		change.Field = "due"
+		due := func(value json.RawMessage) any {
+			return map[string]json.RawMessage{"at": value}
+		}
+		change.Before = convert(change.Before, due)
+		change.After = convert(change.After, due)
+	}
+
+	return change
+}
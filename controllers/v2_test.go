@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type V2TestSuite struct {
+	controllerSuite
+}
+
+func (suite *V2TestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.router.GET("/api/v1/tasks", suite.controller.GetTasks)
+	suite.router.GET("/api/v2/tasks", TasksV2(suite.controller.GetTasks))
+	suite.router.POST("/api/v2/tasks", CreateV2(suite.controller.CreateTask))
+	suite.router.GET("/api/v2/tasks/:id", TaskV2(suite.controller.GetTask))
+	suite.router.PUT("/api/v2/tasks/:id", CreateV2(suite.controller.ReplaceTask))
+	suite.router.PATCH("/api/v2/tasks/:id", PatchV2(suite.controller.UpdateTask))
+	suite.router.GET("/api/v2/tasks/:id/history", HistoryV2(suite.controller.GetHistory))
+	suite.router.POST("/api/v2/tasks/batch", BatchV2(suite.controller.BatchTasks))
+}
+
+// createV2 posts body to /api/v2/tasks and returns the task created.
+func (suite *V2TestSuite) createV2(body gin.H) models.TaskV2 {
+	w := suite.request("POST", "/api/v2/tasks", body)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var task models.TaskV2
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &task))
+	return task
+}
+
+func (suite *V2TestSuite) TestShape() {
+	task := suite.createV2(gin.H{"title": "Buy milk", "due": gin.H{"at": "2030-05-01T09:00:00Z"}, "tags": []string{"Shop"}})
+
+	w := suite.request("GET", "/api/v2/tasks/"+task.Id.Hex(), nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var fields map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &fields))
+	assert.Equal(suite.T(), "Buy milk", fields["title"])
+	assert.Equal(suite.T(), "open", fields["status"])
+	assert.Equal(suite.T(), map[string]interface{}{"at": "2030-05-01T09:00:00Z", "overdue": false, "today": false}, fields["due"])
+	assert.Equal(suite.T(), []interface{}{"shop"}, fields["tags"])
+	assert.Equal(suite.T(), "/api/v2/tasks/"+task.Id.Hex()+"/history", fields["links"].(map[string]interface{})["history"])
+	assert.NotContains(suite.T(), fields, "description")
+	assert.NotContains(suite.T(), fields, "dueAt")
+
+	// Missing values are null and missing lists empty.
+	for _, field := range []string{"completedAt", "priority", "recurrence", "listId", "parentId"} {
+		assert.Contains(suite.T(), fields, field)
+		assert.Nil(suite.T(), fields[field], field)
+	}
+	assert.Equal(suite.T(), []interface{}{}, fields["projects"])
+}
+
+func (suite *V2TestSuite) TestSameTasksAsV1() {
+	task := suite.createV2(gin.H{"title": "Buy milk", "status": "done", "priority": 2})
+	assert.Equal(suite.T(), models.StatusDone, task.Status)
+	assert.NotNil(suite.T(), task.CompletedAt)
+	assert.Equal(suite.T(), task.Id.Timestamp().UTC(), task.CreatedAt)
+
+	w := suite.request("GET", "/api/v1/tasks", nil)
+	var tasks []models.Task
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &tasks))
+	suite.Require().Len(tasks, 1)
+	assert.Equal(suite.T(), "Buy milk", tasks[0].Description)
+	assert.True(suite.T(), tasks[0].Completed)
+	assert.Equal(suite.T(), 2, tasks[0].Priority)
+}
+
+func (suite *V2TestSuite) TestInvalidTask() {
+	w := suite.request("POST", "/api/v2/tasks", gin.H{"description": "Buy milk"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.JSONEq(suite.T(), `{"message": "Title is required"}`, w.Body.String())
+
+	w = suite.request("POST", "/api/v2/tasks", gin.H{"title": "Buy milk", "status": "later"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.JSONEq(suite.T(), `{"message": "Invalid status"}`, w.Body.String())
+}
+
+func (suite *V2TestSuite) TestErrorsPassThrough() {
+	w := suite.request("GET", "/api/v2/tasks/65f000000000000000000000", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	assert.JSONEq(suite.T(), `{"message": "Task not found"}`, w.Body.String())
+}
+
+func (suite *V2TestSuite) TestPatch() {
+	task := suite.createV2(gin.H{"title": "Buy milk", "due": gin.H{"at": "2030-05-01T09:00:00Z"}, "priority": 1})
+	url := "/api/v2/tasks/" + task.Id.Hex()
+
+	w := suite.request("PATCH", url, gin.H{"title": "Buy oat milk", "status": "done", "due": nil, "priority": nil})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var patched models.TaskV2
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &patched))
+	assert.Equal(suite.T(), "Buy oat milk", patched.Title)
+	assert.Equal(suite.T(), models.StatusDone, patched.Status)
+	assert.Nil(suite.T(), patched.Due)
+	assert.Nil(suite.T(), patched.Priority)
+
+	w = suite.request("PATCH", url, gin.H{"due": "tomorrow"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.JSONEq(suite.T(), `{"message": "Invalid due date"}`, w.Body.String())
+}
+
+func (suite *V2TestSuite) TestHistory() {
+	task := suite.createV2(gin.H{"title": "Draft", "due": gin.H{"at": "2030-05-01T09:00:00Z"}})
+	suite.request("PATCH", "/api/v2/tasks/"+task.Id.Hex(), gin.H{"title": "Final", "status": "done"})
+
+	w := suite.request("GET", "/api/v2/tasks/"+task.Id.Hex()+"/history", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var entries []models.HistoryEntry
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &entries))
+	suite.Require().Len(entries, 2)
+
+	changes := map[string]models.FieldChange{}
+	for _, change := range entries[0].Changes {
+		changes[change.Field] = change
+	}
+	assert.JSONEq(suite.T(), `{"at": "2030-05-01T09:00:00Z"}`, string(changes["due"].After))
+
+	changes = map[string]models.FieldChange{}
+	for _, change := range entries[1].Changes {
+		changes[change.Field] = change
+	}
+	assert.JSONEq(suite.T(), `"Draft"`, string(changes["title"].Before))
+	assert.JSONEq(suite.T(), `"Final"`, string(changes["title"].After))
+	assert.JSONEq(suite.T(), `"open"`, string(changes["status"].Before))
+	assert.JSONEq(suite.T(), `"done"`, string(changes["status"].After))
+}
+
+func (suite *V2TestSuite) TestBatch() {
+	task := suite.createV2(gin.H{"title": "Buy milk"})
+
+	w := suite.request("POST", "/api/v2/tasks/batch", gin.H{"operations": []gin.H{
+		{"op": "create", "task": gin.H{"title": "Pack"}},
+		{"op": "update", "id": task.Id.Hex(), "patch": gin.H{"status": "done"}},
+	}})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var response struct {
+		Results []struct {
+			Status int            `json:"status"`
+			Task   *models.TaskV2 `json:"task"`
+		} `json:"results"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Require().Len(response.Results, 2)
+	assert.Equal(suite.T(), "Pack", response.Results[0].Task.Title)
+	assert.Equal(suite.T(), models.StatusDone, response.Results[1].Task.Status)
+
+	w = suite.request("POST", "/api/v2/tasks/batch", gin.H{"operations": []gin.H{
+		{"op": "update", "id": task.Id.Hex(), "patch": gin.H{"status": "later"}},
+	}})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.JSONEq(suite.T(), `{"message": "Operation 1: Invalid status"}`, w.Body.String())
+}
+
+func (suite *V2TestSuite) TestSubtasks() {
+	parent := suite.createV2(gin.H{"title": "Plan trip"})
+	child := suite.createV2(gin.H{"title": "Book flights", "parentId": parent.Id.Hex()})
+	assert.Equal(suite.T(), "/api/v2/tasks/"+parent.Id.Hex(), child.Links.Parent)
+
+	w := suite.request("GET", "/api/v2/tasks/"+parent.Id.Hex()+"?tree=true", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var task models.TaskV2
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &task))
+	suite.Require().Len(task.Subtasks, 1)
+	assert.Equal(suite.T(), "Book flights", task.Subtasks[0].Title)
+	assert.Equal(suite.T(), &models.Progress{Done: 0, Total: 1}, task.Progress)
+}
+
+func TestDeprecate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	since := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+	router.GET("/api/v1/tasks", Deprecate(since, sunset), func(c *gin.Context) {
+		c.JSON(http.StatusOK, []models.Task{})
+	})
+
+	req, _ := http.NewRequest("GET", "/api/v1/tasks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@1792281600", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+}
+
+func TestV2TestSuite(t *testing.T) {
+	suite.Run(t, new(V2TestSuite))
+}
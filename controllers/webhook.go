@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxDeliveries is how many delivery attempts GetDeliveries lists.
+const maxDeliveries = 100
+
+type WebhookController struct {
+	webhooks   repository.WebhookRepository
+	deliveries repository.DeliveryRepository
+	now        func() time.Time
+}
+
+func NewWebhookController(s repository.Store) *WebhookController {
+	return &WebhookController{
+		webhooks:   s.Webhooks,
+		deliveries: s.Deliveries,
+		now:        time.Now,
+	}
+}
+
+// webhookRequest is the body of POST and PUT. A missing secret is
+// generated on create and kept on replace; a missing active flag means
+// true on create and is kept on replace.
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+	Active *bool    `json:"active"`
+}
+
+func (wc WebhookController) getContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), settings().RequestTimeout)
+}
+
+func (wc WebhookController) GetWebhooks(c *gin.Context) {
+	ctx, cancel := wc.getContext()
+	defer cancel()
+
+	webhooks, err := wc.webhooks.List(ctx, currentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch webhooks"})
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (wc WebhookController) GetWebhook(c *gin.Context) {
+	ctx, cancel := wc.getContext()
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	hook, ok := wc.getWebhook(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	hook.Secret = ""
+	c.JSON(http.StatusOK, hook)
+}
+
+// CreateWebhook subscribes a URL to task events. The response is the only
+// one to include the secret payloads are signed with.
+func (wc WebhookController) CreateWebhook(c *gin.Context) {
+	ctx, cancel := wc.getContext()
+	defer cancel()
+
+	request, ok := bindWebhook(c)
+	if !ok {
+		return
+	}
+
+	if request.Secret == "" {
+		secret, err := newToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create webhook"})
+			return
+		}
+		request.Secret = secret
+	}
+
+	hook := models.Webhook{
+		OwnerId:   currentUser(c),
+		URL:       request.URL,
+		Events:    request.Events,
+		Secret:    request.Secret,
+		Active:    request.Active == nil || *request.Active,
+		CreatedAt: wc.now().UTC(),
+	}
+
+	hook, err := wc.webhooks.Insert(ctx, hook)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+// ReplaceWebhook handles PUT. Activating a webhook that was disabled for
+// failing resets its failure count.
+func (wc WebhookController) ReplaceWebhook(c *gin.Context) {
+	ctx, cancel := wc.getContext()
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	request, ok := bindWebhook(c)
+	if !ok {
+		return
+	}
+
+	hook, ok := wc.getWebhook(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	hook.URL = request.URL
+	hook.Events = request.Events
+	if request.Secret != "" {
+		hook.Secret = request.Secret
+	}
+	if request.Active != nil && *request.Active != hook.Active {
+		hook.Active = *request.Active
+		hook.Failures = 0
+		hook.DisabledAt = nil
+	}
+
+	err := wc.webhooks.Update(ctx, hook)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update webhook"})
+		return
+	}
+
+	hook.Secret = ""
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook unsubscribes a webhook and drops its delivery log.
+func (wc WebhookController) DeleteWebhook(c *gin.Context) {
+	ctx, cancel := wc.getContext()
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := wc.getWebhook(ctx, c, objectID); !ok {
+		return
+	}
+
+	err := wc.webhooks.Delete(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete webhook"})
+		return
+	}
+
+	if err := wc.deliveries.DeleteAll(ctx, objectID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries lists the latest delivery attempts of a webhook, newest
+// first.
+func (wc WebhookController) GetDeliveries(c *gin.Context) {
+	ctx, cancel := wc.getContext()
+	defer cancel()
+
+	objectID, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := wc.getWebhook(ctx, c, objectID); !ok {
+		return
+	}
+
+	deliveries, err := wc.deliveries.List(ctx, objectID, maxDeliveries)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// getWebhook fetches a webhook of the logged-in user. Other users'
+// webhooks are reported as missing. On failure it writes a 404 or 500
+// response and returns false.
+func (wc WebhookController) getWebhook(ctx context.Context, c *gin.Context, id bson.ObjectID) (models.Webhook, bool) {
+	hook, err := wc.webhooks.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !ownedBy(c, hook.OwnerId)) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook not found"})
+		return hook, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to fetch webhook"})
+		return hook, false
+	}
+
+	return hook, true
+}
+
+// bindWebhook reads and validates a webhook request. On failure it writes
+// a 400 response and returns false.
+func bindWebhook(c *gin.Context) (webhookRequest, bool) {
+	var request webhookRequest
+
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return request, false
+	}
+
+	target, err := url.Parse(request.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid webhook URL"})
+		return request, false
+	}
+
+	for _, event := range request.Events {
+		if !slices.Contains(webhook.Events, event) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid event: " + event})
+			return request, false
+		}
+	}
+	if request.Events == nil {
+		request.Events = []string{}
+	}
+
+	return request, true
+}
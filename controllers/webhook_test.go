@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookTestSuite struct {
+	controllerSuite
+	lists  *ListController
+	server *httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	received []webhook.Payload
+}
+
+func (suite *WebhookTestSuite) SetupTest() {
+	suite.controllerSuite.SetupTest()
+	suite.controller.webhooks.Backoff = time.Millisecond
+	suite.lists = NewListController(suite.store)
+	wc := NewWebhookController(suite.store)
+
+	suite.statuses = nil
+	suite.received = nil
+	suite.server = httptest.NewServer(http.HandlerFunc(suite.receive))
+
+	suite.router.POST("/api/task", suite.controller.CreateTask)
+	suite.router.PATCH("/api/task/:id", suite.controller.UpdateTask)
+	suite.router.DELETE("/api/task/:id", suite.controller.DeleteTask)
+	suite.router.DELETE("/api/tasks", suite.controller.DeleteAllTasks)
+	suite.router.POST("/api/tasks/undo", suite.controller.UndoDeleteAll)
+	suite.router.POST("/api/tasks/batch", suite.controller.BatchTasks)
+	suite.router.POST("/api/trash/:id/restore", suite.controller.RestoreFromTrash)
+	suite.router.POST("/api/lists", suite.lists.CreateList)
+	suite.router.DELETE("/api/lists/:id", suite.lists.DeleteList)
+	suite.router.GET("/api/webhooks", wc.GetWebhooks)
+	suite.router.POST("/api/webhooks", wc.CreateWebhook)
+	suite.router.GET("/api/webhooks/:id", wc.GetWebhook)
+	suite.router.PUT("/api/webhooks/:id", wc.ReplaceWebhook)
+	suite.router.DELETE("/api/webhooks/:id", wc.DeleteWebhook)
+	suite.router.GET("/api/webhooks/:id/deliveries", wc.GetDeliveries)
+}
+
+func (suite *WebhookTestSuite) TearDownTest() {
+	suite.controller.webhooks.Wait()
+	suite.lists.tasks.webhooks.Wait()
+	suite.server.Close()
+}
+
+// receive is the subscribed endpoint. It checks the signature against the
+// secret "s3cret" and answers with the queued statuses, then 204.
+func (suite *WebhookTestSuite) receive(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get(webhook.SignatureHeader) != webhook.Sign("s3cret", body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+
+	status := http.StatusNoContent
+	if len(suite.statuses) > 0 {
+		status, suite.statuses = suite.statuses[0], suite.statuses[1:]
+	}
+	if status < 300 {
+		var payload webhook.Payload
+		_ = json.Unmarshal(body, &payload)
+		suite.received = append(suite.received, payload)
+	}
+	w.WriteHeader(status)
+}
+
+// call sends body, unless nil, as JSON and decodes the object answered.
+func (suite *WebhookTestSuite) call(method, url string, body any) (*httptest.ResponseRecorder, map[string]interface{}) {
+	w := suite.request(method, url, body)
+	return w, jsonObject(w)
+}
+
+// subscribe creates a webhook for events and returns its id.
+func (suite *WebhookTestSuite) subscribe(events ...string) string {
+	w, response := suite.call("POST", "/api/webhooks", gin.H{"url": suite.server.URL, "events": events, "secret": "s3cret"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return response["id"].(string)
+}
+
+// events waits for the deliveries under way and returns the names of the
+// events received, in order.
+func (suite *WebhookTestSuite) events() []string {
+	suite.controller.webhooks.Wait()
+	suite.lists.tasks.webhooks.Wait()
+
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+
+	names := []string{}
+	for _, payload := range suite.received {
+		names = append(names, payload.Event)
+	}
+	return names
+}
+
+func (suite *WebhookTestSuite) TestCRUD() {
+	w, response := suite.call("POST", "/api/webhooks", gin.H{"url": "https://example.com/hook"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	id := response["id"].(string)
+	assert.NotEmpty(suite.T(), response["secret"])
+	assert.Equal(suite.T(), true, response["active"])
+	assert.Equal(suite.T(), []interface{}{}, response["events"])
+
+	w, response = suite.call("GET", "/api/webhooks/"+id, nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), response, "secret")
+
+	w, response = suite.call("PUT", "/api/webhooks/"+id, gin.H{"url": "https://example.com/other", "events": []string{"task.deleted"}})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "https://example.com/other", response["url"])
+	assert.Equal(suite.T(), []interface{}{"task.deleted"}, response["events"])
+
+	w, _ = suite.call("GET", "/api/webhooks", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), "secret")
+
+	w, _ = suite.call("DELETE", "/api/webhooks/"+id, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w, _ = suite.call("GET", "/api/webhooks/"+id, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *WebhookTestSuite) TestValidation() {
+	for _, body := range []gin.H{
+		{"url": "ftp://example.com/hook"},
+		{"url": "/relative"},
+		{"url": "https://example.com/hook", "events": []string{"task.exploded"}},
+	} {
+		w, _ := suite.call("POST", "/api/webhooks", body)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func (suite *WebhookTestSuite) TestTaskEvents() {
+	suite.subscribe()
+
+	w, response := suite.call("POST", "/api/task", gin.H{"description": "Buy milk"})
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	id := response["id"].(string)
+	suite.Require().Equal([]string{webhook.EventTaskCreated}, suite.events())
+
+	w, _ = suite.call("PATCH", "/api/task/"+id, gin.H{"completed": true})
+	suite.Require().Equal(http.StatusOK, w.Code)
+	suite.Require().Len(suite.events(), 2)
+
+	w, _ = suite.call("DELETE", "/api/task/"+id, nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Equal(suite.T(), []string{webhook.EventTaskCreated, webhook.EventTaskUpdated, webhook.EventTaskDeleted}, suite.events())
+
+	data := suite.received[1].Data.(map[string]interface{})
+	assert.Equal(suite.T(), true, data["completed"])
+	data = suite.received[2].Data.(map[string]interface{})
+	assert.Equal(suite.T(), id, data["id"])
+}
+
+func (suite *WebhookTestSuite) TestClearedEvent() {
+	suite.subscribe(webhook.EventTasksCleared)
+
+	suite.call("POST", "/api/task", gin.H{"description": "One"})
+	suite.call("POST", "/api/task", gin.H{"description": "Two"})
+
+	w := deleteAll(suite.T(), suite.router, "/api/tasks")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Require().Equal([]string{webhook.EventTasksCleared}, suite.events())
+
+	data := suite.received[0].Data.(map[string]interface{})
+	assert.Equal(suite.T(), float64(2), data["deletedCount"])
+}
+
+func (suite *WebhookTestSuite) TestRestoreEvents() {
+	_, task := suite.call("POST", "/api/task", gin.H{"description": "Buy milk"})
+	id := task["id"].(string)
+	suite.call("DELETE", "/api/task/"+id, nil)
+	suite.call("POST", "/api/task", gin.H{"description": "Pack"})
+	suite.Require().Empty(suite.events())
+	suite.subscribe()
+
+	w, _ := suite.call("POST", "/api/trash/"+id+"/restore", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Require().Equal([]string{webhook.EventTaskCreated}, suite.events())
+
+	_, pending := suite.call("DELETE", "/api/tasks", nil)
+	token := pending["token"].(string)
+	w, _ = suite.call("DELETE", "/api/tasks?token="+token, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Require().Len(suite.events(), 2)
+
+	w, _ = suite.call("POST", "/api/tasks/undo?token="+token, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), []string{
+		webhook.EventTaskCreated,
+		webhook.EventTasksCleared,
+		webhook.EventTaskCreated,
+		webhook.EventTaskCreated,
+	}, suite.events())
+}
+
+func (suite *WebhookTestSuite) TestListDeleteEvents() {
+	_, list := suite.call("POST", "/api/lists", gin.H{"name": "Groceries"})
+	listID := list["id"].(string)
+	suite.call("POST", "/api/task", gin.H{"description": "Buy milk", "listId": listID})
+	suite.Require().Empty(suite.events())
+	suite.subscribe(webhook.EventTaskUpdated, webhook.EventTaskDeleted)
+
+	w, _ := suite.call("DELETE", "/api/lists/"+listID, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Require().Equal([]string{webhook.EventTaskUpdated}, suite.events())
+	assert.NotContains(suite.T(), suite.received[0].Data, "listId")
+
+	_, list = suite.call("POST", "/api/lists", gin.H{"name": "Packing"})
+	listID = list["id"].(string)
+	suite.call("POST", "/api/task", gin.H{"description": "Pack", "listId": listID})
+
+	w, _ = suite.call("DELETE", "/api/lists/"+listID+"?tasks=cascade", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), []string{webhook.EventTaskUpdated, webhook.EventTaskDeleted}, suite.events())
+}
+
+func (suite *WebhookTestSuite) TestRolledBackBatchIsSilent() {
+	suite.subscribe()
+
+	w, _ := suite.call("POST", "/api/tasks/batch", gin.H{"atomic": true, "operations": []gin.H{
+		{"op": "create", "task": gin.H{"description": "New"}},
+		{"op": "create", "task": gin.H{"description": "Orphan", "parentId": "65f000000000000000000000"}},
+	}})
+	suite.Require().Equal(http.StatusBadRequest, w.Code)
+	assert.Empty(suite.T(), suite.events())
+
+	w, _ = suite.call("POST", "/api/tasks/batch", gin.H{"atomic": true, "operations": []gin.H{
+		{"op": "create", "task": gin.H{"description": "New"}},
+	}})
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Equal(suite.T(), []string{webhook.EventTaskCreated}, suite.events())
+}
+
+func (suite *WebhookTestSuite) TestDeliveriesAndDisable() {
+	id := suite.subscribe()
+	suite.controller.webhooks.MaxAttempts = 2
+	suite.controller.webhooks.DisableAfter = 2
+	suite.statuses = []int{http.StatusBadGateway}
+
+	suite.call("POST", "/api/task", gin.H{"description": "Retried"})
+	suite.Require().Len(suite.events(), 1)
+
+	w, _ := suite.call("GET", "/api/webhooks/"+id+"/deliveries", nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var deliveries []models.Delivery
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &deliveries))
+	suite.Require().Len(deliveries, 2)
+	assert.True(suite.T(), deliveries[0].Succeeded)
+	assert.Equal(suite.T(), http.StatusBadGateway, deliveries[1].StatusCode)
+
+	// Two deliveries in a row that exhaust their attempts disable it.
+	suite.statuses = []int{500, 500, 500, 500}
+	suite.call("POST", "/api/task", gin.H{"description": "Lost"})
+	suite.call("POST", "/api/task", gin.H{"description": "Lost too"})
+	suite.events()
+
+	_, response := suite.call("GET", "/api/webhooks/"+id, nil)
+	assert.Equal(suite.T(), false, response["active"])
+	assert.NotNil(suite.T(), response["disabledAt"])
+
+	suite.call("POST", "/api/task", gin.H{"description": "Not sent"})
+	assert.Len(suite.T(), suite.events(), 1)
+
+	// Enabling it again clears the failures.
+	w, response = suite.call("PUT", "/api/webhooks/"+id, gin.H{"url": suite.server.URL, "active": true})
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Equal(suite.T(), float64(0), response["failures"])
+	assert.NotContains(suite.T(), response, "disabledAt")
+
+	suite.call("POST", "/api/task", gin.H{"description": "Sent"})
+	assert.Len(suite.T(), suite.events(), 2)
+}
+
+func TestWebhookTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookTestSuite))
+}
@@ -1,12 +1,7 @@
 db = db.getSiblingDB('todo-app-go');
 
-db.tasks.insertMany([
-    {
-        description: "Welcome to your Todo App!"
-    },
-    {
-        description: "Try adding your own tasks"
-    }
-]);
+// Tasks belong to users, so starter tasks are imported per account from
+// seed/tasks.json with POST /api/tasks/import instead of being seeded here.
+db.tasks.createIndex({ description: "text" }, { name: "description_text" });
 
 print("Database initialized successfully!");
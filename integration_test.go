@@ -4,76 +4,76 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"regexp"
+	"strings"
 	"testing"
-	"time"
 
+	"example.com/todo-rest-api/config"
 	"example.com/todo-rest-api/controllers"
 	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type IntegrationTestSuite struct {
 	suite.Suite
 	router *gin.Engine
-	client *mongo.Client
+	// auth is the Authorization header of a freshly registered user.
+	auth string
 }
 
 func (suite *IntegrationTestSuite) SetupSuite() {
-	// Set test environment
-	os.Setenv("MONGODB_URI", "mongodb://localhost:27017")
 	gin.SetMode(gin.TestMode)
+}
 
-	// Connect to test MongoDB
-	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://localhost:27017"))
-	if err != nil {
-		suite.T().Fatalf("Failed to connect to MongoDB: %v", err)
-	}
-
-	suite.client = client
-
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	err = client.Ping(ctx, nil)
-	if err != nil {
-		suite.T().Fatalf("Failed to ping MongoDB: %v", err)
-	}
+func (suite *IntegrationTestSuite) SetupTest() {
+	// Fresh router backed by in-memory storage before each test
+	store := repository.NewMemoryStore()
+	uc := controllers.NewTaskControllerWithStore(store)
+	lc := controllers.NewListController(store)
+	ac := controllers.NewAuthController(store)
+	wc := controllers.NewWebhookController(store)
 
-	// Setup router
 	suite.router = gin.New()
-	uc := controllers.NewTaskControllerWithDB(client, "todo-app-go-test")
+	suite.router.LoadHTMLGlob("templates/*.gohtml")
+	registerRoutes(suite.router, config.Default().API, uc, lc, ac, wc, controllers.NewOpenAPIController())
 
-	apiRoutes := suite.router.Group("/api")
-	viewRoutes := suite.router.Group("/view")
+	suite.auth = suite.login("user@example.com")
+}
 
-	apiRoutes.POST("/task", uc.CreateTask)
-	apiRoutes.GET("/tasks", uc.GetTasks)
-	apiRoutes.DELETE("/task/:id", uc.DeleteTask)
-	apiRoutes.DELETE("/tasks", uc.DeleteAllTasks)
+// login registers email and returns the Authorization header for it.
+func (suite *IntegrationTestSuite) login(email string) string {
+	credentials, _ := json.Marshal(map[string]string{
+		"email":    email,
+		"password": "correct horse",
+	})
 
-	viewRoutes.GET("/tasks", uc.ShowAllTasks)
-}
+	req, _ := http.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(credentials))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusCreated, w.Code)
 
-func (suite *IntegrationTestSuite) TearDownSuite() {
-	if suite.client != nil {
-		suite.client.Disconnect(context.Background())
-	}
-}
+	req, _ = http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(credentials))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
 
-func (suite *IntegrationTestSuite) SetupTest() {
-	// Clean up test database before each test
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	collection := suite.client.Database("todo-app-go-test").Collection("tasks")
-	collection.Drop(ctx)
+	var response map[string]string
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Require().NotEmpty(response["token"])
+
+	return "Bearer " + response["token"]
 }
 
 func (suite *IntegrationTestSuite) TestFullTaskWorkflow() {
@@ -85,6 +85,7 @@ func (suite *IntegrationTestSuite) TestFullTaskWorkflow() {
 
 	req, _ := http.NewRequest("POST", "/api/task", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", suite.auth)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 
@@ -98,6 +99,7 @@ func (suite *IntegrationTestSuite) TestFullTaskWorkflow() {
 
 	// 2. Get all tasks
 	req, _ = http.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", suite.auth)
 	w = httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 
@@ -111,6 +113,7 @@ func (suite *IntegrationTestSuite) TestFullTaskWorkflow() {
 
 	// 3. Delete the task
 	req, _ = http.NewRequest("DELETE", "/api/task/"+createdTask.Id.Hex(), nil)
+	req.Header.Set("Authorization", suite.auth)
 	w = httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 
@@ -118,6 +121,7 @@ func (suite *IntegrationTestSuite) TestFullTaskWorkflow() {
 
 	// 4. Verify task is deleted
 	req, _ = http.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", suite.auth)
 	w = httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 
@@ -139,6 +143,7 @@ func (suite *IntegrationTestSuite) TestCreateMultipleTasksAndDeleteAll() {
 
 		req, _ := http.NewRequest("POST", "/api/task", bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", suite.auth)
 		w := httptest.NewRecorder()
 		suite.router.ServeHTTP(w, req)
 
@@ -147,6 +152,7 @@ func (suite *IntegrationTestSuite) TestCreateMultipleTasksAndDeleteAll() {
 
 	// Verify all tasks exist
 	req, _ := http.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", suite.auth)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 
@@ -157,8 +163,23 @@ func (suite *IntegrationTestSuite) TestCreateMultipleTasksAndDeleteAll() {
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), tasks, 3)
 
-	// Delete all tasks
+	// Delete all tasks: the first call only asks for confirmation
 	req, _ = http.NewRequest("DELETE", "/api/tasks", nil)
+	req.Header.Set("Authorization", suite.auth)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+
+	var confirmation map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &confirmation)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), float64(3), confirmation["count"])
+	token, _ := confirmation["token"].(string)
+	assert.NotEmpty(suite.T(), token)
+
+	req, _ = http.NewRequest("DELETE", "/api/tasks?token="+token, nil)
+	req.Header.Set("Authorization", suite.auth)
 	w = httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 
@@ -172,6 +193,7 @@ func (suite *IntegrationTestSuite) TestCreateMultipleTasksAndDeleteAll() {
 
 	// Verify all tasks are deleted
 	req, _ = http.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", suite.auth)
 	w = httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 
@@ -181,10 +203,398 @@ func (suite *IntegrationTestSuite) TestCreateMultipleTasksAndDeleteAll() {
 	err = json.Unmarshal(w.Body.Bytes(), &emptyTasks)
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), emptyTasks, 0)
+
+	// The token is single-use, and then undoes the delete
+	req, _ = http.NewRequest("DELETE", "/api/tasks?token="+token, nil)
+	req.Header.Set("Authorization", suite.auth)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest("POST", "/api/tasks/undo?token="+token, nil)
+	req.Header.Set("Authorization", suite.auth)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"restoredCount":3`)
+
+	req, _ = http.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", suite.auth)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	err = json.Unmarshal(w.Body.Bytes(), &tasks)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), tasks, 3)
+}
+
+func (suite *IntegrationTestSuite) TestRequiresAuthentication() {
+	req, _ := http.NewRequest("GET", "/api/tasks", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	req, _ = http.NewRequest("GET", "/view/tasks", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusSeeOther, w.Code)
+	assert.Equal(suite.T(), "/view/login", w.Header().Get("Location"))
+}
+
+func (suite *IntegrationTestSuite) TestTasksAreScopedToOwner() {
+	jsonData, _ := json.Marshal(map[string]string{"description": "Private task"})
+
+	req, _ := http.NewRequest("POST", "/api/task", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", suite.auth)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var createdTask models.Task
+	err := json.Unmarshal(w.Body.Bytes(), &createdTask)
+	assert.NoError(suite.T(), err)
+
+	other := suite.login("other@example.com")
+
+	req, _ = http.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", other)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), "[]", w.Body.String())
+
+	req, _ = http.NewRequest("DELETE", "/api/task/"+createdTask.Id.Hex(), nil)
+	req.Header.Set("Authorization", other)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("DELETE", "/api/tasks", nil)
+	req.Header.Set("Authorization", other)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"count":0`)
+
+	// Confirmation tokens only work for the user they were issued to
+	req, _ = http.NewRequest("DELETE", "/api/tasks", nil)
+	req.Header.Set("Authorization", suite.auth)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	var confirmation map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &confirmation)
+	assert.NoError(suite.T(), err)
+	token, _ := confirmation["token"].(string)
+
+	req, _ = http.NewRequest("DELETE", "/api/tasks?token="+token, nil)
+	req.Header.Set("Authorization", other)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", suite.auth)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	var tasks []models.Task
+	err = json.Unmarshal(w.Body.Bytes(), &tasks)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), tasks, 1)
+}
+
+func (suite *IntegrationTestSuite) TestLogout() {
+	req, _ := http.NewRequest("POST", "/api/auth/logout", nil)
+	req.Header.Set("Authorization", suite.auth)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/auth/me", nil)
+	req.Header.Set("Authorization", suite.auth)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+// openAPI loads the OpenAPI document served by the app.
+func (suite *IntegrationTestSuite) openAPI() *openapi3.T {
+	req, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	doc, err := openapi3.NewLoader().LoadFromData(w.Body.Bytes())
+	suite.Require().NoError(err)
+	return doc
+}
+
+// call sends a request as the logged-in user and asserts that the response
+// is one the OpenAPI document describes.
+func (suite *IntegrationTestSuite) call(router routers.Router, method, url, contentType string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+
+	req, _ := http.NewRequest(method, url, bytes.NewReader(data))
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Authorization", suite.auth)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	route, pathParams, err := router.FindRoute(req)
+	suite.Require().NoError(err, url)
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route},
+		Status:                 w.Code,
+		Header:                 w.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+	})
+	assert.NoError(suite.T(), err, "%s %s: %s", method, url, w.Body.String())
+
+	return w
+}
+
+func (suite *IntegrationTestSuite) TestRoutesAreDocumented() {
+	doc := suite.openAPI()
+	param := regexp.MustCompile(`[:*](\w+)`)
+
+	for _, route := range suite.router.Routes() {
+		// WebDAV methods have no place in OpenAPI; the descriptions of the
+		// CalDAV routes mention them.
+		if route.Method == "PROPFIND" || route.Method == "REPORT" {
+			continue
+		}
+
+		path := param.ReplaceAllString(route.Path, "{$1}")
+		pathItem := doc.Paths.Value(path)
+		if pathItem == nil && strings.HasPrefix(path, "/api/") {
+			// Version 1 is also served without its version.
+			pathItem = doc.Paths.Value("/api/v1/" + strings.TrimPrefix(path, "/api/"))
+		}
+		if assert.NotNil(suite.T(), pathItem, path) {
+			assert.NotNil(suite.T(), pathItem.GetOperation(route.Method), "%s %s", route.Method, path)
+		}
+	}
+}
+
+func (suite *IntegrationTestSuite) TestResponsesMatchOpenAPI() {
+	router, err := legacy.NewRouter(suite.openAPI())
+	suite.Require().NoError(err)
+	call := func(method, url string, body interface{}) map[string]interface{} {
+		w := suite.call(router, method, url, "application/json", body)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	call("GET", "/api/auth/me", nil)
+
+	list := call("POST", "/api/v1/lists", map[string]interface{}{"name": "Travel"})["id"].(string)
+	call("PUT", "/api/v1/lists/"+list, map[string]interface{}{"name": "Trips"})
+	call("GET", "/api/v1/lists/"+list, nil)
+	call("GET", "/api/v1/lists", nil)
+
+	parent := call("POST", "/api/v1/task", map[string]interface{}{
+		"description": "Plan trip",
+		"dueAt":       "2030-05-01T09:00:00Z",
+		"priority":    1,
+		"tags":        []string{"Travel"},
+		"listId":      list,
+		"recurrence":  map[string]interface{}{"rule": "FREQ=WEEKLY"},
+	})["id"].(string)
+	child := call("POST", "/api/v1/lists/"+list+"/tasks", map[string]interface{}{"description": "Book flights", "parentId": parent})["id"].(string)
+
+	call("GET", "/api/v1/tasks?count=true&tree=true&limit=1", nil)
+	call("GET", "/api/v1/tasks?q=trip", nil)
+	call("GET", "/api/v1/lists/"+list+"/tasks?sort=-dueAt", nil)
+	call("GET", "/api/v1/tasks/today", nil)
+	call("GET", "/api/v1/tasks/overdue", nil)
+	call("GET", "/api/v1/tasks/upcoming?days=30", nil)
+	call("GET", "/api/v1/tasks/export", nil)
+
+	suite.call(router, "PATCH", "/api/v1/task/"+child, "application/merge-patch+json", map[string]interface{}{"completed": true})
+	call("PUT", "/api/v1/task/"+child, map[string]interface{}{"description": "Book trains", "parentId": parent})
+	call("POST", "/api/v1/task/"+parent+"/toggle", nil)
+	call("GET", "/api/v1/task/"+parent+"/children", nil)
+	call("GET", "/api/v1/task/"+parent+"/occurrences?count=3", nil)
+	call("GET", "/api/v1/task/"+child+"/history", nil)
+	call("POST", "/api/v1/task/"+child+"/restore?version=1", nil)
+
+	call("POST", "/api/v1/tasks/batch", map[string]interface{}{"operations": []interface{}{
+		map[string]interface{}{"op": "create", "task": map[string]interface{}{"description": "Pack"}},
+		map[string]interface{}{"op": "tag", "id": child, "add": []string{"booked"}},
+		map[string]interface{}{"op": "complete", "id": "65f000000000000000000000"},
+	}})
+	call("POST", "/api/v1/tasks/batch", map[string]interface{}{"atomic": true, "operations": []interface{}{
+		map[string]interface{}{"op": "complete", "id": "65f000000000000000000000"},
+	}})
+
+	call("DELETE", "/api/v1/task/"+parent+"?children=cascade", nil)
+	call("GET", "/api/v1/trash", nil)
+	call("POST", "/api/v1/trash/"+parent+"/restore", nil)
+
+	token := call("DELETE", "/api/v1/tasks?status=open", nil)["token"].(string)
+	call("DELETE", "/api/v1/tasks?token="+token, nil)
+	call("POST", "/api/v1/tasks/undo?token="+token, nil)
+	call("DELETE", "/api/v1/trash", nil)
+	call("DELETE", "/api/v1/lists/"+list+"?tasks=cascade", nil)
+
+	// Webhooks come last so that no task event is delivered to them.
+	webhook := call("POST", "/api/v1/webhooks", map[string]interface{}{"url": "https://example.com/hook"})["id"].(string)
+	call("PUT", "/api/v1/webhooks/"+webhook, map[string]interface{}{"url": "https://example.com/hook", "events": []string{"task.created"}})
+	call("GET", "/api/v1/webhooks", nil)
+	call("GET", "/api/v1/webhooks/"+webhook, nil)
+	call("GET", "/api/v1/webhooks/"+webhook+"/deliveries", nil)
+	call("DELETE", "/api/v1/webhooks/"+webhook, nil)
+
+	// Errors are documented too.
+	call("GET", "/api/v1/tasks?limit=0", nil)
+	call("GET", "/api/v1/task/65f000000000000000000000/history", nil)
+	call("POST", "/api/v1/lists", map[string]interface{}{"name": 1})
+}
+
+func (suite *IntegrationTestSuite) TestResponsesMatchOpenAPIV2() {
+	router, err := legacy.NewRouter(suite.openAPI())
+	suite.Require().NoError(err)
+	call := func(method, url string, body interface{}) map[string]interface{} {
+		w := suite.call(router, method, url, "application/json", body)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	list := call("POST", "/api/v2/lists", map[string]interface{}{"name": "Travel"})["id"].(string)
+	call("GET", "/api/v2/lists/"+list, nil)
+
+	parent := call("POST", "/api/v2/tasks", map[string]interface{}{
+		"title":      "Plan trip",
+		"due":        map[string]interface{}{"at": "2030-05-01T09:00:00Z"},
+		"priority":   1,
+		"tags":       []string{"Travel"},
+		"listId":     list,
+		"recurrence": map[string]interface{}{"rule": "FREQ=WEEKLY"},
+	})["id"].(string)
+	child := call("POST", "/api/v2/lists/"+list+"/tasks", map[string]interface{}{"title": "Book flights", "parentId": parent})["id"].(string)
+
+	call("GET", "/api/v2/tasks?count=true&tree=true&limit=1", nil)
+	call("GET", "/api/v2/tasks?q=trip", nil)
+	call("GET", "/api/v2/lists/"+list+"/tasks?sort=-dueAt", nil)
+	call("GET", "/api/v2/tasks/today", nil)
+	call("GET", "/api/v2/tasks/overdue", nil)
+	call("GET", "/api/v2/tasks/upcoming?days=30", nil)
+
+	call("GET", "/api/v2/tasks/"+parent+"?tree=true", nil)
+	suite.call(router, "PATCH", "/api/v2/tasks/"+child, "application/merge-patch+json", map[string]interface{}{"status": "done", "due": nil})
+	call("PUT", "/api/v2/tasks/"+child, map[string]interface{}{"title": "Book trains", "parentId": parent})
+	call("POST", "/api/v2/tasks/"+parent+"/toggle", nil)
+	call("GET", "/api/v2/tasks/"+parent+"/subtasks", nil)
+	call("GET", "/api/v2/tasks/"+parent+"/occurrences?count=3", nil)
+	call("GET", "/api/v2/tasks/"+child+"/history", nil)
+	call("POST", "/api/v2/tasks/"+child+"/restore?version=1", nil)
+
+	call("POST", "/api/v2/tasks/batch", map[string]interface{}{"operations": []interface{}{
+		map[string]interface{}{"op": "create", "task": map[string]interface{}{"title": "Pack"}},
+		map[string]interface{}{"op": "update", "id": child, "patch": map[string]interface{}{"title": "Book buses"}},
+		map[string]interface{}{"op": "complete", "id": "65f000000000000000000000"},
+	}})
+	call("POST", "/api/v2/tasks/batch", map[string]interface{}{"atomic": true, "operations": []interface{}{
+		map[string]interface{}{"op": "complete", "id": "65f000000000000000000000"},
+	}})
+
+	call("DELETE", "/api/v2/tasks/"+parent+"?children=cascade", nil)
+	call("GET", "/api/v2/trash", nil)
+	call("POST", "/api/v2/trash/"+parent+"/restore", nil)
+	call("DELETE", "/api/v2/lists/"+list+"?tasks=cascade", nil)
+
+	// Errors are documented too.
+	call("POST", "/api/v2/tasks", map[string]interface{}{"description": "Plan trip"})
+	call("GET", "/api/v2/tasks/65f000000000000000000000", nil)
+}
+
+func (suite *IntegrationTestSuite) TestVersions() {
+	request := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, url, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", suite.auth)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Scripts written before versions existed keep working, warned that
+	// they will not for long.
+	w := request("POST", "/api/task", map[string]interface{}{"description": "Buy milk", "dueAt": "2030-05-01T09:00:00Z"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "@1792281600", w.Header().Get("Deprecation"))
+	assert.Equal(suite.T(), "Fri, 30 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+
+	var v1 models.Task
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &v1))
+
+	w = request("GET", "/api/v1/tasks", nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.NotEmpty(suite.T(), w.Header().Get("Deprecation"))
+
+	// Version 2 shows the same task in its own shape.
+	w = request("GET", "/api/v2/tasks/"+v1.Id.Hex(), nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(suite.T(), w.Header().Get("Deprecation"))
+	assert.Empty(suite.T(), w.Header().Get("Sunset"))
+
+	var v2 models.TaskV2
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &v2))
+	assert.Equal(suite.T(), "Buy milk", v2.Title)
+	assert.Equal(suite.T(), models.StatusOpen, v2.Status)
+	suite.Require().NotNil(v2.Due)
+	assert.True(suite.T(), v1.DueAt.Equal(v2.Due.At))
+	assert.Equal(suite.T(), "/api/v2/tasks/"+v1.Id.Hex(), v2.Links.Self)
+
+	// And the other way around.
+	w = request("PATCH", "/api/v2/tasks/"+v1.Id.Hex(), map[string]interface{}{"title": "Buy oat milk", "status": "done"})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	w = request("GET", "/api/tasks", nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var tasks []models.Task
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &tasks))
+	suite.Require().Len(tasks, 1)
+	assert.Equal(suite.T(), "Buy oat milk", tasks[0].Description)
+	assert.True(suite.T(), tasks[0].Completed)
+
+	// The singular routes are gone from version 2.
+	w = request("POST", "/api/v2/task", map[string]interface{}{"title": "Buy bread"})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
 }
 
 func TestIntegrationSuite(t *testing.T) {
-	// Skip integration tests if MongoDB is not available
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
@@ -2,9 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log"
+	"net"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
+	"example.com/todo-rest-api/config"
 	"example.com/todo-rest-api/controllers"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -14,38 +20,233 @@ import (
 
 func main() {
 
-	router := gin.Default()
-	uc := controllers.NewTaskController(getClient())
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+	log.Print("Configuration:\n", cfg)
+	controllers.Configure(controllers.SettingsFrom(cfg))
+
+	if os.Getenv(gin.EnvGinMode) == "" && cfg.Log.Level != config.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	router := gin.New()
+	router.Use(controllers.RequestLogger(), gin.Recovery())
+	store := controllers.NewStoreWithCollection(getClient(cfg), cfg.MongoDB.Database, cfg.MongoDB.TasksCollection)
+	uc := controllers.NewTaskControllerWithStore(store)
+	lc := controllers.NewListController(store)
+	ac := controllers.NewAuthController(store)
+	wc := controllers.NewWebhookController(store)
+	oc := controllers.NewOpenAPIController()
 
 	router.Static("/static", "./public")
 	router.LoadHTMLGlob("templates/*.gohtml")
 
-	apiRoutes := router.Group("/api")
-	viewRoutes := router.Group("/view")
+	registerRoutes(router, cfg.API, uc, lc, ac, wc, oc)
+
+	go uc.PurgeTrash(context.Background())
+	go serveGRPC(uc, ac, cfg.Server.GRPCAddr)
+	go reloadOnHangup(cfg)
+
+	router.Run(cfg.Server.Addr)
+}
+
+// reloadOnHangup reads the configuration again on every SIGHUP and applies
+// the settings that can change while the app runs. An invalid
+// configuration is logged and ignored.
+func reloadOnHangup(cfg config.Config) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	for range hangup {
+		next, err := config.Load(os.Args[1:], os.Getenv)
+		var ignored []string
+		if err == nil {
+			next, ignored, err = config.Reload(cfg, next)
+		}
+		if err != nil {
+			log.Print("Not reloading the configuration:\n", err)
+			continue
+		}
+
+		for _, key := range ignored {
+			log.Println("Changing", key, "needs a restart")
+		}
+
+		cfg = next
+		controllers.Configure(controllers.SettingsFrom(cfg))
+		log.Print("Reloaded the configuration:\n", cfg)
+	}
+}
+
+// registerRoutes serves the app on router. Version 1 of the API, served
+// under /api/v1 and, for the scripts written before versions existed,
+// under /api, is deprecated on the dates of api.
+func registerRoutes(router *gin.Engine, api config.API, uc *controllers.TaskController, lc *controllers.ListController, ac *controllers.AuthController, wc *controllers.WebhookController, oc *controllers.OpenAPIController) {
+	router.GET("/api/openapi.json", oc.Spec)
+	router.GET("/docs", oc.Docs)
+
+	authRoutes := router.Group("/api/auth", oc.ValidateRequest)
+	sessionRoutes := router.Group("/api/auth", ac.RequireAPIAuth, oc.ValidateRequest)
+	loginRoutes := router.Group("/view")
+	viewRoutes := router.Group("/view", ac.RequireViewAuth)
+	davRoutes := router.Group("/caldav", ac.RequireDAVAuth)
+
+	authRoutes.POST("/register", ac.Register)
+	authRoutes.POST("/login", ac.Login)
+	sessionRoutes.POST("/logout", ac.Logout)
+	sessionRoutes.GET("/me", ac.Me)
+
+	loginRoutes.GET("/login", ac.ShowLogin)
+	loginRoutes.POST("/login", ac.SubmitLogin)
+	loginRoutes.POST("/register", ac.SubmitRegister)
+	loginRoutes.POST("/logout", ac.SubmitLogout)
+
+	deprecated := controllers.Deprecate(time.Time(api.V1Deprecation), time.Time(api.V1Sunset))
+	registerV1Routes(router.Group("/api", deprecated, ac.RequireAPIAuth, oc.ValidateRequest), uc, lc, wc)
+	registerV1Routes(router.Group("/api/v1", deprecated, ac.RequireAPIAuth, oc.ValidateRequest), uc, lc, wc)
+	registerV2Routes(router.Group("/api/v2", ac.RequireAPIAuth, oc.ValidateRequest), uc, lc, wc)
+
+	// Calendar apps subscribe to the feed with Basic authentication, which
+	// the rest of the API does not take.
+	router.GET("/api/tasks.ics", deprecated, ac.RequireFeedAuth, oc.ValidateRequest, uc.TaskFeed)
+	router.GET("/api/v1/tasks.ics", deprecated, ac.RequireFeedAuth, oc.ValidateRequest, uc.TaskFeed)
+	router.GET("/api/v2/tasks.ics", ac.RequireFeedAuth, oc.ValidateRequest, uc.TaskFeed)
+
+	router.POST("/graphql", ac.RequireAPIAuth, oc.ValidateRequest, uc.GraphQL)
 
+	router.GET("/.well-known/caldav", uc.RedirectCalDAV)
+	router.Handle("PROPFIND", "/.well-known/caldav", uc.RedirectCalDAV)
+	davRoutes.OPTIONS("/", uc.CalDAVOptions)
+	davRoutes.Handle("PROPFIND", "/", uc.PropfindRoot)
+	davRoutes.OPTIONS("/tasks/", uc.CalDAVOptions)
+	davRoutes.Handle("PROPFIND", "/tasks/", uc.PropfindCalendar)
+	davRoutes.Handle("REPORT", "/tasks/", uc.ReportCalendar)
+	davRoutes.OPTIONS("/tasks/:name", uc.CalDAVOptions)
+	davRoutes.Handle("PROPFIND", "/tasks/:name", uc.PropfindCalendarObject)
+	davRoutes.GET("/tasks/:name", uc.GetCalendarObject)
+	davRoutes.PUT("/tasks/:name", uc.PutCalendarObject)
+	davRoutes.DELETE("/tasks/:name", uc.DeleteCalendarObject)
+
+	viewRoutes.GET("/tasks", uc.ShowAllTasks)
+	viewRoutes.GET("/lists/:id", uc.ShowList)
+}
+
+// registerV1Routes registers version 1 of the API, whose handlers answer
+// with models.Task.
+func registerV1Routes(apiRoutes *gin.RouterGroup, uc *controllers.TaskController, lc *controllers.ListController, wc *controllers.WebhookController) {
 	apiRoutes.POST("/task", uc.CreateTask)
 	apiRoutes.GET("/tasks", uc.GetTasks)
+	apiRoutes.GET("/tasks/today", uc.TodayTasks)
+	apiRoutes.GET("/tasks/overdue", uc.OverdueTasks)
+	apiRoutes.GET("/tasks/upcoming", uc.UpcomingTasks)
+	apiRoutes.PUT("/task/:id", uc.ReplaceTask)
+	apiRoutes.PATCH("/task/:id", uc.UpdateTask)
+	apiRoutes.POST("/task/:id/toggle", uc.ToggleTask)
+	apiRoutes.GET("/task/:id/children", uc.GetChildren)
+	apiRoutes.GET("/task/:id/occurrences", uc.GetOccurrences)
+	apiRoutes.GET("/task/:id/history", uc.GetHistory)
+	apiRoutes.POST("/task/:id/restore", uc.RestoreTask)
 	apiRoutes.DELETE("/task/:id", uc.DeleteTask)
 	apiRoutes.DELETE("/tasks", uc.DeleteAllTasks)
+	apiRoutes.POST("/tasks/undo", uc.UndoDeleteAll)
+	apiRoutes.POST("/tasks/batch", uc.BatchTasks)
+	apiRoutes.GET("/tasks/export", uc.ExportTasks)
+	apiRoutes.POST("/tasks/import", uc.ImportTasks)
+	apiRoutes.GET("/events", uc.StreamEvents)
 
-	viewRoutes.GET("/tasks", uc.ShowAllTasks)
+	apiRoutes.GET("/trash", uc.GetTrash)
+	apiRoutes.POST("/trash/:id/restore", uc.RestoreFromTrash)
+	apiRoutes.DELETE("/trash", uc.EmptyTrash)
+
+	registerListRoutes(apiRoutes, lc)
+	apiRoutes.GET("/lists/:id/tasks", uc.GetListTasks)
+	apiRoutes.POST("/lists/:id/tasks", uc.CreateListTask)
 
-	router.Run(":8080")
+	registerWebhookRoutes(apiRoutes, wc)
 }
 
-func getClient() *mongo.Client {
-	uri := os.Getenv("MONGODB_URI")
-	if uri == "" {
-		log.Fatal("Set your 'MONGODB_URI' environment variable")
+// registerV2Routes registers version 2 of the API, which shows tasks as
+// models.TaskV2 under consistently plural routes. It runs the handlers of
+// version 1 through the translations of controllers/v2.go.
+func registerV2Routes(apiRoutes *gin.RouterGroup, uc *controllers.TaskController, lc *controllers.ListController, wc *controllers.WebhookController) {
+	apiRoutes.GET("/tasks", controllers.TasksV2(uc.GetTasks))
+	apiRoutes.POST("/tasks", controllers.CreateV2(uc.CreateTask))
+	apiRoutes.GET("/tasks/today", controllers.TasksV2(uc.TodayTasks))
+	apiRoutes.GET("/tasks/overdue", controllers.TasksV2(uc.OverdueTasks))
+	apiRoutes.GET("/tasks/upcoming", controllers.TasksV2(uc.UpcomingTasks))
+	apiRoutes.GET("/tasks/:id", controllers.TaskV2(uc.GetTask))
+	apiRoutes.PUT("/tasks/:id", controllers.CreateV2(uc.ReplaceTask))
+	apiRoutes.PATCH("/tasks/:id", controllers.PatchV2(uc.UpdateTask))
+	apiRoutes.POST("/tasks/:id/toggle", controllers.TaskV2(uc.ToggleTask))
+	apiRoutes.GET("/tasks/:id/subtasks", controllers.TasksV2(uc.GetChildren))
+	apiRoutes.GET("/tasks/:id/occurrences", uc.GetOccurrences)
+	apiRoutes.GET("/tasks/:id/history", controllers.HistoryV2(uc.GetHistory))
+	apiRoutes.POST("/tasks/:id/restore", controllers.TaskV2(uc.RestoreTask))
+	apiRoutes.DELETE("/tasks/:id", uc.DeleteTask)
+	apiRoutes.DELETE("/tasks", uc.DeleteAllTasks)
+	apiRoutes.POST("/tasks/undo", uc.UndoDeleteAll)
+	apiRoutes.POST("/tasks/batch", controllers.BatchV2(uc.BatchTasks))
+	apiRoutes.GET("/tasks/export", uc.ExportTasks)
+	apiRoutes.POST("/tasks/import", uc.ImportTasks)
+	apiRoutes.GET("/events", uc.StreamEventsV2)
+
+	apiRoutes.GET("/trash", controllers.TasksV2(uc.GetTrash))
+	apiRoutes.POST("/trash/:id/restore", controllers.TaskV2(uc.RestoreFromTrash))
+	apiRoutes.DELETE("/trash", uc.EmptyTrash)
+
+	registerListRoutes(apiRoutes, lc)
+	apiRoutes.GET("/lists/:id/tasks", controllers.TasksV2(uc.GetListTasks))
+	apiRoutes.POST("/lists/:id/tasks", controllers.CreateV2(uc.CreateListTask))
+
+	registerWebhookRoutes(apiRoutes, wc)
+}
+
+// registerListRoutes registers the lists, which both versions share.
+func registerListRoutes(apiRoutes *gin.RouterGroup, lc *controllers.ListController) {
+	apiRoutes.GET("/lists", lc.GetLists)
+	apiRoutes.POST("/lists", lc.CreateList)
+	apiRoutes.GET("/lists/:id", lc.GetList)
+	apiRoutes.PUT("/lists/:id", lc.ReplaceList)
+	apiRoutes.DELETE("/lists/:id", lc.DeleteList)
+}
+
+// registerWebhookRoutes registers the webhooks, which both versions share.
+// Their payloads are always those of version 1.
+func registerWebhookRoutes(apiRoutes *gin.RouterGroup, wc *controllers.WebhookController) {
+	apiRoutes.GET("/webhooks", wc.GetWebhooks)
+	apiRoutes.POST("/webhooks", wc.CreateWebhook)
+	apiRoutes.GET("/webhooks/:id", wc.GetWebhook)
+	apiRoutes.PUT("/webhooks/:id", wc.ReplaceWebhook)
+	apiRoutes.DELETE("/webhooks/:id", wc.DeleteWebhook)
+	apiRoutes.GET("/webhooks/:id/deliveries", wc.GetDeliveries)
+}
+
+// serveGRPC runs the gRPC TaskService on addr, next to the router.
+func serveGRPC(uc *controllers.TaskController, ac *controllers.AuthController, addr string) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal("Failed to listen for gRPC on ", addr, ": ", err)
 	}
-	
-	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+
+	log.Println("Serving gRPC on", addr)
+	log.Fatal(controllers.NewGRPCServer(uc, ac).Serve(listener))
+}
+
+func getClient(cfg config.Config) *mongo.Client {
+	client, err := mongo.Connect(options.Client().ApplyURI(cfg.MongoDB.URI))
 	if err != nil {
 		panic(err)
 	}
 
 	// Sprawdź połączenie
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.RequestTimeout))
 	defer cancel()
 	
 	err = client.Ping(ctx, readpref.Primary())
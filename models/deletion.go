@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Deletion is a bulk delete of tasks, keyed by the SHA-256 hash of the
+// token handed to the client. It starts out pending, is carried out when
+// the client confirms it with the token, and can then be undone with the
+// same token until it expires.
+type Deletion struct {
+	TokenHash string         `bson:"_id"`
+	OwnerId   *bson.ObjectID `bson:"ownerId,omitempty"`
+	// TaskIds are the tasks matched when the deletion was requested; once
+	// confirmed, every task that went to the trash, subtasks included.
+	TaskIds []bson.ObjectID `bson:"taskIds"`
+	Policy  string          `bson:"policy"`
+	// DeletedAt is set once the deletion has been confirmed.
+	DeletedAt *time.Time `bson:"deletedAt,omitempty"`
+	// ExpiresAt ends the confirmation window and, once confirmed, the undo
+	// window.
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Event is a change to the tasks of a user, as GET /api/events streams it.
+type Event struct {
+	// Id is the position of the event in the stream, assigned by the
+	// repository. Clients send it back as Last-Event-ID to resume after it.
+	Id      string         `json:"id" bson:"-"`
+	OwnerId *bson.ObjectID `json:"-" bson:"ownerId,omitempty"`
+	// Name is the event, e.g. "task.created".
+	Name string `json:"event" bson:"name"`
+	// Data is the JSON payload, the same that webhooks receive.
+	Data json.RawMessage `json:"data" bson:"data"`
+	At   time.Time       `json:"at" bson:"at"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// History actions.
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionCompleted = "completed"
+	ActionReopened  = "reopened"
+	ActionDeleted   = "deleted"
+	ActionRestored  = "restored"
+	// ActionPurged marks a task removed for good, e.g. when the trash is
+	// emptied.
+	ActionPurged = "purged"
+)
+
+// HistoryEntry is one change to a task. Entries are append-only and
+// numbered from 1 per task.
+type HistoryEntry struct {
+	Id      bson.ObjectID  `json:"-" bson:"_id,omitempty"`
+	TaskId  bson.ObjectID  `json:"taskId" bson:"taskId"`
+	OwnerId *bson.ObjectID `json:"-" bson:"ownerId,omitempty"`
+	Version int            `json:"version" bson:"version"`
+	Action  string         `json:"action" bson:"action"`
+	// Actor is the user who made the change, if known.
+	Actor   *bson.ObjectID `json:"actor,omitempty" bson:"actor,omitempty"`
+	At      time.Time      `json:"at" bson:"at"`
+	Changes []FieldChange  `json:"changes,omitempty" bson:"changes,omitempty"`
+	// Task is the state right after the change; nil once purged.
+	Task *Task `json:"-" bson:"task,omitempty"`
+}
+
+// FieldChange holds the JSON values of one task field before and after a
+// change. A missing side means the field was unset.
+type FieldChange struct {
+	Field  string          `json:"field" bson:"field"`
+	Before json.RawMessage `json:"before,omitempty" bson:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+// computedFields are filled in per response and never differ in storage.
+var computedFields = map[string]bool{
+	"id":        true,
+	"overdue":   true,
+	"dueToday":  true,
+	"progress":  true,
+	"children":  true,
+	"next":      true,
+	"score":     true,
+	"highlight": true,
+}
+
+// DiffTasks lists the fields that differ between before and after, in
+// field name order. Either side may be nil.
+func DiffTasks(before, after *Task) []FieldChange {
+	beforeFields, afterFields := taskFields(before), taskFields(after)
+
+	names := []string{}
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+	for name := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, name := range names {
+		if !bytes.Equal(beforeFields[name], afterFields[name]) {
+			changes = append(changes, FieldChange{
+				Field:  name,
+				Before: beforeFields[name],
+				After:  afterFields[name],
+			})
+		}
+	}
+
+	return changes
+}
+
+func taskFields(task *Task) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	if task == nil {
+		return fields
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+
+	for name := range computedFields {
+		delete(fields, name)
+	}
+
+	return fields
+}
@@ -0,0 +1,11 @@
+package models
+
+import "go.mongodb.org/mongo-driver/v2/bson"
+
+// List is a named collection of tasks, e.g. a project. Tasks without a
+// list belong to the inbox.
+type List struct {
+	Id      bson.ObjectID  `json:"id" bson:"_id,omitempty"`
+	OwnerId *bson.ObjectID `json:"-" bson:"ownerId,omitempty"`
+	Name    string         `json:"name" bson:"name"`
+}
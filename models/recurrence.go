@@ -0,0 +1,142 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// Recurrence modes: what completing an occurrence of a recurring task does.
+const (
+	// RecurrenceGenerate keeps the completed task as done and creates the
+	// next occurrence as a new task.
+	RecurrenceGenerate = "generate"
+	// RecurrenceRoll reopens the same task with the next due date.
+	RecurrenceRoll = "roll"
+)
+
+var (
+	ErrInvalidRule     = errors.New("invalid recurrence rule")
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+	ErrInvalidMode     = errors.New("invalid recurrence mode")
+)
+
+// Recurrence repeats a task following an RFC 5545 RRULE, e.g.
+// "FREQ=WEEKLY;BYDAY=MO". Occurrences are computed on the wall clock of
+// TimeZone, so a task due at 09:00 stays at 09:00 across DST changes.
+type Recurrence struct {
+	Rule     string `json:"rule" bson:"rule"`
+	TimeZone string `json:"timeZone,omitempty" bson:"timeZone,omitempty"`
+	// Start anchors the series (the RRULE's DTSTART). It defaults to the
+	// task's due date and stays put as occurrences move forward, so COUNT
+	// and UNTIL keep counting from the first occurrence.
+	Start time.Time `json:"start" bson:"start"`
+	Mode  string    `json:"mode,omitempty" bson:"mode,omitempty"`
+}
+
+// Validate checks the rule, time zone and mode.
+func (r Recurrence) Validate() error {
+	if r.Mode != "" && r.Mode != RecurrenceGenerate && r.Mode != RecurrenceRoll {
+		return ErrInvalidMode
+	}
+
+	_, err := r.rule()
+	return err
+}
+
+// Next returns the first occurrence strictly after t, or false once the
+// rule is exhausted.
+func (r Recurrence) Next(t time.Time) (time.Time, bool) {
+	rule, err := r.rule()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	next := rule.After(t, false)
+	return next, !next.IsZero()
+}
+
+// Upcoming returns up to n occurrences from t onwards, t included.
+func (r Recurrence) Upcoming(t time.Time, n int) []time.Time {
+	occurrences := []time.Time{}
+
+	rule, err := r.rule()
+	if err != nil {
+		return occurrences
+	}
+
+	for next := rule.After(t, true); !next.IsZero() && len(occurrences) < n; next = rule.After(next, false) {
+		occurrences = append(occurrences, next)
+	}
+
+	return occurrences
+}
+
+func (r Recurrence) location() (*time.Location, error) {
+	if r.TimeZone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+
+	return loc, nil
+}
+
+func (r Recurrence) rule() (*rrule.RRule, error) {
+	loc, err := r.location()
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimPrefix(strings.TrimSpace(r.Rule), "RRULE:")
+	if text == "" || strings.ContainsAny(text, "\r\n") {
+		return nil, ErrInvalidRule
+	}
+
+	options, err := rrule.StrToROptionInLocation(text, loc)
+	if err != nil {
+		return nil, ErrInvalidRule
+	}
+	options.Dtstart = r.Start.In(loc).Truncate(time.Second)
+
+	rule, err := rrule.NewRRule(*options)
+	if err != nil {
+		return nil, ErrInvalidRule
+	}
+
+	return rule, nil
+}
+
+// From returns the recurrence anchored at the occurrence at instead of
+// Start, with the same occurrences from there on: a COUNT, which would
+// count again from at, becomes the UNTIL of the last occurrence.
+func (r Recurrence) From(at time.Time) Recurrence {
+	from := r
+	from.Start = at
+
+	rule, err := r.rule()
+	if err != nil || rule.OrigOptions.Count == 0 {
+		return from
+	}
+
+	occurrences := rule.All()
+	if len(occurrences) == 0 {
+		return from
+	}
+
+	parts := []string{}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(r.Rule), "RRULE:"), ";") {
+		if !strings.HasPrefix(strings.ToUpper(part), "COUNT=") {
+			parts = append(parts, part)
+		}
+	}
+	until := occurrences[len(occurrences)-1].UTC().Format("20060102T150405Z")
+	from.Rule = strings.Join(append(parts, "UNTIL="+until), ";")
+
+	return from
+}
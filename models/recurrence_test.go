@@ -0,0 +1,109 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrenceKeepsWallClockAcrossDST(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	require.NoError(t, err)
+
+	// Mondays at 09:00; clocks go forward on Sunday, March 30 2025.
+	start := time.Date(2025, 3, 24, 9, 0, 0, 0, warsaw)
+	recurrence := Recurrence{Rule: "FREQ=WEEKLY;BYDAY=MO", TimeZone: "Europe/Warsaw", Start: start}
+	require.NoError(t, recurrence.Validate())
+
+	next, ok := recurrence.Next(start)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 31, 9, 0, 0, 0, warsaw), next)
+	assert.Equal(t, 9, next.In(warsaw).Hour())
+	// One week later on the calendar is one hour less on the clock.
+	assert.Equal(t, 7*24*time.Hour-time.Hour, next.Sub(start))
+}
+
+func TestRecurrenceUpcomingHonoursCount(t *testing.T) {
+	start := time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)
+	recurrence := Recurrence{Rule: "RRULE:FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3", Start: start}
+
+	occurrences := recurrence.Upcoming(start, 10)
+	assert.Equal(t, []time.Time{
+		start,
+		time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 31, 10, 0, 0, 0, time.UTC),
+	}, occurrences)
+
+	_, ok := recurrence.Next(occurrences[2])
+	assert.False(t, ok)
+}
+
+func TestRecurrenceValidate(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.ErrorIs(t, Recurrence{Rule: "FREQ=SOMETIMES", Start: start}.Validate(), ErrInvalidRule)
+	assert.ErrorIs(t, Recurrence{Rule: "", Start: start}.Validate(), ErrInvalidRule)
+	assert.ErrorIs(t, Recurrence{Rule: "FREQ=DAILY", TimeZone: "Mars/Olympus", Start: start}.Validate(), ErrInvalidTimeZone)
+	assert.ErrorIs(t, Recurrence{Rule: "FREQ=DAILY", Mode: "sometimes", Start: start}.Validate(), ErrInvalidMode)
+	assert.NoError(t, Recurrence{Rule: "FREQ=DAILY;INTERVAL=2", Mode: RecurrenceRoll, Start: start}.Validate())
+}
+
+func TestAdvance(t *testing.T) {
+	due := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	now := due.Add(time.Hour)
+	newTask := func(mode string) Task {
+		return Task{
+			Description: "Water plants",
+			Completed:   true,
+			CompletedAt: &now,
+			DueAt:       &due,
+			Recurrence:  &Recurrence{Rule: "FREQ=DAILY", Start: due, Mode: mode},
+		}
+	}
+
+	// Generate mode: the done task stops repeating, the next one takes over.
+	task := newTask("")
+	next := task.Advance(now)
+	require.NotNil(t, next)
+	assert.True(t, task.Completed)
+	assert.Nil(t, task.Recurrence)
+	assert.False(t, next.Completed)
+	assert.Equal(t, due.AddDate(0, 0, 1), *next.DueAt)
+	assert.NotNil(t, next.Recurrence)
+	assert.Equal(t, "Water plants", next.Description)
+
+	// Roll mode: the same task reopens.
+	task = newTask(RecurrenceRoll)
+	assert.Nil(t, task.Advance(now))
+	assert.False(t, task.Completed)
+	assert.Nil(t, task.CompletedAt)
+	assert.Equal(t, due.AddDate(0, 0, 1), *task.DueAt)
+
+	// Late completions skip the occurrences that have already passed.
+	task = newTask("")
+	next = task.Advance(due.AddDate(0, 0, 3).Add(time.Minute))
+	require.NotNil(t, next)
+	assert.Equal(t, due.AddDate(0, 0, 4), *next.DueAt)
+
+	// Open tasks are left alone.
+	task = newTask("")
+	task.Completed = false
+	assert.Nil(t, task.Advance(now))
+	assert.NotNil(t, task.Recurrence)
+}
+
+func TestRecurrenceFrom(t *testing.T) {
+	start := time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)
+	recurrence := Recurrence{Rule: "RRULE:FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3", Start: start}
+	at := time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC)
+
+	from := recurrence.From(at)
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20250331T100000Z", from.Rule)
+	assert.Equal(t, at, from.Start)
+	assert.Equal(t, recurrence.Upcoming(at, 10), from.Upcoming(at, 10))
+
+	weekly := Recurrence{Rule: "FREQ=WEEKLY;BYDAY=MO", Start: start}
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", weekly.From(at).Rule)
+}
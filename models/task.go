@@ -1,13 +1,178 @@
 package models
 
-import "go.mongodb.org/mongo-driver/v2/bson"
+import (
+	"html/template"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
 
 type Task struct {
 	Id          bson.ObjectID `json:"id" bson:"_id,omitempty"`
-	Description string         `json:"description" bson:"description"`
+	Description string        `json:"description" bson:"description"`
+	// OwnerId is the user the task belongs to; never exposed over the API.
+	OwnerId *bson.ObjectID `json:"-" bson:"ownerId,omitempty"`
+	// ParentId makes the task a subtask; subtasks nest to any depth.
+	ParentId *bson.ObjectID `json:"parentId,omitempty" bson:"parentId,omitempty"`
+	// ListId is the list the task belongs to; nil means the inbox.
+	ListId      *bson.ObjectID `json:"listId,omitempty" bson:"listId,omitempty"`
+	Completed   bool           `json:"completed" bson:"completed"`
+	CompletedAt *time.Time     `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+	DueAt       *time.Time     `json:"dueAt,omitempty" bson:"dueAt,omitempty"`
+	// Priority ranks tasks from 1 (highest) downwards; 0 means none.
+	Priority int `json:"priority,omitempty" bson:"priority,omitempty"`
+	// Tags label the task; they are stored trimmed, lowercased and unique.
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	// Projects are the "+project" markers of a todo.txt line, as written.
+	Projects []string `json:"projects,omitempty" bson:"projects,omitempty"`
+	// Extensions are the key:value pairs of a todo.txt line that have no
+	// field of their own, in their original order.
+	Extensions []Extension `json:"extensions,omitempty" bson:"extensions,omitempty"`
+	// Recurrence repeats the task; it requires DueAt.
+	Recurrence *Recurrence `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	// Uid is the iCalendar UID of a task created by a calendar client;
+	// other tasks have one derived from Id.
+	Uid string `json:"uid,omitempty" bson:"uid,omitempty"`
+	// ResourceName is the CalDAV resource a calendar client created the
+	// task as; other tasks are served as "<id>.ics".
+	ResourceName string `json:"-" bson:"resourceName,omitempty"`
+	// DeletedAt is set while the task is in the trash.
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+
+	// Computed by the server on every response, never stored.
+	Overdue  bool `json:"overdue" bson:"-"`
+	DueToday bool `json:"dueToday" bson:"-"`
+
+	// Progress is set on tasks with subtasks; Children only in tree mode.
+	Progress *Progress `json:"progress,omitempty" bson:"-"`
+	Children []Task    `json:"children,omitempty" bson:"-"`
+	// Next is the occurrence generated by completing a recurring task.
+	Next *Task `json:"next,omitempty" bson:"-"`
+
+	// Set only on full-text search results.
+	Score     float64 `json:"score,omitempty" bson:"-"`
+	Highlight string  `json:"highlight,omitempty" bson:"-"`
+}
+
+// Extension is a key:value pair of a todo.txt line, e.g. "rec:1w".
+type Extension struct {
+	Key   string `json:"key" bson:"key"`
+	Value string `json:"value" bson:"value"`
+}
+
+// Progress counts the subtasks below a task at any depth, e.g. 3 of 5 done.
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// NormalizeTags trims and lowercases the tags, dropping empty and repeated
+// ones while keeping their order.
+func (t *Task) NormalizeTags() {
+	var tags []string
+	for _, tag := range t.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	t.Tags = tags
+}
+
+// SyncCompletion keeps CompletedAt consistent with Completed, stamping
+// now on tasks that were just marked done.
+func (t *Task) SyncCompletion(now time.Time) {
+	if !t.Completed {
+		t.CompletedAt = nil
+		return
+	}
+
+	if t.CompletedAt == nil {
+		t.CompletedAt = &now
+	}
+}
+
+// Advance moves a completed recurring task on to its next occurrence,
+// the first one after both its due date and now. In roll mode the task
+// itself is reopened with the new due date; otherwise it stays done, stops
+// repeating, and the returned task is the next occurrence to insert.
+// Tasks that are open, not recurring, or at the end of their series are
+// left alone apart from dropping an exhausted recurrence.
+func (t *Task) Advance(now time.Time) *Task {
+	if !t.Completed || t.Recurrence == nil || t.DueAt == nil {
+		return nil
+	}
+
+	after := *t.DueAt
+	if now.After(after) {
+		after = now
+	}
+
+	due, ok := t.Recurrence.Next(after)
+	if !ok {
+		t.Recurrence = nil
+		return nil
+	}
+
+	if t.Recurrence.Mode == RecurrenceRoll {
+		t.DueAt = &due
+		t.Completed = false
+		t.CompletedAt = nil
+		return nil
+	}
+
+	next := Task{
+		OwnerId:     t.OwnerId,
+		ParentId:    t.ParentId,
+		ListId:      t.ListId,
+		Description: t.Description,
+		DueAt:       &due,
+		Priority:    t.Priority,
+		Tags:        t.Tags,
+		Projects:    t.Projects,
+		Extensions:  t.Extensions,
+		Recurrence:  t.Recurrence,
+	}
+	t.Recurrence = nil
+
+	return &next
+}
+
+// ComputeDueFlags sets Overdue and DueToday relative to now. The calendar
+// day is taken in now's location.
+func (t *Task) ComputeDueFlags(now time.Time) {
+	t.Overdue = false
+	t.DueToday = false
+
+	if t.DueAt == nil {
+		return
+	}
+
+	start := StartOfDay(now)
+	due := t.DueAt.In(now.Location())
+
+	t.Overdue = !t.Completed && due.Before(now)
+	t.DueToday = !due.Before(start) && due.Before(start.AddDate(0, 0, 1))
+}
+
+// StartOfDay returns midnight of t's calendar day in t's location.
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
 }
 
 type ViewTask struct {
-    Id          string `json:"id"`
-    Description string `json:"description"`
-}
\ No newline at end of file
+	Id          string `json:"id"`
+	Description string `json:"description"`
+	Completed   bool   `json:"completed"`
+	DueAt       string `json:"dueAt,omitempty"`
+	Overdue     bool   `json:"overdue"`
+	DueToday    bool   `json:"dueToday"`
+	Recurring   bool   `json:"recurring"`
+	// Progress reads e.g. "3/5" on tasks with subtasks.
+	Progress string `json:"progress,omitempty"`
+	// Highlight is the escaped description with search matches marked.
+	Highlight template.HTML `json:"-"`
+}
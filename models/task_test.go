@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -89,3 +90,77 @@ func TestTaskToViewTaskConversion(t *testing.T) {
 	assert.Equal(t, task.Description, viewTask.Description)
 	assert.Len(t, viewTask.Id, 24) // ObjectID hex string length
 }
+
+func TestTaskSyncCompletion(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	task := Task{Description: "Test task", Completed: true}
+	task.SyncCompletion(now)
+	require.NotNil(t, task.CompletedAt)
+	assert.Equal(t, now, *task.CompletedAt)
+
+	// An existing completion time is kept
+	task.SyncCompletion(now.Add(time.Hour))
+	assert.Equal(t, now, *task.CompletedAt)
+
+	task.Completed = false
+	task.SyncCompletion(now)
+	assert.Nil(t, task.CompletedAt)
+}
+
+func TestTaskComputeDueFlags(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		due := now.Add(d)
+		return &due
+	}
+
+	tests := []struct {
+		name     string
+		task     Task
+		overdue  bool
+		dueToday bool
+	}{
+		{"no due date", Task{}, false, false},
+		{"earlier today", Task{DueAt: at(-time.Hour)}, true, true},
+		{"later today", Task{DueAt: at(time.Hour)}, false, true},
+		{"yesterday", Task{DueAt: at(-24 * time.Hour)}, true, false},
+		{"tomorrow", Task{DueAt: at(24 * time.Hour)}, false, false},
+		{"completed in the past", Task{DueAt: at(-time.Hour), Completed: true}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.task.ComputeDueFlags(now)
+			assert.Equal(t, tt.overdue, tt.task.Overdue)
+			assert.Equal(t, tt.dueToday, tt.task.DueToday)
+		})
+	}
+}
+
+func TestTaskDueTodayUsesNowLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	// 20:00 UTC on March 10 is already March 11 in Tokyo
+	due := time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC)
+	task := Task{DueAt: &due}
+
+	task.ComputeDueFlags(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	assert.True(t, task.DueToday)
+
+	task.ComputeDueFlags(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC).In(tokyo))
+	assert.False(t, task.DueToday)
+}
+
+func TestTaskDueAtJSON(t *testing.T) {
+	var task Task
+	err := json.Unmarshal([]byte(`{"description": "Test task", "dueAt": "2025-03-10T09:30:00+02:00"}`), &task)
+	require.NoError(t, err)
+	require.NotNil(t, task.DueAt)
+	assert.True(t, task.DueAt.Equal(time.Date(2025, 3, 10, 7, 30, 0, 0, time.UTC)))
+
+	jsonData, err := json.Marshal(Task{Description: "No due date"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(jsonData), "dueAt")
+}
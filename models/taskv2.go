@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// The statuses of a TaskV2.
+const (
+	StatusOpen = "open"
+	StatusDone = "done"
+)
+
+// TaskV2 is a task as version 2 of the API shows it. It is stored as a
+// Task: the description is its title, completion is a status, the due date
+// comes with the flags computed from it, and it links to the resources
+// related to it. Missing values are null and lists are never missing.
+type TaskV2 struct {
+	Id          bson.ObjectID `json:"id"`
+	Title       string        `json:"title"`
+	Status      string        `json:"status"`
+	CompletedAt *time.Time    `json:"completedAt"`
+	// CreatedAt is taken from the id.
+	CreatedAt  time.Time      `json:"createdAt"`
+	Due        *Due           `json:"due"`
+	Priority   *int           `json:"priority"`
+	Tags       []string       `json:"tags"`
+	Projects   []string       `json:"projects"`
+	Extensions []Extension    `json:"extensions"`
+	Recurrence *Recurrence    `json:"recurrence"`
+	ListId     *bson.ObjectID `json:"listId"`
+	ParentId   *bson.ObjectID `json:"parentId"`
+	Uid        string         `json:"uid,omitempty"`
+	DeletedAt  *time.Time     `json:"deletedAt,omitempty"`
+
+	Progress *Progress `json:"progress,omitempty"`
+	Subtasks []TaskV2  `json:"subtasks,omitempty"`
+	Next     *TaskV2   `json:"next,omitempty"`
+	// Match is set on search results.
+	Match *Match    `json:"match,omitempty"`
+	Links TaskLinks `json:"links"`
+}
+
+// Due is when a task is due. Overdue and Today are computed on every
+// response and ignored in requests.
+type Due struct {
+	At      time.Time `json:"at"`
+	Overdue bool      `json:"overdue"`
+	Today   bool      `json:"today"`
+}
+
+// Match is how a task matched a search.
+type Match struct {
+	Score float64 `json:"score"`
+	// Highlight is the matching part of the title, with the matches
+	// wrapped in <mark>.
+	Highlight string `json:"highlight"`
+}
+
+// TaskLinks are the URLs of the resources related to a task.
+type TaskLinks struct {
+	Self     string `json:"self"`
+	Subtasks string `json:"subtasks"`
+	History  string `json:"history"`
+	Parent   string `json:"parent,omitempty"`
+	List     string `json:"list,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type User struct {
+	Id           bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	Email        string        `json:"email" bson:"email"`
+	PasswordHash string        `json:"-" bson:"passwordHash"`
+	CreatedAt    time.Time     `json:"createdAt" bson:"createdAt"`
+}
+
+// Session is a logged-in user's token. Only the SHA-256 hash of the token
+// is stored; the token itself is handed to the client once.
+type Session struct {
+	TokenHash string        `json:"-" bson:"_id"`
+	UserId    bson.ObjectID `json:"userId" bson:"userId"`
+	ExpiresAt time.Time     `json:"expiresAt" bson:"expiresAt"`
+}
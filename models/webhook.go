@@ -0,0 +1,48 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Webhook subscribes a URL to task events. Payloads are signed with
+// Secret, which the API only reveals when the webhook is created.
+type Webhook struct {
+	Id      bson.ObjectID  `json:"id" bson:"_id,omitempty"`
+	OwnerId *bson.ObjectID `json:"-" bson:"ownerId,omitempty"`
+	URL     string         `json:"url" bson:"url"`
+	// Events are the events delivered, e.g. "task.created"; empty means
+	// all of them.
+	Events []string `json:"events" bson:"events"`
+	Secret string   `json:"secret,omitempty" bson:"secret"`
+	// Active is false once the webhook was disabled, by its owner or for
+	// failing too often.
+	Active bool `json:"active" bson:"active"`
+	// Failures counts the deliveries in a row that failed every attempt.
+	Failures   int        `json:"failures" bson:"failures"`
+	DisabledAt *time.Time `json:"disabledAt,omitempty" bson:"disabledAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"createdAt"`
+}
+
+// Subscribes reports whether the webhook delivers event.
+func (w Webhook) Subscribes(event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// Delivery logs one attempt at delivering an event to a webhook. Retries
+// of the same event share its EventId.
+type Delivery struct {
+	Id        bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	WebhookId bson.ObjectID `json:"webhookId" bson:"webhookId"`
+	EventId   string        `json:"eventId" bson:"eventId"`
+	Event     string        `json:"event" bson:"event"`
+	Attempt   int           `json:"attempt" bson:"attempt"`
+	At        time.Time     `json:"at" bson:"at"`
+	// StatusCode is the endpoint's response status, 0 when there was none.
+	StatusCode int    `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs int64  `json:"durationMs" bson:"durationMs"`
+	Succeeded  bool   `json:"succeeded" bson:"succeeded"`
+}
@@ -0,0 +1,443 @@
+// docs.js renders the OpenAPI document of the app, with a form to try each
+// operation against the running server.
+
+const methods = ['get', 'put', 'post', 'delete', 'options', 'head', 'patch']
+const nav = document.getElementById('docs_nav')
+const operationsEl = document.getElementById('docs_operations')
+const tokenField = document.getElementById('docs_token')
+
+let spec = {}
+
+// el creates an element with attributes and children; strings become text.
+function el(tag, attrs, ...children) {
+    const node = document.createElement(tag)
+    for (const [name, value] of Object.entries(attrs || {})) {
+        if (name.startsWith('on')) {
+            node.addEventListener(name.slice(2), value)
+        } else if (value !== undefined && value !== false) {
+            node.setAttribute(name, value === true ? '' : value)
+        }
+    }
+    for (const child of children.flat()) {
+        if (child !== undefined && child !== null) {
+            node.append(child)
+        }
+    }
+    return node
+}
+
+// resolve follows a local $ref such as "#/components/schemas/Task".
+function resolve(value) {
+    while (value && value.$ref) {
+        value = value.$ref.slice(2).split('/').reduce((node, key) => node[key], spec)
+    }
+    return value || {}
+}
+
+function refName(value) {
+    return value && value.$ref ? value.$ref.split('/').pop() : ''
+}
+
+// text renders the Markdown subset used in the document: paragraphs and
+// `code`.
+function text(markdown) {
+    const container = el('div', { class: 'docs-text' })
+    for (const paragraph of (markdown || '').split(/\n\s*\n/)) {
+        if (!paragraph.trim()) {
+            continue
+        }
+        const p = el('p')
+        paragraph.split('`').forEach((part, i) => {
+            p.append(i % 2 ? el('code', {}, part) : part)
+        })
+        container.append(p)
+    }
+    return container
+}
+
+// typeOf describes a schema in a few words, e.g. "array of Task".
+function typeOf(schema) {
+    const name = refName(schema)
+    const resolved = resolve(schema)
+    if (resolved.allOf && resolved.allOf.length === 1) {
+        return typeOf(resolved.allOf[0]) + (resolved.nullable ? ' or null' : '')
+    }
+    if (resolved.oneOf) {
+        return resolved.oneOf.map(typeOf).join(' or ')
+    }
+
+    let type = name || resolved.type || 'any'
+    if (!name && resolved.type === 'array') {
+        type = 'array of ' + typeOf(resolved.items)
+    } else if (!name && resolved.format) {
+        type += ' (' + resolved.format + ')'
+    }
+    if (!name && resolved.enum) {
+        type += ': ' + resolved.enum.filter((v) => v !== null).join(' | ')
+    }
+    if (resolved.nullable) {
+        type += ' or null'
+    }
+    return type
+}
+
+// schemaView shows the properties of a schema; nested schemas open on
+// demand so that recursive ones such as Task stay finite.
+function schemaView(schema) {
+    let resolved = resolve(schema)
+    if (resolved.allOf && resolved.allOf.length === 1) {
+        resolved = resolve(resolved.allOf[0])
+    }
+    if (resolved.type === 'array') {
+        return el('div', { class: 'docs-schema' }, el('p', {}, typeOf(schema)), nested(resolved.items))
+    }
+    if (resolved.oneOf) {
+        return el('div', { class: 'docs-schema' }, resolved.oneOf.map(schemaView))
+    }
+    if (!resolved.properties) {
+        return el('div', { class: 'docs-schema' }, el('p', {}, typeOf(schema)), text(resolved.description))
+    }
+
+    const required = resolved.required || []
+    const rows = Object.entries(resolved.properties).map(([name, property]) => el('tr', {},
+        el('td', {}, el('code', {}, name), required.includes(name) ? el('span', { class: 'docs-required' }, ' required') : ''),
+        el('td', {}, typeOf(property)),
+        el('td', {}, text(resolve(property).description || property.description), nested(property)),
+    ))
+
+    return el('div', { class: 'docs-schema' },
+        refName(schema) ? el('p', {}, el('strong', {}, refName(schema))) : '',
+        text(resolved.description),
+        el('table', {}, el('tbody', {}, rows)),
+    )
+}
+
+// nested offers the properties of an object schema behind a toggle.
+function nested(schema) {
+    let resolved = resolve(schema)
+    if (resolved.allOf && resolved.allOf.length === 1) {
+        schema = resolved.allOf[0]
+        resolved = resolve(schema)
+    }
+    if (resolved.type === 'array') {
+        return nested(resolved.items)
+    }
+    if (!resolved.properties) {
+        return ''
+    }
+
+    const details = el('details', {}, el('summary', {}, 'Properties'))
+    details.addEventListener('toggle', () => {
+        if (details.open && details.children.length === 1) {
+            details.append(schemaView(schema))
+        }
+    })
+    return details
+}
+
+// example builds a starting value for a request body: the required
+// properties of an object, or its first one.
+function example(schema, depth = 0) {
+    let resolved = resolve(schema)
+    if (resolved.allOf) {
+        resolved = resolve(resolved.allOf[0])
+    }
+    if (resolved.example !== undefined) {
+        return resolved.example
+    }
+    if (resolved.enum) {
+        return resolved.enum[0]
+    }
+
+    switch (resolved.type) {
+    case 'object': {
+        const value = {}
+        if (depth > 2 || !resolved.properties) {
+            return value
+        }
+        const names = resolved.required || Object.keys(resolved.properties).slice(0, 1)
+        for (const name of names) {
+            value[name] = example(resolved.properties[name], depth + 1)
+        }
+        return value
+    }
+    case 'array':
+        return []
+    case 'integer':
+    case 'number':
+        return resolved.minimum || 0
+    case 'boolean':
+        return false
+    case 'string':
+        return resolved.format === 'date-time' ? new Date().toISOString() : ''
+    default:
+        return null
+    }
+}
+
+// parametersOf merges the parameters of a path and of its operation.
+function parametersOf(pathItem, operation) {
+    const params = new Map()
+    for (const param of [...(pathItem.parameters || []), ...(operation.parameters || [])]) {
+        const resolved = resolve(param)
+        params.set(resolved.in + ':' + resolved.name, resolved)
+    }
+    return [...params.values()]
+}
+
+function parametersView(params) {
+    if (!params.length) {
+        return ''
+    }
+    return el('div', {},
+        el('h4', {}, 'Parameters'),
+        el('table', {}, el('tbody', {}, params.map((param) => el('tr', {},
+            el('td', {}, el('code', {}, param.name), param.required ? el('span', { class: 'docs-required' }, ' required') : ''),
+            el('td', {}, param.in),
+            el('td', {}, typeOf(param.schema)),
+            el('td', {}, text(param.description)),
+        )))),
+    )
+}
+
+function bodyView(requestBody) {
+    if (!requestBody) {
+        return ''
+    }
+    const body = resolve(requestBody)
+    return el('div', {},
+        el('h4', {}, 'Request body', body.required ? el('span', { class: 'docs-required' }, ' required') : ''),
+        text(body.description),
+        Object.entries(body.content || {}).map(([type, media]) => el('div', {},
+            el('p', { class: 'docs-media' }, type),
+            schemaView(media.schema || {}),
+        )),
+    )
+}
+
+function responsesView(responses) {
+    return el('div', {},
+        el('h4', {}, 'Responses'),
+        Object.entries(responses || {}).map(([code, response]) => {
+            response = resolve(response)
+            const content = Object.entries(response.content || {})
+            return el('div', { class: 'docs-response' },
+                el('p', {}, el('span', { class: 'docs-status docs-status-' + code[0] }, code), ' ', response.description || ''),
+                Object.keys(response.headers || {}).length
+                    ? el('p', { class: 'docs-media' }, 'Headers: ' + Object.keys(response.headers).join(', '))
+                    : '',
+                content.map(([type, media]) => el('details', {},
+                    el('summary', {}, type),
+                    schemaView(media.schema || {}),
+                )),
+            )
+        }),
+    )
+}
+
+// tryView is the form that sends the operation to the server.
+function tryView(path, method, params, requestBody) {
+    const inputs = params
+        .filter((param) => param.in !== 'cookie')
+        .map((param) => {
+            const schema = resolve(param.schema)
+            const input = schema.enum
+                ? el('select', {}, el('option', { value: '' }, ''), schema.enum.map((v) => el('option', { value: v }, v)))
+                : el('input', { type: 'text', placeholder: schema.default !== undefined ? String(schema.default) : '' })
+            return { param, input }
+        })
+
+    const body = resolve(requestBody)
+    const contentTypes = Object.keys(body.content || {})
+    const contentType = contentTypes[0]
+    let bodyInput
+    if (contentType === 'multipart/form-data') {
+        bodyInput = el('input', { type: 'file' })
+    } else if (contentType && contentType.endsWith('json')) {
+        bodyInput = el('textarea', { rows: 8, spellcheck: 'false' })
+        bodyInput.value = JSON.stringify(example(body.content[contentType].schema), null, 2)
+    } else if (contentType) {
+        bodyInput = el('textarea', { rows: 8, spellcheck: 'false' })
+    }
+
+    const output = el('pre', { class: 'docs-output' })
+    let controller
+    const button = el('button', { type: 'submit' }, 'Send')
+
+    const form = el('form', { class: 'docs-try' },
+        el('h4', {}, 'Try it'),
+        inputs.map(({ param, input }) => el('label', {},
+            el('span', {}, param.name, el('small', {}, ' ' + param.in)),
+            input,
+        )),
+        bodyInput ? el('label', {}, el('span', {}, contentType), bodyInput) : '',
+        button,
+        output,
+    )
+
+    form.addEventListener('submit', async (e) => {
+        e.preventDefault()
+        if (controller) {
+            controller.abort()
+            return
+        }
+
+        let url = path
+        const query = new URLSearchParams()
+        const headers = {}
+        for (const { param, input } of inputs) {
+            const value = input.value.trim()
+            if (param.in === 'path') {
+                url = url.replace('{' + param.name + '}', encodeURIComponent(value))
+            } else if (value && param.in === 'query') {
+                query.append(param.name, value)
+            } else if (value && param.in === 'header') {
+                headers[param.name] = value
+            }
+        }
+        if (query.toString()) {
+            url += '?' + query
+        }
+        if (tokenField.value) {
+            headers.Authorization = 'Bearer ' + tokenField.value
+        }
+
+        const options = { method: method.toUpperCase(), headers }
+        if (bodyInput && bodyInput.type === 'file') {
+            const data = new FormData()
+            if (bodyInput.files[0]) {
+                data.append('file', bodyInput.files[0])
+            }
+            options.body = data
+        } else if (bodyInput && bodyInput.value.trim()) {
+            headers['Content-Type'] = contentType
+            options.body = bodyInput.value
+        }
+
+        controller = new AbortController()
+        options.signal = controller.signal
+        button.textContent = 'Stop'
+        output.textContent = options.method + ' ' + url + '\n\n'
+        try {
+            const response = await fetch(url, options)
+            output.textContent += response.status + ' ' + response.statusText + '\n'
+            for (const [name, value] of response.headers) {
+                output.textContent += name + ': ' + value + '\n'
+            }
+            output.textContent += '\n'
+
+            const reader = response.body.getReader()
+            const decoder = new TextDecoder()
+            let raw = ''
+            for (;;) {
+                const { done, value } = await reader.read()
+                if (done) {
+                    break
+                }
+                const chunk = decoder.decode(value, { stream: true })
+                raw += chunk
+                output.textContent += chunk
+            }
+
+            if ((response.headers.get('Content-Type') || '').includes('json')) {
+                const head = output.textContent.slice(0, output.textContent.length - raw.length)
+                try {
+                    output.textContent = head + JSON.stringify(JSON.parse(raw), null, 2)
+                } catch (err) {
+                    // Leave the body as it came.
+                }
+            }
+        } catch (err) {
+            if (err.name !== 'AbortError') {
+                output.textContent += err.message
+            }
+        } finally {
+            controller = undefined
+            button.textContent = 'Send'
+        }
+    })
+
+    return form
+}
+
+function operationView(path, pathItem, method, operation) {
+    const params = parametersOf(pathItem, operation)
+    const id = operation.operationId || method + path
+    const classes = operation.deprecated ? 'docs-operation docs-deprecated' : 'docs-operation'
+    const details = el('details', { class: classes, id },
+        el('summary', {},
+            el('span', { class: 'docs-method docs-method-' + method }, method.toUpperCase()),
+            el('code', { class: 'docs-path' }, path),
+            el('span', { class: 'docs-summary' }, operation.summary || ''),
+        ),
+    )
+    // The contents are built when first opened.
+    details.addEventListener('toggle', () => {
+        if (details.open && details.children.length === 1) {
+            details.append(
+                text(operation.description),
+                parametersView(params),
+                bodyView(operation.requestBody),
+                responsesView(operation.responses),
+                tryView(path, method, params, operation.requestBody),
+            )
+        }
+    })
+    return details
+}
+
+function render() {
+    document.title = 'TODO - ' + spec.info.title
+    document.getElementById('docs_title').textContent = spec.info.title
+    document.getElementById('docs_version').textContent = 'Version ' + spec.info.version
+    document.getElementById('docs_description').append(text(spec.info.description))
+
+    const groups = new Map((spec.tags || []).map((tag) => [tag.name, { tag, operations: [] }]))
+    for (const [path, pathItem] of Object.entries(spec.paths)) {
+        for (const method of methods) {
+            const operation = pathItem[method]
+            if (!operation) {
+                continue
+            }
+            const name = (operation.tags || ['other'])[0]
+            if (!groups.has(name)) {
+                groups.set(name, { tag: { name }, operations: [] })
+            }
+            groups.get(name).operations.push(operationView(path, pathItem, method, operation))
+        }
+    }
+
+    operationsEl.replaceChildren()
+    for (const { tag, operations } of groups.values()) {
+        if (!operations.length) {
+            continue
+        }
+        nav.append(el('a', { href: '#tag-' + tag.name }, tag.name))
+        operationsEl.append(el('section', { id: 'tag-' + tag.name },
+            el('h2', {}, tag.name),
+            tag.description ? el('p', { class: 'docs-tag' }, tag.description) : '',
+            operations,
+        ))
+    }
+
+    // Open the operation linked to, e.g. /docs#createTask.
+    const linked = location.hash && document.getElementById(location.hash.slice(1))
+    if (linked && linked.tagName === 'DETAILS') {
+        linked.open = true
+        linked.scrollIntoView()
+    }
+}
+
+tokenField.value = sessionStorage.getItem('docsToken') || ''
+tokenField.addEventListener('change', () => sessionStorage.setItem('docsToken', tokenField.value))
+document.getElementById('docs_auth').addEventListener('submit', (e) => e.preventDefault())
+
+fetch('/api/openapi.json')
+    .then((response) => response.json())
+    .then((doc) => {
+        spec = doc
+        render()
+    })
+    .catch((err) => {
+        operationsEl.textContent = 'Could not load the API description: ' + err.message
+    })
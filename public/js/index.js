@@ -1,10 +1,24 @@
 const formInput = document.getElementById('form_input')
 const inputField = document.getElementById('input_field')
+const dueField = document.getElementById('due_field')
 const addButton = document.getElementById('add_button')
 const clearAllBtn = document.getElementById('clear_all_btn')
+const newListBtn = document.getElementById('new_list_btn')
+const deleteListBtn = document.getElementById('delete_list_btn')
+const listId = formInput.dataset.listId
 const todoList = document.getElementById('todo_list')
 const info = document.getElementsByClassName('info')
 
+// apiFetch calls the API with the session cookie and returns to the login
+// page once the session has expired.
+async function apiFetch(url, options) {
+    const response = await fetch(url, options)
+    if (response.status === 401) {
+        window.location.href = '/view/login'
+    }
+    return response
+}
+
 
 inputField.onkeyup = () => {
     let userData = inputField.value.trim()
@@ -18,33 +32,24 @@ inputField.onkeyup = () => {
 formInput.addEventListener('submit', async (e) => {
     e.preventDefault()
     const taskData = inputField.value
+    const due = dueField.value ? { at: new Date(dueField.value).toISOString() } : undefined
 
-    const response = await fetch("/api/task", {
+    const response = await apiFetch("/api/v2/tasks", {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
-            description: taskData
+            title: taskData,
+            due: due,
+            listId: listId && listId !== 'inbox' ? listId : undefined
         })
     })
 
     if (response.status === 201) {
         const data = await response.json()
-        
-        const taskElement = document.createElement('li')
-        const button = document.createElement('button')
-        const trashIcon = document.createElement('i')
-        
-        trashIcon.classList.add('fa', 'fa-trash')
-
-        button.appendChild(trashIcon)
-        button.setAttribute('id', data.id)
-        button.setAttribute('onclick', 'deleteItem(this.id)')
-
-        taskElement.appendChild(document.createTextNode(data.description))
-        taskElement.appendChild(button)
 
-        todoList.appendChild(taskElement)
+        placeTask(data)
         inputField.value = ""
+        dueField.value = ""
         addButton.classList.remove('active')
 
         getTasksAmountInfo()
@@ -55,17 +60,37 @@ formInput.addEventListener('submit', async (e) => {
     }         
 })
 
+// Clearing takes two calls: the first only counts the tasks and returns a
+// token, the second one deletes them once the user has confirmed.
 clearAllBtn.addEventListener('click', async () => {
-    const response = await fetch("/api/tasks", {
+    const scope = listId ? `?list=${listId}` : ''
+    const request = await apiFetch(`/api/v2/tasks${scope}`, {
+        method: 'DELETE'
+    })
+
+    if (request.status !== 202) {
+        info[0].textContent = "Unable to delete tasks."
+        return
+    }
+
+    const { token, count } = await request.json()
+    if (!count || !confirm(`Delete ${count} tasks? You can undo this for a few minutes.`)) {
+        return
+    }
+
+    const response = await apiFetch(`/api/v2/tasks?token=${encodeURIComponent(token)}`, {
         method: 'DELETE'
     })
 
     if (response.status === 200) {
+        const data = await response.json()
+
         while (todoList.firstChild) {
             todoList.removeChild(todoList.firstChild)
         }
 
         getTasksAmountInfo()
+        showUndo(token, new Date(data.undoUntil))
     }
     else {
         info[0].textContent = "Unable to delete tasks."
@@ -73,14 +98,79 @@ clearAllBtn.addEventListener('click', async () => {
 
 })
 
+// showUndo offers to bring back the cleared tasks until the undo window
+// closes.
+function showUndo(token, until) {
+    document.getElementById('undo_btn')?.remove()
+
+    const button = document.createElement('button')
+    button.id = 'undo_btn'
+    button.textContent = 'Undo'
+    button.addEventListener('click', async () => {
+        const response = await apiFetch(`/api/v2/tasks/undo?token=${encodeURIComponent(token)}`, {
+            method: 'POST'
+        })
+
+        if (response.status === 200) {
+            window.location.reload()
+        } else {
+            info[0].textContent = "Unable to undo."
+            button.remove()
+        }
+    })
+
+    clearAllBtn.before(button)
+    setTimeout(() => button.remove(), until - Date.now())
+}
+
+newListBtn.addEventListener('click', async () => {
+    const name = prompt('Name of the new list')
+    if (!name || !name.trim()) {
+        return
+    }
+
+    const response = await apiFetch("/api/v2/lists", {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+            name: name
+        })
+    })
+
+    if (response.status === 201) {
+        const data = await response.json()
+        window.location.href = `/view/lists/${data.id}`
+    } else {
+        info[0].textContent = "Unable to create list."
+    }
+})
+
+if (deleteListBtn) {
+    deleteListBtn.addEventListener('click', async () => {
+        if (!confirm('Delete this list? Its tasks will be moved to the inbox.')) {
+            return
+        }
+
+        const response = await apiFetch(`/api/v2/lists/${deleteListBtn.dataset.listId}`, {
+            method: 'DELETE'
+        })
+
+        if (response.status === 200) {
+            window.location.href = '/view/lists/inbox'
+        } else {
+            info[0].textContent = "Unable to delete list."
+        }
+    })
+}
+
 async function deleteItem(id) {
-    const response = await fetch(`/api/task/${id}`, {
+    const response = await apiFetch(`/api/v2/tasks/${id}`, {
          method: 'DELETE'
     })
 
     if (response.status === 200) {
-        const task = document.getElementById(id)
-        task.parentElement.remove()
+        removeTask(id)
+        removeEmptyGroups()
 
         getTasksAmountInfo()
     } else {
@@ -88,11 +178,261 @@ async function deleteItem(id) {
     }
 }
 
+function createTaskElement(data) {
+    const taskElement = document.createElement('li')
+    const toggle = document.createElement('input')
+    const button = document.createElement('button')
+    const trashIcon = document.createElement('i')
+
+    taskElement.classList.add('task')
+    if (data.due?.overdue) {
+        taskElement.classList.add('overdue')
+    }
+
+    toggle.type = 'checkbox'
+    toggle.classList.add('toggle')
+    toggle.dataset.id = data.id
+    toggle.checked = data.status === 'done'
+    if (data.status === 'done') {
+        taskElement.classList.add('completed')
+    }
+
+    trashIcon.classList.add('fa', 'fa-trash')
+
+    button.appendChild(trashIcon)
+    button.setAttribute('id', data.id)
+    button.setAttribute('onclick', 'deleteItem(this.id)')
+
+    taskElement.appendChild(toggle)
+    taskElement.appendChild(createDescription(data.id, data.title))
+    if (data.recurrence) {
+        const repeat = document.createElement('i')
+        repeat.classList.add('fa', 'fa-repeat', 'repeat')
+        repeat.title = 'Repeats'
+        taskElement.appendChild(repeat)
+    }
+    if (data.due) {
+        const due = document.createElement('span')
+        due.classList.add('due')
+        due.textContent = new Date(data.due.at).toLocaleString([], {
+            month: 'short', day: 'numeric', hour: '2-digit', minute: '2-digit'
+        })
+        taskElement.appendChild(due)
+    }
+    taskElement.appendChild(button)
+    return taskElement
+}
+
+const groupTitles = { overdue: 'Overdue', today: 'Today', later: 'Later' }
+
+function groupOf(data) {
+    if (data.due?.overdue) {
+        return 'overdue'
+    }
+    return data.due?.today ? 'today' : 'later'
+}
+
+function insertIntoGroup(taskElement, groupId) {
+    let header = document.getElementById(`group_${groupId}`)
+    if (!header) {
+        header = document.createElement('li')
+        header.classList.add('group-header')
+        header.id = `group_${groupId}`
+        header.textContent = groupTitles[groupId]
+
+        const order = Object.keys(groupTitles)
+        const next = order.slice(order.indexOf(groupId) + 1)
+            .map(id => document.getElementById(`group_${id}`))
+            .find(el => el)
+        todoList.insertBefore(header, next || null)
+    }
+
+    let sibling = header.nextElementSibling
+    while (sibling && !sibling.classList.contains('group-header')) {
+        sibling = sibling.nextElementSibling
+    }
+    todoList.insertBefore(taskElement, sibling)
+}
+
+// placeTask shows a task in its group, in place of the element showing it
+// already, if any: the change may have arrived as an event first.
+function placeTask(data) {
+    removeTask(data.id)
+    insertIntoGroup(createTaskElement(data), groupOf(data))
+}
+
+function removeTask(id) {
+    document.getElementById(id)?.parentElement.remove()
+}
+
+function removeEmptyGroups() {
+    todoList.querySelectorAll('.group-header').forEach(header => {
+        const next = header.nextElementSibling
+        if (!next || next.classList.contains('group-header')) {
+            header.remove()
+        }
+    })
+}
+
+function createDescription(id, text) {
+    const description = document.createElement('span')
+    description.classList.add('description')
+    description.dataset.id = id
+    description.title = 'Double-click to edit'
+    description.textContent = text
+    return description
+}
+
+todoList.addEventListener('change', async (e) => {
+    const toggle = e.target.closest('.toggle')
+    if (!toggle) {
+        return
+    }
+
+    const response = await apiFetch(`/api/v2/tasks/${toggle.dataset.id}/toggle`, {
+        method: 'POST'
+    })
+
+    if (response.status === 200) {
+        const data = await response.json()
+        const taskElement = toggle.parentElement
+
+        const done = data.status === 'done'
+
+        if (data.recurrence && toggle.checked && !done) {
+            // A rolling task reopened with its next due date
+            placeTask(data)
+            removeEmptyGroups()
+        } else {
+            toggle.checked = done
+            taskElement.classList.toggle('completed', done)
+        }
+
+        if (data.next) {
+            placeTask(data.next)
+        }
+
+        getTasksAmountInfo()
+    } else {
+        toggle.checked = !toggle.checked
+        info[0].textContent = "Unable to update task."
+    }
+})
+
+todoList.addEventListener('dblclick', (e) => {
+    const description = e.target.closest('.description')
+    if (!description || description.isContentEditable) {
+        return
+    }
+
+    description.dataset.original = description.textContent
+    description.contentEditable = 'true'
+    description.focus()
+    document.getSelection().selectAllChildren(description)
+})
+
+todoList.addEventListener('keydown', (e) => {
+    const description = e.target.closest('.description')
+    if (!description || !description.isContentEditable) {
+        return
+    }
+
+    if (e.key === 'Enter') {
+        e.preventDefault()
+        description.blur()
+    } else if (e.key === 'Escape') {
+        description.textContent = description.dataset.original
+        description.blur()
+    }
+})
+
+todoList.addEventListener('focusout', async (e) => {
+    const description = e.target.closest('.description')
+    if (!description || !description.isContentEditable) {
+        return
+    }
+
+    description.contentEditable = 'false'
+    const text = description.textContent.trim()
+    if (!text || text === description.dataset.original) {
+        description.textContent = description.dataset.original
+        return
+    }
+
+    const response = await apiFetch(`/api/v2/tasks/${description.dataset.id}`, {
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/merge-patch+json' },
+        body: JSON.stringify({
+            title: text
+        })
+    })
+
+    if (response.status === 200) {
+        const data = await response.json()
+        description.textContent = data.title
+    } else {
+        description.textContent = description.dataset.original
+        info[0].textContent = "Unable to update task."
+    }
+})
+
 function getTasksAmountInfo() {
-    const tasks = todoList.getElementsByTagName("li").length
+    const tasks = todoList.querySelectorAll("li.task").length
+    const pending = todoList.querySelectorAll("li.task:not(.completed)").length
     if (!tasks) {
         info[0].textContent = "No tasks available."
+    } else if (!pending) {
+        info[0].textContent = "All tasks completed."
     } else {
-        info[0].textContent = `You have ${tasks} pending tasks`
+        info[0].textContent = `You have ${pending} pending tasks`
+    }
+}
+
+// showsTask reports whether a task belongs on this page. Pages narrowed by
+// a search, filter or page number only keep the tasks they already show
+// up to date.
+function showsTask(data) {
+    if (window.location.search) {
+        return Boolean(document.getElementById(data.id))
+    }
+    if (listId === 'inbox') {
+        return !data.listId
+    }
+    return !listId || data.listId === listId
+}
+
+// applyEvent brings the page up to date with a change made elsewhere, in
+// another tab or by another client.
+function applyEvent(name, data) {
+    switch (name) {
+    case 'task.created':
+    case 'task.updated': {
+        const editing = document.getElementById(data.id)?.parentElement.querySelector('[contenteditable="true"]')
+        if (editing) {
+            return
+        }
+        if (showsTask(data)) {
+            placeTask(data)
+        } else {
+            removeTask(data.id)
+        }
+        break
+    }
+    case 'task.deleted':
+        removeTask(data.id)
+        break
+    case 'tasks.cleared':
+        data.ids.forEach(removeTask)
+        break
     }
-}
\ No newline at end of file
+
+    removeEmptyGroups()
+    getTasksAmountInfo()
+}
+
+const events = new EventSource('/api/v2/events')
+for (const name of ['task.created', 'task.updated', 'task.deleted', 'tasks.cleared']) {
+    events.addEventListener(name, (e) => applyEvent(name, JSON.parse(e.data)))
+}
+// Sent when the server no longer knows the events missed while offline.
+events.addEventListener('reset', () => window.location.reload())
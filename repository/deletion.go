@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"example.com/todo-rest-api/models"
+)
+
+// DeletionRepository is the storage used for bulk deletes awaiting
+// confirmation or undo, keyed by the hash of their token. Delete succeeds
+// only once per token, which is what makes each step single-use.
+type DeletionRepository interface {
+	Get(ctx context.Context, tokenHash string) (models.Deletion, error)
+	Insert(ctx context.Context, deletion models.Deletion) error
+	Delete(ctx context.Context, tokenHash string) error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"example.com/todo-rest-api/models"
+)
+
+// MemoryDeletionRepository keeps bulk deletes in process memory. It is
+// safe for concurrent use. Expired ones are left for the caller to reject.
+type MemoryDeletionRepository struct {
+	mu        sync.RWMutex
+	deletions map[string]models.Deletion
+}
+
+func NewMemoryDeletionRepository() *MemoryDeletionRepository {
+	return &MemoryDeletionRepository{
+		deletions: make(map[string]models.Deletion),
+	}
+}
+
+func (r *MemoryDeletionRepository) Get(ctx context.Context, tokenHash string) (models.Deletion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deletion, ok := r.deletions[tokenHash]
+	if !ok {
+		return models.Deletion{}, ErrNotFound
+	}
+
+	return deletion, nil
+}
+
+func (r *MemoryDeletionRepository) Insert(ctx context.Context, deletion models.Deletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.deletions[deletion.TokenHash]; exists {
+		return ErrDuplicateID
+	}
+
+	r.deletions[deletion.TokenHash] = deletion
+
+	return nil
+}
+
+func (r *MemoryDeletionRepository) Delete(ctx context.Context, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.deletions[tokenHash]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.deletions, tokenHash)
+
+	return nil
+}
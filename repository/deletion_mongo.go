@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoDeletionRepository stores bulk deletes in a MongoDB collection. A
+// TTL index removes them once they expire.
+type MongoDeletionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDeletionRepository(collection *mongo.Collection) *MongoDeletionRepository {
+	return &MongoDeletionRepository{collection: collection}
+}
+
+// EnsureIndexes creates the TTL index on expiresAt.
+func (r *MongoDeletionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *MongoDeletionRepository) Get(ctx context.Context, tokenHash string) (models.Deletion, error) {
+	var deletion models.Deletion
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": tokenHash}).Decode(&deletion)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return deletion, ErrNotFound
+	}
+
+	return deletion, err
+}
+
+func (r *MongoDeletionRepository) Insert(ctx context.Context, deletion models.Deletion) error {
+	_, err := r.collection.InsertOne(ctx, deletion)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateID
+	}
+
+	return err
+}
+
+func (r *MongoDeletionRepository) Delete(ctx context.Context, tokenHash string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": tokenHash})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrEventExpired is returned by EventRepository.Watch when the event to
+// resume after is unknown, or too old to resume after.
+var ErrEventExpired = errors.New("event expired")
+
+// EventRepository is the stream of task events, shared by every instance
+// using the same storage.
+type EventRepository interface {
+	// Append adds an event to the stream, assigning its Id.
+	Append(ctx context.Context, event models.Event) error
+	// Watch returns the events of owner, or of everyone when owner is nil,
+	// appended after the event with id after, or from now on when after is
+	// empty. The channel is closed once ctx is done or the stream breaks
+	// off; watching again after the last event received picks up where it
+	// stopped.
+	Watch(ctx context.Context, owner *bson.ObjectID, after string) (<-chan models.Event, error)
+}
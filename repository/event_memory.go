@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxMemoryEvents is how many events MemoryEventRepository keeps to resume
+// from.
+const maxMemoryEvents = 1000
+
+// MemoryEventRepository keeps the latest events in process memory, so it
+// only reaches the watchers of the same process. Event ids are sequence
+// numbers, starting over when the process restarts. It is safe for
+// concurrent use.
+type MemoryEventRepository struct {
+	mu     sync.Mutex
+	events []models.Event
+	// first is the sequence number of events[0].
+	first int64
+	// appended is closed, and replaced, whenever an event is appended.
+	appended chan struct{}
+}
+
+func NewMemoryEventRepository() *MemoryEventRepository {
+	return &MemoryEventRepository{
+		first:    1,
+		appended: make(chan struct{}),
+	}
+}
+
+func (r *MemoryEventRepository) Append(ctx context.Context, event models.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.Id = strconv.FormatInt(r.first+int64(len(r.events)), 10)
+	r.events = append(r.events, event)
+	if len(r.events) > maxMemoryEvents {
+		r.events = append([]models.Event(nil), r.events[1:]...)
+		r.first++
+	}
+
+	close(r.appended)
+	r.appended = make(chan struct{})
+
+	return nil
+}
+
+func (r *MemoryEventRepository) Watch(ctx context.Context, owner *bson.ObjectID, after string) (<-chan models.Event, error) {
+	r.mu.Lock()
+	next := r.first + int64(len(r.events))
+	if after != "" {
+		seq, err := strconv.ParseInt(after, 10, 64)
+		if err != nil || seq < r.first-1 || seq >= next {
+			r.mu.Unlock()
+			return nil, ErrEventExpired
+		}
+		next = seq + 1
+	}
+	r.mu.Unlock()
+
+	events := make(chan models.Event)
+	go func() {
+		defer close(events)
+
+		for {
+			pending, appended, ok := r.since(next)
+			if !ok {
+				// The watcher fell so far behind that events were dropped.
+				return
+			}
+
+			for _, event := range pending {
+				next++
+				if owner != nil && (event.OwnerId == nil || *event.OwnerId != *owner) {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if len(pending) == 0 {
+				select {
+				case <-appended:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// since returns the events from sequence number seq on, and the channel
+// closed when the next one is appended. It reports false when the event
+// at seq was dropped already.
+func (r *MemoryEventRepository) since(seq int64) ([]models.Event, chan struct{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if seq < r.first {
+		return nil, nil, false
+	}
+
+	pending := append([]models.Event(nil), r.events[seq-r.first:]...)
+	return pending, r.appended, true
+}
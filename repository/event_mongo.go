@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// eventRetention is how long events stay in their collection. Watchers
+// read them from the change stream, so this only bounds its size.
+const eventRetention = 24 * time.Hour
+
+// Server error codes of change streams that cannot be resumed.
+const (
+	codeInvalidResumeToken      = 260
+	codeChangeStreamFatal       = 280
+	codeChangeStreamHistoryLost = 286
+)
+
+// MongoEventRepository appends events to a MongoDB collection and watches
+// it through a change stream, so that every instance sees the events of
+// the others. Event ids are change stream resume tokens, which can be
+// resumed after as long as they are in the oplog. Change streams need a
+// replica set or a sharded cluster.
+type MongoEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoEventRepository(collection *mongo.Collection) *MongoEventRepository {
+	return &MongoEventRepository{collection: collection}
+}
+
+// EnsureIndexes creates the TTL index on at.
+func (r *MongoEventRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(eventRetention.Seconds())),
+	})
+	return err
+}
+
+func (r *MongoEventRepository) Append(ctx context.Context, event models.Event) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+func (r *MongoEventRepository) Watch(ctx context.Context, owner *bson.ObjectID, after string) (<-chan models.Event, error) {
+	match := bson.M{"operationType": "insert"}
+	if owner != nil {
+		match["fullDocument.ownerId"] = *owner
+	}
+
+	opts := options.ChangeStream()
+	if after != "" {
+		if _, err := hex.DecodeString(after); err != nil {
+			return nil, ErrEventExpired
+		}
+		opts.SetResumeAfter(bson.M{"_data": after})
+	}
+
+	stream, err := r.collection.Watch(ctx, mongo.Pipeline{{{Key: "$match", Value: match}}}, opts)
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && (serverErr.HasErrorCode(codeInvalidResumeToken) ||
+		serverErr.HasErrorCode(codeChangeStreamFatal) || serverErr.HasErrorCode(codeChangeStreamHistoryLost)) {
+		return nil, ErrEventExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan models.Event)
+	go func() {
+		defer close(events)
+		defer stream.Close(context.Background())
+
+		for stream.Next(ctx) {
+			var change struct {
+				FullDocument models.Event `bson:"fullDocument"`
+			}
+			if err := stream.Decode(&change); err != nil {
+				log.Println("Error decoding event:", err)
+				return
+			}
+
+			event := change.FullDocument
+			event.Id, _ = stream.ResumeToken().Lookup("_data").StringValueOK()
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			log.Println("Error watching events:", err)
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// HistoryRepository is the append-only storage of task changes.
+type HistoryRepository interface {
+	// Append stores entry as the next version of its task and returns it
+	// with Version set.
+	Append(ctx context.Context, entry models.HistoryEntry) (models.HistoryEntry, error)
+	// List returns the history of a task, oldest first.
+	List(ctx context.Context, taskID bson.ObjectID) ([]models.HistoryEntry, error)
+	Get(ctx context.Context, taskID bson.ObjectID, version int) (models.HistoryEntry, error)
+}
+
+type actorKey struct{}
+
+type actionKey struct{}
+
+// WithActor records actor as the user making the changes done with ctx.
+func WithActor(ctx context.Context, actor *bson.ObjectID) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithAction overrides the action recorded for changes done with ctx, e.g.
+// models.ActionRestored.
+func WithAction(ctx context.Context, action string) context.Context {
+	return context.WithValue(ctx, actionKey{}, action)
+}
+
+// HistoryTaskRepository wraps a TaskRepository and appends a history entry
+// for every task it creates, changes or deletes. Reads pass through.
+type HistoryTaskRepository struct {
+	TaskRepository
+	history HistoryRepository
+	now     func() time.Time
+}
+
+func NewHistoryTaskRepository(tasks TaskRepository, history HistoryRepository) *HistoryTaskRepository {
+	return &HistoryTaskRepository{
+		TaskRepository: tasks,
+		history:        history,
+		now:            time.Now,
+	}
+}
+
+func (r *HistoryTaskRepository) Insert(ctx context.Context, task models.Task) (models.Task, error) {
+	task, err := r.TaskRepository.Insert(ctx, task)
+	if err != nil {
+		return task, err
+	}
+
+	r.record(ctx, models.ActionCreated, nil, &task)
+
+	return task, nil
+}
+
+func (r *HistoryTaskRepository) Update(ctx context.Context, task models.Task) error {
+	before, err := r.TaskRepository.Get(ctx, task.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.TaskRepository.Update(ctx, task); err != nil {
+		return err
+	}
+
+	action := models.ActionUpdated
+	if task.Completed && !before.Completed {
+		action = models.ActionCompleted
+	} else if !task.Completed && before.Completed {
+		action = models.ActionReopened
+	}
+	r.record(ctx, action, &before, &task)
+
+	return nil
+}
+
+func (r *HistoryTaskRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	before, err := r.TaskRepository.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.TaskRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	r.record(ctx, models.ActionPurged, &before, nil)
+
+	return nil
+}
+
+func (r *HistoryTaskRepository) DeleteAll(ctx context.Context, filter TaskFilter) (int64, error) {
+	tasks, err := r.TaskRepository.List(ctx, filter, ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := r.TaskRepository.DeleteAll(ctx, filter)
+	if err != nil {
+		return deleted, err
+	}
+
+	for i := range tasks {
+		r.record(ctx, models.ActionPurged, &tasks[i], nil)
+	}
+
+	return deleted, nil
+}
+
+func (r *HistoryTaskRepository) SetList(ctx context.Context, filter TaskFilter, listID *bson.ObjectID) (int64, error) {
+	return r.updateAll(ctx, filter, models.ActionUpdated, func(task *models.Task) { task.ListId = listID }, func() (int64, error) {
+		return r.TaskRepository.SetList(ctx, filter, listID)
+	})
+}
+
+func (r *HistoryTaskRepository) SetParent(ctx context.Context, filter TaskFilter, parentID *bson.ObjectID) (int64, error) {
+	return r.updateAll(ctx, filter, models.ActionUpdated, func(task *models.Task) { task.ParentId = parentID }, func() (int64, error) {
+		return r.TaskRepository.SetParent(ctx, filter, parentID)
+	})
+}
+
+func (r *HistoryTaskRepository) SetDeleted(ctx context.Context, filter TaskFilter, deletedAt *time.Time) (int64, error) {
+	action := models.ActionDeleted
+	if deletedAt == nil {
+		action = models.ActionRestored
+	}
+
+	return r.updateAll(ctx, filter, action, func(task *models.Task) { task.DeletedAt = deletedAt }, func() (int64, error) {
+		return r.TaskRepository.SetDeleted(ctx, filter, deletedAt)
+	})
+}
+
+// updateAll runs a bulk update and records, for each task matching filter,
+// the change that apply makes to it.
+func (r *HistoryTaskRepository) updateAll(ctx context.Context, filter TaskFilter, action string, apply func(*models.Task), update func() (int64, error)) (int64, error) {
+	tasks, err := r.TaskRepository.List(ctx, filter, ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	updated, err := update()
+	if err != nil {
+		return updated, err
+	}
+
+	for _, before := range tasks {
+		after := before
+		apply(&after)
+		r.record(ctx, action, &before, &after)
+	}
+
+	return updated, nil
+}
+
+// record appends a history entry, unless nothing changed. Failures are
+// logged: the change itself has already been stored.
+func (r *HistoryTaskRepository) record(ctx context.Context, action string, before, after *models.Task) {
+	changes := models.DiffTasks(before, after)
+	if before != nil && after != nil && len(changes) == 0 {
+		return
+	}
+
+	if override, ok := ctx.Value(actionKey{}).(string); ok {
+		action = override
+	}
+
+	entry := models.HistoryEntry{
+		Action:  action,
+		At:      r.now().UTC(),
+		Changes: changes,
+	}
+	if actor, ok := ctx.Value(actorKey{}).(*bson.ObjectID); ok {
+		entry.Actor = actor
+	}
+
+	if after != nil {
+		snapshot := *after
+		entry.TaskId, entry.OwnerId, entry.Task = after.Id, after.OwnerId, &snapshot
+	} else {
+		entry.TaskId, entry.OwnerId = before.Id, before.OwnerId
+		entry.Changes = nil
+	}
+
+	if _, err := r.history.Append(ctx, entry); err != nil {
+		log.Println("Error recording task history:", err)
+	}
+}
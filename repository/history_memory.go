@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MemoryHistoryRepository keeps task history in process memory. It is safe
+// for concurrent use.
+type MemoryHistoryRepository struct {
+	mu      sync.RWMutex
+	entries map[bson.ObjectID][]models.HistoryEntry
+}
+
+func NewMemoryHistoryRepository() *MemoryHistoryRepository {
+	return &MemoryHistoryRepository{
+		entries: make(map[bson.ObjectID][]models.HistoryEntry),
+	}
+}
+
+func (r *MemoryHistoryRepository) Append(ctx context.Context, entry models.HistoryEntry) (models.HistoryEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.Id = bson.NewObjectID()
+	entry.Version = len(r.entries[entry.TaskId]) + 1
+	r.entries[entry.TaskId] = append(r.entries[entry.TaskId], entry)
+
+	return entry, nil
+}
+
+func (r *MemoryHistoryRepository) List(ctx context.Context, taskID bson.ObjectID) ([]models.HistoryEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]models.HistoryEntry, len(r.entries[taskID]))
+	copy(entries, r.entries[taskID])
+
+	return entries, nil
+}
+
+func (r *MemoryHistoryRepository) Get(ctx context.Context, taskID bson.ObjectID, version int) (models.HistoryEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := r.entries[taskID]
+	if version < 1 || version > len(entries) {
+		return models.HistoryEntry{}, ErrNotFound
+	}
+
+	return entries[version-1], nil
+}
+
+// snapshot copies the history and returns a function putting the copy back.
+func (r *MemoryHistoryRepository) snapshot() func() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make(map[bson.ObjectID][]models.HistoryEntry, len(r.entries))
+	for taskID, list := range r.entries {
+		entries[taskID] = slices.Clone(list)
+	}
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.entries = entries
+	}
+}
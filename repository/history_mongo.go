@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoHistoryRepository stores task history in a MongoDB collection, and
+// the last version number of each task in a second one.
+type MongoHistoryRepository struct {
+	collection *mongo.Collection
+	versions   *mongo.Collection
+}
+
+func NewMongoHistoryRepository(collection, versions *mongo.Collection) *MongoHistoryRepository {
+	return &MongoHistoryRepository{collection: collection, versions: versions}
+}
+
+// EnsureIndexes creates the unique index that numbers each task's history.
+func (r *MongoHistoryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "taskId", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// Append numbers entry with one atomic update of the task's counter, so
+// that concurrent appends never take the same version and an append in a
+// transaction has no duplicate key to fail it. The counter is raised past
+// the last stored version, for history written before there were counters.
+func (r *MongoHistoryRepository) Append(ctx context.Context, entry models.HistoryEntry) (models.HistoryEntry, error) {
+	var last models.HistoryEntry
+	err := r.collection.FindOne(ctx, bson.M{"taskId": entry.TaskId},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return entry, err
+	}
+
+	var counter struct {
+		Version int `bson:"version"`
+	}
+	next := bson.A{bson.M{"$set": bson.M{"version": bson.M{"$add": bson.A{
+		bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, last.Version}}, 1,
+	}}}}}
+	err = r.versions.FindOneAndUpdate(ctx, bson.M{"_id": entry.TaskId}, next,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&counter)
+	if err != nil {
+		return entry, err
+	}
+
+	entry.Id = bson.NewObjectID()
+	entry.Version = counter.Version
+	_, err = r.collection.InsertOne(ctx, entry)
+	return entry, err
+}
+
+func (r *MongoHistoryRepository) List(ctx context.Context, taskID bson.ObjectID) ([]models.HistoryEntry, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"taskId": taskID},
+		options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.HistoryEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (r *MongoHistoryRepository) Get(ctx context.Context, taskID bson.ObjectID, version int) (models.HistoryEntry, error) {
+	var entry models.HistoryEntry
+
+	err := r.collection.FindOne(ctx, bson.M{"taskId": taskID, "version": version}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return entry, ErrNotFound
+	}
+
+	return entry, err
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ListRepository is the storage used for task lists.
+type ListRepository interface {
+	// List returns the lists of owner, or all lists when owner is nil.
+	List(ctx context.Context, owner *bson.ObjectID) ([]models.List, error)
+	Get(ctx context.Context, id bson.ObjectID) (models.List, error)
+	Insert(ctx context.Context, list models.List) (models.List, error)
+	Update(ctx context.Context, list models.List) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+}
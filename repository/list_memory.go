@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MemoryListRepository keeps lists in process memory. It is safe for
+// concurrent use.
+type MemoryListRepository struct {
+	mu    sync.RWMutex
+	lists map[bson.ObjectID]models.List
+}
+
+func NewMemoryListRepository() *MemoryListRepository {
+	return &MemoryListRepository{
+		lists: make(map[bson.ObjectID]models.List),
+	}
+}
+
+func (r *MemoryListRepository) List(ctx context.Context, owner *bson.ObjectID) ([]models.List, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lists := make([]models.List, 0, len(r.lists))
+	for _, list := range r.lists {
+		if owner != nil && (list.OwnerId == nil || *list.OwnerId != *owner) {
+			continue
+		}
+		lists = append(lists, list)
+	}
+
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].Name != lists[j].Name {
+			return lists[i].Name < lists[j].Name
+		}
+		return lists[i].Id.Hex() < lists[j].Id.Hex()
+	})
+
+	return lists, nil
+}
+
+func (r *MemoryListRepository) Get(ctx context.Context, id bson.ObjectID) (models.List, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list, ok := r.lists[id]
+	if !ok {
+		return models.List{}, ErrNotFound
+	}
+
+	return list, nil
+}
+
+func (r *MemoryListRepository) Insert(ctx context.Context, list models.List) (models.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if list.Id.IsZero() {
+		list.Id = bson.NewObjectID()
+	}
+
+	if _, exists := r.lists[list.Id]; exists {
+		return list, ErrDuplicateID
+	}
+
+	r.lists[list.Id] = list
+
+	return list, nil
+}
+
+func (r *MemoryListRepository) Update(ctx context.Context, list models.List) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.lists[list.Id]; !ok {
+		return ErrNotFound
+	}
+
+	r.lists[list.Id] = list
+
+	return nil
+}
+
+func (r *MemoryListRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.lists[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.lists, id)
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoListRepository stores lists in a MongoDB collection.
+type MongoListRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoListRepository(collection *mongo.Collection) *MongoListRepository {
+	return &MongoListRepository{collection: collection}
+}
+
+func (r *MongoListRepository) List(ctx context.Context, owner *bson.ObjectID) ([]models.List, error) {
+	query := bson.M{}
+	if owner != nil {
+		query["ownerId"] = *owner
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	lists := []models.List{}
+	if err = cursor.All(ctx, &lists); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+func (r *MongoListRepository) Get(ctx context.Context, id bson.ObjectID) (models.List, error) {
+	var list models.List
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&list)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return list, ErrNotFound
+	}
+
+	return list, err
+}
+
+func (r *MongoListRepository) Insert(ctx context.Context, list models.List) (models.List, error) {
+	result, err := r.collection.InsertOne(ctx, list)
+	if mongo.IsDuplicateKeyError(err) {
+		return list, ErrDuplicateID
+	}
+	if err != nil {
+		return list, err
+	}
+
+	if oid, ok := result.InsertedID.(bson.ObjectID); ok {
+		list.Id = oid
+	}
+
+	return list, nil
+}
+
+func (r *MongoListRepository) Update(ctx context.Context, list models.List) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": list.Id}, list)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MongoListRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/search"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MemoryTaskRepository keeps tasks in process memory. It is safe for
+// concurrent use and preserves insertion order when listing.
+type MemoryTaskRepository struct {
+	mu    sync.RWMutex
+	order []bson.ObjectID
+	tasks map[bson.ObjectID]models.Task
+}
+
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{
+		tasks: make(map[bson.ObjectID]models.Task),
+	}
+}
+
+func (r *MemoryTaskRepository) List(ctx context.Context, filter TaskFilter, opts ListOptions) ([]models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	terms := search.Terms(filter.Text)
+
+	tasks := make([]models.Task, 0, len(r.order))
+	for _, id := range r.order {
+		if task := r.tasks[id]; filter.Matches(task) {
+			if len(terms) > 0 {
+				task.Score = search.Score(task.Description, terms)
+			}
+			tasks = append(tasks, task)
+		}
+	}
+
+	if opts.Descending {
+		slices.Reverse(tasks)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return lessTask(tasks[i], tasks[j], opts)
+	})
+
+	if opts.After != nil {
+		tasks = tasks[seek(tasks, *opts.After, opts):]
+	}
+	if opts.Before != nil {
+		end := seek(tasks, *opts.Before, opts)
+		if end > 0 && tasks[end-1].Id == opts.Before.Id {
+			end--
+		}
+		tasks = tasks[:end]
+		if opts.Limit > 0 && opts.Limit < len(tasks) {
+			tasks = tasks[len(tasks)-opts.Limit:]
+		}
+	}
+	if opts.Limit > 0 && opts.Limit < len(tasks) {
+		tasks = tasks[:opts.Limit]
+	}
+
+	return tasks, nil
+}
+
+// seek returns the index of the first of the sorted tasks that comes after
+// position. Ties in creation order are told apart by the task itself or,
+// once it is gone, by its id, which grows with the time of creation.
+func seek(tasks []models.Task, position Position, opts ListOptions) int {
+	for i, task := range tasks {
+		if task.Id == position.Id {
+			return i + 1
+		}
+	}
+
+	at := models.Task{
+		Id:          position.Id,
+		DueAt:       position.DueAt,
+		Priority:    position.Priority,
+		Description: position.Description,
+		Score:       position.Score,
+	}
+	return sort.Search(len(tasks), func(i int) bool {
+		if lessTask(at, tasks[i], opts) || lessTask(tasks[i], at, opts) {
+			return lessTask(at, tasks[i], opts)
+		}
+		order := bytes.Compare(tasks[i].Id[:], at.Id[:])
+		if opts.Descending {
+			return order < 0
+		}
+		return order > 0
+	})
+}
+
+func (r *MemoryTaskRepository) Count(ctx context.Context, filter TaskFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, task := range r.tasks {
+		if filter.Matches(task) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *MemoryTaskRepository) CountTags(ctx context.Context, filter TaskFilter) (map[string]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, task := range r.tasks {
+		if filter.Matches(task) {
+			for _, tag := range slices.Compact(slices.Sorted(slices.Values(task.Tags))) {
+				counts[tag]++
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+func (r *MemoryTaskRepository) Get(ctx context.Context, id bson.ObjectID) (models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return models.Task{}, ErrNotFound
+	}
+
+	return task, nil
+}
+
+func (r *MemoryTaskRepository) Insert(ctx context.Context, task models.Task) (models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if task.Id.IsZero() {
+		task.Id = bson.NewObjectID()
+	}
+
+	if _, exists := r.tasks[task.Id]; exists {
+		return task, ErrDuplicateID
+	}
+
+	r.tasks[task.Id] = task
+	r.order = append(r.order, task.Id)
+
+	return task, nil
+}
+
+func (r *MemoryTaskRepository) Update(ctx context.Context, task models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tasks[task.Id]; !ok {
+		return ErrNotFound
+	}
+
+	r.tasks[task.Id] = task
+
+	return nil
+}
+
+func (r *MemoryTaskRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tasks[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.tasks, id)
+	for i, existing := range r.order {
+		if existing == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryTaskRepository) DeleteAll(ctx context.Context, filter TaskFilter) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	kept := r.order[:0]
+	for _, id := range r.order {
+		if filter.Matches(r.tasks[id]) {
+			delete(r.tasks, id)
+			deleted++
+			continue
+		}
+		kept = append(kept, id)
+	}
+	r.order = kept
+
+	return deleted, nil
+}
+
+func (r *MemoryTaskRepository) SetList(ctx context.Context, filter TaskFilter, listID *bson.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var moved int64
+	for id, task := range r.tasks {
+		if filter.Matches(task) {
+			task.ListId = listID
+			r.tasks[id] = task
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+func (r *MemoryTaskRepository) SetParent(ctx context.Context, filter TaskFilter, parentID *bson.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var moved int64
+	for id, task := range r.tasks {
+		if filter.Matches(task) {
+			task.ParentId = parentID
+			r.tasks[id] = task
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+func (r *MemoryTaskRepository) SetDeleted(ctx context.Context, filter TaskFilter, deletedAt *time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var moved int64
+	for id, task := range r.tasks {
+		if filter.Matches(task) {
+			task.DeletedAt = deletedAt
+			r.tasks[id] = task
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+// lessTask orders a before b for opts.Sort. Equal keys report false so the
+// stable sort keeps creation order, which the caller has already reversed
+// for descending lists.
+func lessTask(a, b models.Task, opts ListOptions) bool {
+	switch opts.Sort {
+	case SortDueAt:
+		if a.DueAt == nil || b.DueAt == nil {
+			return a.DueAt != nil && b.DueAt == nil
+		}
+		if opts.Descending {
+			return a.DueAt.After(*b.DueAt)
+		}
+		return a.DueAt.Before(*b.DueAt)
+	case SortPriority:
+		if a.Priority <= 0 || b.Priority <= 0 {
+			return a.Priority > 0 && b.Priority <= 0
+		}
+		if opts.Descending {
+			return a.Priority > b.Priority
+		}
+		return a.Priority < b.Priority
+	case SortDescription:
+		if opts.Descending {
+			return a.Description > b.Description
+		}
+		return a.Description < b.Description
+	case SortRelevance:
+		if opts.Descending {
+			return a.Score < b.Score
+		}
+		return a.Score > b.Score
+	}
+
+	return false
+}
+
+// snapshot copies the tasks and returns a function putting the copy back.
+func (r *MemoryTaskRepository) snapshot() func() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order := slices.Clone(r.order)
+	tasks := maps.Clone(r.tasks)
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.order = order
+		r.tasks = tasks
+	}
+}
@@ -0,0 +1,477 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMemoryInsertAssignsID(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+
+	task, err := repo.Insert(context.Background(), models.Task{Description: "Test task"})
+	require.NoError(t, err)
+	assert.False(t, task.Id.IsZero())
+
+	stored, err := repo.Get(context.Background(), task.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Test task", stored.Description)
+}
+
+func TestMemoryInsertDuplicateID(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	task := models.Task{Id: bson.NewObjectID(), Description: "Test task"}
+
+	_, err := repo.Insert(context.Background(), task)
+	require.NoError(t, err)
+
+	_, err = repo.Insert(context.Background(), task)
+	assert.ErrorIs(t, err, ErrDuplicateID)
+}
+
+func TestMemoryListPreservesInsertionOrder(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	for _, description := range []string{"First", "Second", "Third"} {
+		_, err := repo.Insert(ctx, models.Task{Description: description})
+		require.NoError(t, err)
+	}
+
+	tasks, err := repo.List(ctx, TaskFilter{}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+	assert.Equal(t, "First", tasks[0].Description)
+	assert.Equal(t, "Second", tasks[1].Description)
+	assert.Equal(t, "Third", tasks[2].Description)
+}
+
+func TestMemoryUpdate(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	task, err := repo.Insert(ctx, models.Task{Description: "Before"})
+	require.NoError(t, err)
+
+	task.Description = "After"
+	require.NoError(t, repo.Update(ctx, task))
+
+	stored, err := repo.Get(ctx, task.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "After", stored.Description)
+
+	err = repo.Update(ctx, models.Task{Id: bson.NewObjectID()})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryDelete(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	task, err := repo.Insert(ctx, models.Task{Description: "Task to delete"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, task.Id))
+	assert.ErrorIs(t, repo.Delete(ctx, task.Id), ErrNotFound)
+
+	_, err = repo.Get(ctx, task.Id)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	tasks, err := repo.List(ctx, TaskFilter{}, ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+func TestMemoryDeleteAll(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := repo.Insert(ctx, models.Task{Description: "Task"})
+		require.NoError(t, err)
+	}
+
+	deleted, err := repo.DeleteAll(ctx, TaskFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	tasks, err := repo.List(ctx, TaskFilter{}, ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+func TestMemoryConcurrentInserts(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Insert(ctx, models.Task{Description: "Concurrent task"})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	tasks, err := repo.List(ctx, TaskFilter{}, ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, tasks, 50)
+}
+
+func TestMemoryListFilter(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	yesterday := now.Add(-24 * time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+
+	for _, task := range []models.Task{
+		{Description: "Yesterday", DueAt: &yesterday},
+		{Description: "Tomorrow", DueAt: &tomorrow, Completed: true},
+		{Description: "No due date"},
+	} {
+		_, err := repo.Insert(ctx, task)
+		require.NoError(t, err)
+	}
+
+	completed := true
+	tasks, err := repo.List(ctx, TaskFilter{Completed: &completed}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Tomorrow", tasks[0].Description)
+
+	tasks, err = repo.List(ctx, TaskFilter{DueBefore: &now}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Yesterday", tasks[0].Description)
+
+	tasks, err = repo.List(ctx, TaskFilter{DueFrom: &yesterday, DueBefore: &tomorrow}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Yesterday", tasks[0].Description)
+}
+
+func TestMemoryListSortAndPage(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	inserted := map[string]models.Task{}
+	for _, task := range []models.Task{
+		{Description: "c", Priority: 3},
+		{Description: "a", DueAt: &later},
+		{Description: "d"},
+		{Description: "b", DueAt: &now, Priority: 1},
+	} {
+		task, err := repo.Insert(ctx, task)
+		require.NoError(t, err)
+		inserted[task.Description] = task
+	}
+	position := func(description string, field SortField) *Position {
+		position := PositionOf(inserted[description], field)
+		return &position
+	}
+
+	descriptions := func(opts ListOptions) []string {
+		tasks, err := repo.List(ctx, TaskFilter{}, opts)
+		require.NoError(t, err)
+
+		result := []string{}
+		for _, task := range tasks {
+			result = append(result, task.Description)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"c", "a", "d", "b"}, descriptions(ListOptions{}))
+	assert.Equal(t, []string{"b", "d", "a", "c"}, descriptions(ListOptions{Descending: true}))
+	assert.Equal(t, []string{"b", "a", "c", "d"}, descriptions(ListOptions{Sort: SortDueAt}))
+	assert.Equal(t, []string{"a", "b", "d", "c"}, descriptions(ListOptions{Sort: SortDueAt, Descending: true}))
+	assert.Equal(t, []string{"b", "c", "a", "d"}, descriptions(ListOptions{Sort: SortPriority}))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, descriptions(ListOptions{Sort: SortDescription}))
+	assert.Equal(t, []string{"b", "c"}, descriptions(ListOptions{Sort: SortDescription, After: position("a", SortDescription), Limit: 2}))
+	assert.Equal(t, []string{"b", "c"}, descriptions(ListOptions{Sort: SortDescription, Before: position("d", SortDescription), Limit: 2}))
+	assert.Equal(t, []string{"d"}, descriptions(ListOptions{Sort: SortPriority, After: position("a", SortPriority)}))
+	assert.Equal(t, []string{"a", "b"}, descriptions(ListOptions{Sort: SortDueAt, Descending: true, Before: position("d", SortDueAt)}))
+	assert.Equal(t, []string{}, descriptions(ListOptions{After: position("b", SortCreated)}))
+
+	count, err := repo.Count(ctx, TaskFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	// A page resumes where it left off even once its last task is gone.
+	require.NoError(t, repo.Delete(ctx, inserted["b"].Id))
+	assert.Equal(t, []string{"c", "d"}, descriptions(ListOptions{Sort: SortDescription, After: position("b", SortDescription)}))
+	assert.Equal(t, []string{"a"}, descriptions(ListOptions{Sort: SortDescription, Before: position("b", SortDescription)}))
+	assert.Equal(t, []string{"c", "a", "d"}, descriptions(ListOptions{Sort: SortPriority, After: position("b", SortPriority)}))
+}
+
+func TestMemoryDeleteAllAndSetListWithFilter(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	listID := bson.NewObjectID()
+	otherID := bson.NewObjectID()
+	for _, task := range []models.Task{
+		{Description: "In list", ListId: &listID},
+		{Description: "Also in list", ListId: &listID},
+		{Description: "Inbox"},
+	} {
+		_, err := repo.Insert(ctx, task)
+		require.NoError(t, err)
+	}
+
+	moved, err := repo.SetList(ctx, TaskFilter{List: &listID}, &otherID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), moved)
+
+	tasks, err := repo.List(ctx, TaskFilter{Inbox: true}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Inbox", tasks[0].Description)
+
+	deleted, err := repo.DeleteAll(ctx, TaskFilter{List: &otherID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	tasks, err = repo.List(ctx, TaskFilter{}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Inbox", tasks[0].Description)
+}
+
+func TestMemoryUserEmailIsUnique(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	user, err := repo.Insert(ctx, models.User{Email: "ada@example.com"})
+	require.NoError(t, err)
+	assert.False(t, user.Id.IsZero())
+
+	_, err = repo.Insert(ctx, models.User{Email: "ada@example.com"})
+	assert.ErrorIs(t, err, ErrDuplicateEmail)
+
+	stored, err := repo.GetByEmail(ctx, "ada@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.Id, stored.Id)
+
+	_, err = repo.GetByEmail(ctx, "bob@example.com")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryListOwnerFilter(t *testing.T) {
+	tasks := NewMemoryTaskRepository()
+	lists := NewMemoryListRepository()
+	ctx := context.Background()
+
+	ada, bob := bson.NewObjectID(), bson.NewObjectID()
+	for _, owner := range []*bson.ObjectID{&ada, &bob, nil} {
+		_, err := tasks.Insert(ctx, models.Task{Description: "Task", OwnerId: owner})
+		require.NoError(t, err)
+		_, err = lists.Insert(ctx, models.List{Name: "List", OwnerId: owner})
+		require.NoError(t, err)
+	}
+
+	owned, err := tasks.List(ctx, TaskFilter{Owner: &ada}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	assert.Equal(t, ada, *owned[0].OwnerId)
+
+	ownedLists, err := lists.List(ctx, &bob)
+	require.NoError(t, err)
+	require.Len(t, ownedLists, 1)
+	assert.Equal(t, bob, *ownedLists[0].OwnerId)
+
+	allLists, err := lists.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, allLists, 3)
+}
+
+func TestMemoryCountTags(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	ada, bob := bson.NewObjectID(), bson.NewObjectID()
+	now := time.Now()
+	for _, task := range []models.Task{
+		{Description: "a", OwnerId: &ada, Tags: []string{"home", "errand", "home"}},
+		{Description: "b", OwnerId: &ada, Tags: []string{"home"}},
+		{Description: "c", OwnerId: &ada, Tags: []string{"work"}, DeletedAt: &now},
+		{Description: "d", OwnerId: &bob, Tags: []string{"home"}},
+	} {
+		_, err := repo.Insert(ctx, task)
+		require.NoError(t, err)
+	}
+
+	counts, err := repo.CountTags(ctx, TaskFilter{Owner: &ada})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"home": 2, "errand": 1}, counts)
+}
+
+func TestMemoryParentFilterAndSetParent(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	parent, err := repo.Insert(ctx, models.Task{Description: "Parent"})
+	require.NoError(t, err)
+	child, err := repo.Insert(ctx, models.Task{Description: "Child", ParentId: &parent.Id})
+	require.NoError(t, err)
+
+	tasks, err := repo.List(ctx, TaskFilter{Parents: []bson.ObjectID{parent.Id}}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, child.Id, tasks[0].Id)
+
+	tasks, err = repo.List(ctx, TaskFilter{TopLevel: true}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, parent.Id, tasks[0].Id)
+
+	tasks, err = repo.List(ctx, TaskFilter{Ids: []bson.ObjectID{}}, ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	moved, err := repo.SetParent(ctx, TaskFilter{Ids: []bson.ObjectID{child.Id}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), moved)
+
+	count, err := repo.Count(ctx, TaskFilter{TopLevel: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestHistoryTaskRepositoryRecordsChanges(t *testing.T) {
+	history := NewMemoryHistoryRepository()
+	repo := NewHistoryTaskRepository(NewMemoryTaskRepository(), history)
+	actor := bson.NewObjectID()
+	ctx := WithActor(context.Background(), &actor)
+
+	task, err := repo.Insert(ctx, models.Task{Description: "Task"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Update(ctx, task))
+	task.Completed = true
+	require.NoError(t, repo.Update(ctx, task))
+
+	listID := bson.NewObjectID()
+	_, err = repo.SetList(ctx, TaskFilter{}, &listID)
+	require.NoError(t, err)
+
+	_, err = repo.DeleteAll(ctx, TaskFilter{})
+	require.NoError(t, err)
+
+	entries, err := history.List(ctx, task.Id)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	assert.Equal(t, models.ActionCreated, entries[0].Action)
+	assert.Equal(t, models.ActionCompleted, entries[1].Action)
+	assert.Equal(t, models.ActionUpdated, entries[2].Action)
+	assert.Equal(t, "listId", entries[2].Changes[0].Field)
+	assert.Equal(t, models.ActionPurged, entries[3].Action)
+	assert.Nil(t, entries[3].Task)
+	assert.Equal(t, &actor, entries[3].Actor)
+
+	entry, err := history.Get(ctx, task.Id, 2)
+	require.NoError(t, err)
+	assert.True(t, entry.Task.Completed)
+
+	_, err = history.Get(ctx, task.Id, 5)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryTrash(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-48 * time.Hour)
+
+	kept, err := repo.Insert(ctx, models.Task{Description: "Kept"})
+	require.NoError(t, err)
+	old, err := repo.Insert(ctx, models.Task{Description: "Old", DeletedAt: &earlier})
+	require.NoError(t, err)
+	_, err = repo.Insert(ctx, models.Task{Description: "Recent", DeletedAt: &now})
+	require.NoError(t, err)
+
+	tasks, err := repo.List(ctx, TaskFilter{}, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, kept.Id, tasks[0].Id)
+
+	trashed, err := repo.Count(ctx, TaskFilter{Trashed: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), trashed)
+
+	cutoff := now.Add(-24 * time.Hour)
+	purged, err := repo.DeleteAll(ctx, TaskFilter{Trashed: true, DeletedBefore: &cutoff})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = repo.Get(ctx, old.Id)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	restored, err := repo.SetDeleted(ctx, TaskFilter{Trashed: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), restored)
+
+	count, err := repo.Count(ctx, TaskFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestMemoryEventsWatch(t *testing.T) {
+	repo := NewMemoryEventRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	alice, bob := bson.NewObjectID(), bson.NewObjectID()
+	publish := func(owner bson.ObjectID, name string) {
+		require.NoError(t, repo.Append(ctx, models.Event{OwnerId: &owner, Name: name, Data: []byte("{}")}))
+	}
+	receive := func(events <-chan models.Event) models.Event {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("no event received")
+			return models.Event{}
+		}
+	}
+
+	publish(alice, "task.created")
+
+	live, err := repo.Watch(ctx, &alice, "")
+	require.NoError(t, err)
+
+	publish(bob, "task.created")
+	publish(alice, "task.updated")
+	updated := receive(live)
+	assert.Equal(t, "task.updated", updated.Name)
+	assert.Equal(t, "3", updated.Id)
+
+	// Resuming replays what came after, for the owner only.
+	resumed, err := repo.Watch(ctx, &alice, "0")
+	require.NoError(t, err)
+	assert.Equal(t, "task.created", receive(resumed).Name)
+	assert.Equal(t, updated.Id, receive(resumed).Id)
+
+	for _, after := range []string{"4", "-1", "nope"} {
+		_, err = repo.Watch(ctx, &alice, after)
+		assert.ErrorIs(t, err, ErrEventExpired, after)
+	}
+
+	cancel()
+	_, ok := <-live
+	assert.False(t, ok)
+}
@@ -0,0 +1,390 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoTaskRepository stores tasks in a MongoDB collection.
+type MongoTaskRepository struct {
+	collection *mongo.Collection
+}
+
+// scoredTask decodes a task together with its text search score.
+type scoredTask struct {
+	models.Task `bson:",inline"`
+	Score       float64 `bson:"_score"`
+}
+
+func NewMongoTaskRepository(collection *mongo.Collection) *MongoTaskRepository {
+	return &MongoTaskRepository{collection: collection}
+}
+
+// EnsureIndexes creates the indexes the queries rely on, including the
+// text index used for full-text search.
+func (r *MongoTaskRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "description", Value: "text"}},
+			Options: options.Index().SetName("description_text"),
+		},
+		{
+			Keys: bson.D{{Key: "ownerId", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "parentId", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "deletedAt", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "tags", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "resourceName", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "uid", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	})
+	return err
+}
+
+func (r *MongoTaskRepository) List(ctx context.Context, filter TaskFilter, opts ListOptions) ([]models.Task, error) {
+	cursor, err := r.collection.Aggregate(ctx, listPipeline(mongoFilter(filter), filter.Text != "", opts))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []scoredTask
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]models.Task, 0, len(results))
+	for _, result := range results {
+		result.Task.Score = result.Score
+		tasks = append(tasks, result.Task)
+	}
+	if opts.Before != nil {
+		slices.Reverse(tasks)
+	}
+
+	return tasks, nil
+}
+
+func (r *MongoTaskRepository) Count(ctx context.Context, filter TaskFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, mongoFilter(filter))
+}
+
+func (r *MongoTaskRepository) CountTags(ctx context.Context, filter TaskFilter) (map[string]int64, error) {
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: mongoFilter(filter)}},
+		// A tag given twice still counts the task once.
+		{{Key: "$project", Value: bson.M{"tags": bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$tags", bson.A{}}}}}}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Tag   string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(results))
+	for _, result := range results {
+		counts[result.Tag] = result.Count
+	}
+
+	return counts, nil
+}
+
+func (r *MongoTaskRepository) Get(ctx context.Context, id bson.ObjectID) (models.Task, error) {
+	var task models.Task
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&task)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return task, ErrNotFound
+	}
+
+	return task, err
+}
+
+func (r *MongoTaskRepository) Insert(ctx context.Context, task models.Task) (models.Task, error) {
+	result, err := r.collection.InsertOne(ctx, task)
+	if mongo.IsDuplicateKeyError(err) {
+		return task, ErrDuplicateID
+	}
+	if err != nil {
+		return task, err
+	}
+
+	if oid, ok := result.InsertedID.(bson.ObjectID); ok {
+		task.Id = oid
+	}
+
+	return task, nil
+}
+
+func (r *MongoTaskRepository) Update(ctx context.Context, task models.Task) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": task.Id}, task)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MongoTaskRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MongoTaskRepository) DeleteAll(ctx context.Context, filter TaskFilter) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, mongoFilter(filter))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+func (r *MongoTaskRepository) SetList(ctx context.Context, filter TaskFilter, listID *bson.ObjectID) (int64, error) {
+	update := bson.M{"$unset": bson.M{"listId": ""}}
+	if listID != nil {
+		update = bson.M{"$set": bson.M{"listId": *listID}}
+	}
+
+	result, err := r.collection.UpdateMany(ctx, mongoFilter(filter), update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r *MongoTaskRepository) SetParent(ctx context.Context, filter TaskFilter, parentID *bson.ObjectID) (int64, error) {
+	update := bson.M{"$unset": bson.M{"parentId": ""}}
+	if parentID != nil {
+		update = bson.M{"$set": bson.M{"parentId": *parentID}}
+	}
+
+	result, err := r.collection.UpdateMany(ctx, mongoFilter(filter), update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r *MongoTaskRepository) SetDeleted(ctx context.Context, filter TaskFilter, deletedAt *time.Time) (int64, error) {
+	update := bson.M{"$unset": bson.M{"deletedAt": ""}}
+	if deletedAt != nil {
+		update = bson.M{"$set": bson.M{"deletedAt": *deletedAt}}
+	}
+
+	result, err := r.collection.UpdateMany(ctx, mongoFilter(filter), update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func mongoFilter(filter TaskFilter) bson.M {
+	query := bson.M{"deletedAt": nil}
+
+	if filter.Trashed {
+		deleted := bson.M{"$ne": nil}
+		if filter.DeletedBefore != nil {
+			deleted = bson.M{"$lt": *filter.DeletedBefore}
+		}
+		query["deletedAt"] = deleted
+	}
+
+	if filter.Owner != nil {
+		query["ownerId"] = *filter.Owner
+	}
+
+	if filter.Ids != nil {
+		query["_id"] = bson.M{"$in": filter.Ids}
+	}
+
+	if filter.Parents != nil {
+		query["parentId"] = bson.M{"$in": filter.Parents}
+	}
+
+	if filter.TopLevel {
+		query["parentId"] = nil
+	}
+
+	if filter.List != nil {
+		query["listId"] = *filter.List
+	}
+
+	if filter.Inbox {
+		query["listId"] = nil
+	}
+
+	if filter.Completed != nil {
+		query["completed"] = *filter.Completed
+	}
+
+	if filter.Tag != "" {
+		query["tags"] = filter.Tag
+	}
+
+	if filter.Uid != "" {
+		query["uid"] = filter.Uid
+	}
+
+	if filter.ResourceName != "" {
+		query["resourceName"] = filter.ResourceName
+	}
+
+	if filter.DueFrom != nil || filter.DueBefore != nil {
+		due := bson.M{}
+		if filter.DueFrom != nil {
+			due["$gte"] = *filter.DueFrom
+		}
+		if filter.DueBefore != nil {
+			due["$lt"] = *filter.DueBefore
+		}
+		query["dueAt"] = due
+	}
+
+	if filter.Text != "" {
+		query["$text"] = bson.M{"$search": filter.Text}
+	}
+
+	return query
+}
+
+// listPipeline matches, sorts and pages tasks. Documents missing the sort
+// field get a helper "_missing" key so they land last in both directions.
+// Priority, which tasks without one lack altogether, is sorted by a copy
+// in "_priority" that is 0 for them, so that a page can start at such a
+// task. Text queries also carry their relevance in "_score".
+func listPipeline(query bson.M, text bool, opts ListOptions) mongo.Pipeline {
+	direction := 1
+	if opts.Descending {
+		direction = -1
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: query}}}
+	if text {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{
+			"_score": bson.M{"$meta": "textScore"},
+		}}})
+	}
+
+	var sort bson.D
+	switch opts.Sort {
+	case SortDueAt:
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{
+			"_missing": bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{"$dueAt", false}}, 0, 1}},
+		}}})
+		sort = bson.D{{Key: "_missing", Value: 1}, {Key: "dueAt", Value: direction}}
+	case SortPriority:
+		// Tasks without a priority have no field at all, which neither
+		// equals nor compares to 0; "_priority" gives them a 0 to page by.
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{
+			"_missing":  bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$priority", 0}}, 0, 1}},
+			"_priority": bson.M{"$ifNull": bson.A{"$priority", 0}},
+		}}})
+		sort = bson.D{{Key: "_missing", Value: 1}, {Key: "_priority", Value: direction}}
+	case SortDescription:
+		sort = bson.D{{Key: "description", Value: direction}}
+	case SortRelevance:
+		if text {
+			sort = bson.D{{Key: "_score", Value: -direction}}
+		}
+	}
+	sort = append(sort, bson.E{Key: "_id", Value: direction})
+
+	if opts.After != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: seekQuery(sort, *opts.After, opts.Sort, false)}})
+	}
+	if opts.Before != nil {
+		// The tasks closest to the position come first, so that the limit
+		// keeps them; List puts them back in order.
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: seekQuery(sort, *opts.Before, opts.Sort, true)}})
+		for i := range sort {
+			sort[i].Value = -sort[i].Value.(int)
+		}
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+	if opts.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: opts.Limit}})
+	}
+	switch opts.Sort {
+	case SortDueAt:
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: bson.M{"_missing": 0}}})
+	case SortPriority:
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: bson.M{"_missing": 0, "_priority": 0}}})
+	}
+
+	return pipeline
+}
+
+// seekQuery matches the documents that sort after position, or before it,
+// by the keys of sort: those past it in the first key that differs.
+func seekQuery(sort bson.D, position Position, field SortField, before bool) bson.M {
+	missing := 0
+	if field == SortDueAt && position.DueAt == nil || field == SortPriority && position.Priority <= 0 {
+		missing = 1
+	}
+	values := bson.M{
+		"_missing":    missing,
+		"dueAt":       position.DueAt,
+		"_priority":   position.Priority,
+		"description": position.Description,
+		"_score":      position.Score,
+		"_id":         position.Id,
+	}
+
+	var or bson.A
+	for i, key := range sort {
+		op := "$gt"
+		if (key.Value.(int) < 0) != before {
+			op = "$lt"
+		}
+
+		clause := bson.M{key.Key: bson.M{op: values[key.Key]}}
+		for _, equal := range sort[:i] {
+			clause[equal.Key] = values[equal.Key]
+		}
+		or = append(or, clause)
+	}
+
+	return bson.M{"$or": or}
+}
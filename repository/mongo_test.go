@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// stage returns the value of the last stage of pipeline named name.
+func stage(t *testing.T, pipeline []bson.D, name string) any {
+	for i := len(pipeline) - 1; i >= 0; i-- {
+		if pipeline[i][0].Key == name {
+			return pipeline[i][0].Value
+		}
+	}
+	require.Failf(t, "missing stage", "%s in %v", name, pipeline)
+	return nil
+}
+
+func TestListPipelineSeeksPastTaskWithoutPriority(t *testing.T) {
+	id := bson.NewObjectID()
+	pipeline := listPipeline(bson.M{}, false, ListOptions{Sort: SortPriority, After: &Position{Id: id}})
+
+	// Documents without a priority field sort, and are matched, by a 0.
+	added := pipeline[1][0].Value.(bson.M)
+	assert.Equal(t, bson.M{"$ifNull": bson.A{"$priority", 0}}, added["_priority"])
+	assert.Equal(t, bson.D{{Key: "_missing", Value: 1}, {Key: "_priority", Value: 1}, {Key: "_id", Value: 1}}, stage(t, pipeline, "$sort"))
+
+	seek := pipeline[2][0].Value.(bson.M)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"_missing": bson.M{"$gt": 1}},
+		bson.M{"_missing": 1, "_priority": bson.M{"$gt": 0}},
+		bson.M{"_missing": 1, "_priority": 0, "_id": bson.M{"$gt": id}},
+	}}, seek)
+
+	assert.Equal(t, bson.M{"_missing": 0, "_priority": 0}, stage(t, pipeline, "$project"))
+}
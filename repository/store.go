@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	tasksCollection    = "tasks"
+	listsCollection    = "lists"
+	usersCollection    = "users"
+	sessionsCollection = "sessions"
+	historyCollection  = "history"
+	versionCollection  = "historyVersions"
+	deletionCollection = "deletions"
+	webhookCollection  = "webhooks"
+	deliveryCollection = "deliveries"
+	eventCollection    = "events"
+)
+
+// Store groups the repositories backing the API.
+type Store struct {
+	Tasks      TaskRepository
+	Lists      ListRepository
+	Users      UserRepository
+	Sessions   SessionRepository
+	History    HistoryRepository
+	Deletions  DeletionRepository
+	Webhooks   WebhookRepository
+	Deliveries DeliveryRepository
+	Events     EventRepository
+	// Transactor makes groups of writes atomic; nil when the storage
+	// cannot.
+	Transactor Transactor
+}
+
+// NewMongoStore returns a store backed by the collections of db.
+func NewMongoStore(db *mongo.Database) Store {
+	return NewMongoStoreWithTasks(db, tasksCollection)
+}
+
+// NewMongoStoreWithTasks is NewMongoStore keeping the tasks in the
+// collection named tasks.
+func NewMongoStoreWithTasks(db *mongo.Database, tasks string) Store {
+	return Store{
+		Tasks:      NewMongoTaskRepository(db.Collection(tasks)),
+		Lists:      NewMongoListRepository(db.Collection(listsCollection)),
+		Users:      NewMongoUserRepository(db.Collection(usersCollection)),
+		Sessions:   NewMongoSessionRepository(db.Collection(sessionsCollection)),
+		History:    NewMongoHistoryRepository(db.Collection(historyCollection), db.Collection(versionCollection)),
+		Deletions:  NewMongoDeletionRepository(db.Collection(deletionCollection)),
+		Webhooks:   NewMongoWebhookRepository(db.Collection(webhookCollection)),
+		Deliveries: NewMongoDeliveryRepository(db.Collection(deliveryCollection)),
+		Events:     NewMongoEventRepository(db.Collection(eventCollection)),
+		Transactor: NewMongoTransactor(db.Client()),
+	}
+}
+
+// NewMemoryStore returns an empty store kept in process memory.
+func NewMemoryStore() Store {
+	s := Store{
+		Tasks:      NewMemoryTaskRepository(),
+		Lists:      NewMemoryListRepository(),
+		Users:      NewMemoryUserRepository(),
+		Sessions:   NewMemorySessionRepository(),
+		History:    NewMemoryHistoryRepository(),
+		Deletions:  NewMemoryDeletionRepository(),
+		Webhooks:   NewMemoryWebhookRepository(),
+		Deliveries: NewMemoryDeliveryRepository(),
+		Events:     NewMemoryEventRepository(),
+	}
+	s.Transactor = NewMemoryTransactor(s)
+
+	return s
+}
+
+// EnsureIndexes creates the indexes of every repository that needs them.
+func (s Store) EnsureIndexes(ctx context.Context) error {
+	if tasks, ok := s.Tasks.(*MongoTaskRepository); ok {
+		if err := tasks.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+	}
+
+	if users, ok := s.Users.(*MongoUserRepository); ok {
+		if err := users.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+	}
+
+	if sessions, ok := s.Sessions.(*MongoSessionRepository); ok {
+		if err := sessions.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+	}
+
+	if history, ok := s.History.(*MongoHistoryRepository); ok {
+		if err := history.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+	}
+
+	if deletions, ok := s.Deletions.(*MongoDeletionRepository); ok {
+		if err := deletions.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+	}
+
+	if deliveries, ok := s.Deliveries.(*MongoDeliveryRepository); ok {
+		if err := deliveries.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+	}
+
+	if events, ok := s.Events.(*MongoEventRepository); ok {
+		if err := events.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/search"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	// ErrNotFound is returned when the requested document does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicateID is returned when inserting a task whose id is taken.
+	ErrDuplicateID = errors.New("task id already exists")
+)
+
+// TaskFilter narrows the tasks returned by List. Zero values match every
+// task that is not in the trash.
+type TaskFilter struct {
+	// Trashed selects the tasks in the trash instead of the live ones;
+	// DeletedBefore narrows them to those trashed before a given time.
+	Trashed       bool
+	DeletedBefore *time.Time
+	// Owner restricts tasks to those of one user.
+	Owner *bson.ObjectID
+	// Ids restricts tasks to the given ids; a non-nil empty slice matches
+	// nothing.
+	Ids []bson.ObjectID
+	// Parents restricts tasks to the direct subtasks of the given tasks;
+	// TopLevel to tasks without a parent.
+	Parents  []bson.ObjectID
+	TopLevel bool
+	// List restricts tasks to one list; Inbox to tasks without a list.
+	List      *bson.ObjectID
+	Inbox     bool
+	Completed *bool
+	// Tag restricts tasks to those carrying a tag.
+	Tag string
+	// Uid and ResourceName look tasks up by their CalDAV identity.
+	Uid          string
+	ResourceName string
+	// DueFrom and DueBefore bound DueAt to [DueFrom, DueBefore). Setting
+	// either one excludes tasks without a due date.
+	DueFrom   *time.Time
+	DueBefore *time.Time
+	// Text is a full-text query over the description.
+	Text string
+}
+
+// Matches reports whether task satisfies the filter.
+func (f TaskFilter) Matches(task models.Task) bool {
+	if (task.DeletedAt != nil) != f.Trashed {
+		return false
+	}
+
+	if f.DeletedBefore != nil && (task.DeletedAt == nil || !task.DeletedAt.Before(*f.DeletedBefore)) {
+		return false
+	}
+
+	if f.Owner != nil && (task.OwnerId == nil || *task.OwnerId != *f.Owner) {
+		return false
+	}
+
+	if f.Ids != nil && !containsID(f.Ids, task.Id) {
+		return false
+	}
+
+	if f.Parents != nil && (task.ParentId == nil || !containsID(f.Parents, *task.ParentId)) {
+		return false
+	}
+
+	if f.TopLevel && task.ParentId != nil {
+		return false
+	}
+
+	if f.List != nil && (task.ListId == nil || *task.ListId != *f.List) {
+		return false
+	}
+
+	if f.Inbox && task.ListId != nil {
+		return false
+	}
+
+	if f.Completed != nil && task.Completed != *f.Completed {
+		return false
+	}
+
+	if f.Tag != "" && !slices.Contains(task.Tags, f.Tag) {
+		return false
+	}
+
+	if f.Uid != "" && task.Uid != f.Uid {
+		return false
+	}
+
+	if f.ResourceName != "" && task.ResourceName != f.ResourceName {
+		return false
+	}
+
+	if f.DueFrom != nil || f.DueBefore != nil {
+		if task.DueAt == nil {
+			return false
+		}
+		if f.DueFrom != nil && task.DueAt.Before(*f.DueFrom) {
+			return false
+		}
+		if f.DueBefore != nil && !task.DueAt.Before(*f.DueBefore) {
+			return false
+		}
+	}
+
+	if f.Text != "" && search.Score(task.Description, search.Terms(f.Text)) == 0 {
+		return false
+	}
+
+	return true
+}
+
+func containsID(ids []bson.ObjectID, id bson.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SortField names a field List can order by.
+type SortField string
+
+const (
+	SortCreated     SortField = "created"
+	SortDueAt       SortField = "dueAt"
+	SortPriority    SortField = "priority"
+	SortDescription SortField = "description"
+	// SortRelevance puts the best full-text matches first. It only applies
+	// when TaskFilter.Text is set.
+	SortRelevance SortField = "relevance"
+)
+
+// ListOptions controls ordering and paging in List. Tasks without a due
+// date or priority always sort last, whatever the direction. Ties are
+// broken by creation order in the same direction.
+type ListOptions struct {
+	Sort       SortField
+	Descending bool
+	// After starts the list with the task following this position.
+	After *Position
+	// Before ends the list with the task preceding this position; Limit
+	// then keeps the tasks closest to it.
+	Before *Position
+	// Limit caps the number of tasks returned; 0 means no limit.
+	Limit int
+}
+
+// Position is where a task sits in a sorted list: the value of the field
+// sorted by and the id that breaks ties. Paging from a position rather
+// than skipping a number of tasks neither repeats nor misses tasks when
+// others are added or removed in front.
+type Position struct {
+	DueAt       *time.Time    `json:"d,omitempty"`
+	Priority    int           `json:"p,omitempty"`
+	Description string        `json:"t,omitempty"`
+	Score       float64       `json:"r,omitempty"`
+	Id          bson.ObjectID `json:"i"`
+}
+
+// PositionOf returns the position of task in a list sorted by field.
+func PositionOf(task models.Task, field SortField) Position {
+	position := Position{Id: task.Id}
+	switch field {
+	case SortDueAt:
+		position.DueAt = task.DueAt
+	case SortPriority:
+		position.Priority = task.Priority
+	case SortDescription:
+		position.Description = task.Description
+	case SortRelevance:
+		position.Score = task.Score
+	}
+	return position
+}
+
+// TaskRepository is the storage used by the task controller.
+type TaskRepository interface {
+	List(ctx context.Context, filter TaskFilter, opts ListOptions) ([]models.Task, error)
+	Count(ctx context.Context, filter TaskFilter) (int64, error)
+	// CountTags counts the tasks matching filter that carry each tag.
+	CountTags(ctx context.Context, filter TaskFilter) (map[string]int64, error)
+	Get(ctx context.Context, id bson.ObjectID) (models.Task, error)
+	Insert(ctx context.Context, task models.Task) (models.Task, error)
+	Update(ctx context.Context, task models.Task) error
+	// Delete and DeleteAll remove tasks for good; SetDeleted moves them to
+	// the trash.
+	Delete(ctx context.Context, id bson.ObjectID) error
+	// DeleteAll removes every task matching filter.
+	DeleteAll(ctx context.Context, filter TaskFilter) (int64, error)
+	// SetList moves every task matching filter to listID (nil for the inbox).
+	SetList(ctx context.Context, filter TaskFilter, listID *bson.ObjectID) (int64, error)
+	// SetParent moves every task matching filter under parentID (nil for
+	// the top level).
+	SetParent(ctx context.Context, filter TaskFilter, parentID *bson.ObjectID) (int64, error)
+	// SetDeleted moves every task matching filter to the trash, stamped
+	// with deletedAt, or back out of it when deletedAt is nil.
+	SetDeleted(ctx context.Context, filter TaskFilter, deletedAt *time.Time) (int64, error)
+}
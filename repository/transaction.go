@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Transactor runs a group of writes atomically: either all of them are
+// kept or, when fn fails, none is.
+type Transactor interface {
+	// WithTransaction runs fn, passing it the context its writes must use.
+	// fn may run more than once when the transaction is retried.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// MongoTransactor runs multi-document transactions. The server must be a
+// replica set or a sharded cluster.
+type MongoTransactor struct {
+	client *mongo.Client
+}
+
+func NewMongoTransactor(client *mongo.Client) *MongoTransactor {
+	return &MongoTransactor{client: client}
+}
+
+func (t *MongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+// MemoryTransactor rolls back the memory repositories of a store by
+// restoring a snapshot taken before fn ran. Transactions run one at a
+// time, but writes made outside of one while it runs are rolled back
+// with it, so it suits tests and single-user embedding.
+type MemoryTransactor struct {
+	mu      sync.Mutex
+	tasks   *MemoryTaskRepository
+	history *MemoryHistoryRepository
+}
+
+// NewMemoryTransactor covers the tasks and history of s that are kept in
+// memory.
+func NewMemoryTransactor(s Store) *MemoryTransactor {
+	t := &MemoryTransactor{}
+	t.tasks, _ = s.Tasks.(*MemoryTaskRepository)
+	t.history, _ = s.History.(*MemoryHistoryRepository)
+	return t
+}
+
+func (t *MemoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var restore []func()
+	if t.tasks != nil {
+		restore = append(restore, t.tasks.snapshot())
+	}
+	if t.history != nil {
+		restore = append(restore, t.history.snapshot())
+	}
+
+	err := fn(ctx)
+	if err != nil {
+		for _, undo := range restore {
+			undo()
+		}
+	}
+
+	return err
+}
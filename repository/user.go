@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrDuplicateEmail is returned when registering an email that is taken.
+var ErrDuplicateEmail = errors.New("email already registered")
+
+// UserRepository is the storage used for user accounts. Emails are unique.
+type UserRepository interface {
+	Get(ctx context.Context, id bson.ObjectID) (models.User, error)
+	GetByEmail(ctx context.Context, email string) (models.User, error)
+	Insert(ctx context.Context, user models.User) (models.User, error)
+}
+
+// SessionRepository is the storage used for login sessions, keyed by the
+// hash of their token.
+type SessionRepository interface {
+	Get(ctx context.Context, tokenHash string) (models.Session, error)
+	Insert(ctx context.Context, session models.Session) error
+	Delete(ctx context.Context, tokenHash string) error
+}
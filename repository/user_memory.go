@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MemoryUserRepository keeps users in process memory. It is safe for
+// concurrent use.
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[bson.ObjectID]models.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users: make(map[bson.ObjectID]models.User),
+	}
+}
+
+func (r *MemoryUserRepository) Get(ctx context.Context, id bson.ObjectID) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return models.User{}, ErrNotFound
+}
+
+func (r *MemoryUserRepository) Insert(ctx context.Context, user models.User) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user.Id.IsZero() {
+		user.Id = bson.NewObjectID()
+	}
+
+	if _, exists := r.users[user.Id]; exists {
+		return user, ErrDuplicateID
+	}
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return user, ErrDuplicateEmail
+		}
+	}
+
+	r.users[user.Id] = user
+
+	return user, nil
+}
+
+// MemorySessionRepository keeps sessions in process memory. It is safe for
+// concurrent use. Expired sessions are left for the caller to reject.
+type MemorySessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]models.Session
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{
+		sessions: make(map[string]models.Session),
+	}
+}
+
+func (r *MemorySessionRepository) Get(ctx context.Context, tokenHash string) (models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[tokenHash]
+	if !ok {
+		return models.Session{}, ErrNotFound
+	}
+
+	return session, nil
+}
+
+func (r *MemorySessionRepository) Insert(ctx context.Context, session models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessions[session.TokenHash]; exists {
+		return ErrDuplicateID
+	}
+
+	r.sessions[session.TokenHash] = session
+
+	return nil
+}
+
+func (r *MemorySessionRepository) Delete(ctx context.Context, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[tokenHash]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.sessions, tokenHash)
+
+	return nil
+}
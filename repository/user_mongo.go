@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoUserRepository stores users in a MongoDB collection.
+type MongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoUserRepository(collection *mongo.Collection) *MongoUserRepository {
+	return &MongoUserRepository{collection: collection}
+}
+
+// EnsureIndexes creates the unique index on email.
+func (r *MongoUserRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *MongoUserRepository) Get(ctx context.Context, id bson.ObjectID) (models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *MongoUserRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *MongoUserRepository) findOne(ctx context.Context, query bson.M) (models.User, error) {
+	var user models.User
+
+	err := r.collection.FindOne(ctx, query).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return user, ErrNotFound
+	}
+
+	return user, err
+}
+
+func (r *MongoUserRepository) Insert(ctx context.Context, user models.User) (models.User, error) {
+	if user.Id.IsZero() {
+		user.Id = bson.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		// The id is freshly generated, so the clash is on the email.
+		return user, ErrDuplicateEmail
+	}
+
+	return user, err
+}
+
+// MongoSessionRepository stores sessions in a MongoDB collection. A TTL
+// index removes them once they expire.
+type MongoSessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSessionRepository(collection *mongo.Collection) *MongoSessionRepository {
+	return &MongoSessionRepository{collection: collection}
+}
+
+// EnsureIndexes creates the TTL index on expiresAt.
+func (r *MongoSessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *MongoSessionRepository) Get(ctx context.Context, tokenHash string) (models.Session, error) {
+	var session models.Session
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": tokenHash}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return session, ErrNotFound
+	}
+
+	return session, err
+}
+
+func (r *MongoSessionRepository) Insert(ctx context.Context, session models.Session) error {
+	_, err := r.collection.InsertOne(ctx, session)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateID
+	}
+
+	return err
+}
+
+func (r *MongoSessionRepository) Delete(ctx context.Context, tokenHash string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": tokenHash})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// WebhookRepository is the storage used for webhook subscriptions.
+type WebhookRepository interface {
+	// List returns the webhooks of owner, or all webhooks when owner is
+	// nil.
+	List(ctx context.Context, owner *bson.ObjectID) ([]models.Webhook, error)
+	Get(ctx context.Context, id bson.ObjectID) (models.Webhook, error)
+	Insert(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	Update(ctx context.Context, webhook models.Webhook) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+	// RecordResult counts a delivery that succeeded or failed for good.
+	// Success resets Failures; a failure that brings it to disableAfter
+	// deactivates the webhook at now.
+	RecordResult(ctx context.Context, id bson.ObjectID, succeeded bool, disableAfter int, now time.Time) error
+}
+
+// DeliveryRepository is the log of webhook delivery attempts.
+type DeliveryRepository interface {
+	Append(ctx context.Context, delivery models.Delivery) error
+	// List returns the latest limit attempts for a webhook, newest first.
+	List(ctx context.Context, webhookID bson.ObjectID, limit int) ([]models.Delivery, error)
+	// DeleteAll drops the log of a webhook.
+	DeleteAll(ctx context.Context, webhookID bson.ObjectID) error
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxMemoryDeliveries is how many attempts MemoryDeliveryRepository keeps
+// per webhook.
+const maxMemoryDeliveries = 100
+
+// MemoryWebhookRepository keeps webhooks in process memory. It is safe for
+// concurrent use.
+type MemoryWebhookRepository struct {
+	mu       sync.RWMutex
+	webhooks map[bson.ObjectID]models.Webhook
+}
+
+func NewMemoryWebhookRepository() *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		webhooks: make(map[bson.ObjectID]models.Webhook),
+	}
+}
+
+func (r *MemoryWebhookRepository) List(ctx context.Context, owner *bson.ObjectID) ([]models.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhooks := make([]models.Webhook, 0, len(r.webhooks))
+	for _, webhook := range r.webhooks {
+		if owner != nil && (webhook.OwnerId == nil || *webhook.OwnerId != *owner) {
+			continue
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].Id.Hex() < webhooks[j].Id.Hex()
+	})
+
+	return webhooks, nil
+}
+
+func (r *MemoryWebhookRepository) Get(ctx context.Context, id bson.ObjectID) (models.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return models.Webhook{}, ErrNotFound
+	}
+
+	return webhook, nil
+}
+
+func (r *MemoryWebhookRepository) Insert(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if webhook.Id.IsZero() {
+		webhook.Id = bson.NewObjectID()
+	}
+
+	if _, exists := r.webhooks[webhook.Id]; exists {
+		return webhook, ErrDuplicateID
+	}
+
+	r.webhooks[webhook.Id] = webhook
+
+	return webhook, nil
+}
+
+func (r *MemoryWebhookRepository) Update(ctx context.Context, webhook models.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[webhook.Id]; !ok {
+		return ErrNotFound
+	}
+
+	r.webhooks[webhook.Id] = webhook
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.webhooks, id)
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) RecordResult(ctx context.Context, id bson.ObjectID, succeeded bool, disableAfter int, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if succeeded {
+		webhook.Failures = 0
+	} else {
+		webhook.Failures++
+		if webhook.Active && webhook.Failures >= disableAfter {
+			webhook.Active = false
+			webhook.DisabledAt = &now
+		}
+	}
+	r.webhooks[id] = webhook
+
+	return nil
+}
+
+// MemoryDeliveryRepository keeps the latest delivery attempts of each
+// webhook in process memory. It is safe for concurrent use.
+type MemoryDeliveryRepository struct {
+	mu         sync.RWMutex
+	deliveries map[bson.ObjectID][]models.Delivery
+}
+
+func NewMemoryDeliveryRepository() *MemoryDeliveryRepository {
+	return &MemoryDeliveryRepository{
+		deliveries: make(map[bson.ObjectID][]models.Delivery),
+	}
+}
+
+func (r *MemoryDeliveryRepository) Append(ctx context.Context, delivery models.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if delivery.Id.IsZero() {
+		delivery.Id = bson.NewObjectID()
+	}
+
+	log := append(r.deliveries[delivery.WebhookId], delivery)
+	if len(log) > maxMemoryDeliveries {
+		log = log[len(log)-maxMemoryDeliveries:]
+	}
+	r.deliveries[delivery.WebhookId] = log
+
+	return nil
+}
+
+func (r *MemoryDeliveryRepository) List(ctx context.Context, webhookID bson.ObjectID, limit int) ([]models.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	log := r.deliveries[webhookID]
+	deliveries := make([]models.Delivery, 0, min(len(log), limit))
+	for i := len(log) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		deliveries = append(deliveries, log[i])
+	}
+
+	return deliveries, nil
+}
+
+func (r *MemoryDeliveryRepository) DeleteAll(ctx context.Context, webhookID bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.deliveries, webhookID)
+
+	return nil
+}
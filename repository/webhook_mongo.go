@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// deliveryRetention is how long delivery attempts are kept.
+const deliveryRetention = 30 * 24 * time.Hour
+
+// MongoWebhookRepository stores webhooks in a MongoDB collection.
+type MongoWebhookRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoWebhookRepository(collection *mongo.Collection) *MongoWebhookRepository {
+	return &MongoWebhookRepository{collection: collection}
+}
+
+func (r *MongoWebhookRepository) List(ctx context.Context, owner *bson.ObjectID) ([]models.Webhook, error) {
+	query := bson.M{}
+	if owner != nil {
+		query["ownerId"] = *owner
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []models.Webhook{}
+	if err = cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (r *MongoWebhookRepository) Get(ctx context.Context, id bson.ObjectID) (models.Webhook, error) {
+	var webhook models.Webhook
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return webhook, ErrNotFound
+	}
+
+	return webhook, err
+}
+
+func (r *MongoWebhookRepository) Insert(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	result, err := r.collection.InsertOne(ctx, webhook)
+	if mongo.IsDuplicateKeyError(err) {
+		return webhook, ErrDuplicateID
+	}
+	if err != nil {
+		return webhook, err
+	}
+
+	if oid, ok := result.InsertedID.(bson.ObjectID); ok {
+		webhook.Id = oid
+	}
+
+	return webhook, nil
+}
+
+func (r *MongoWebhookRepository) Update(ctx context.Context, webhook models.Webhook) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": webhook.Id}, webhook)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MongoWebhookRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MongoWebhookRepository) RecordResult(ctx context.Context, id bson.ObjectID, succeeded bool, disableAfter int, now time.Time) error {
+	if succeeded {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"failures": 0}})
+		return err
+	}
+
+	var webhook models.Webhook
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"failures": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&webhook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if webhook.Active && webhook.Failures >= disableAfter {
+		_, err = r.collection.UpdateOne(ctx, bson.M{"_id": id, "active": true},
+			bson.M{"$set": bson.M{"active": false, "disabledAt": now}})
+	}
+
+	return err
+}
+
+// MongoDeliveryRepository stores delivery attempts in a MongoDB collection.
+// A TTL index removes them after deliveryRetention.
+type MongoDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDeliveryRepository(collection *mongo.Collection) *MongoDeliveryRepository {
+	return &MongoDeliveryRepository{collection: collection}
+}
+
+// EnsureIndexes creates the index used to list the attempts of a webhook
+// and the TTL index on at.
+func (r *MongoDeliveryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "_id", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(deliveryRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+func (r *MongoDeliveryRepository) Append(ctx context.Context, delivery models.Delivery) error {
+	_, err := r.collection.InsertOne(ctx, delivery)
+	return err
+}
+
+func (r *MongoDeliveryRepository) List(ctx context.Context, webhookID bson.ObjectID, limit int) ([]models.Delivery, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"webhookId": webhookID},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []models.Delivery{}
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *MongoDeliveryRepository) DeleteAll(ctx context.Context, webhookID bson.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"webhookId": webhookID})
+	return err
+}
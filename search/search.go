@@ -0,0 +1,158 @@
+// Package search implements the small subset of MongoDB text search used
+// by the in-memory repository and for highlighting matches in responses.
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Terms splits a query into normalized search terms.
+func Terms(query string) []string {
+	var terms []string
+	for _, word := range words(query) {
+		terms = append(terms, stem(strings.ToLower(word.text)))
+	}
+	return terms
+}
+
+// Score rates how well text matches terms: every matching word counts,
+// and shorter texts rank higher for the same number of matches. Zero
+// means no match.
+func Score(text string, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	tokens := words(text)
+	matches := 0
+	for _, token := range tokens {
+		if matchesAny(token.text, terms) {
+			matches++
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	return float64(matches) * (0.5 + 0.5/float64(len(tokens)))
+}
+
+// Highlight HTML-escapes text and wraps words matching terms in <mark>.
+// When width is positive the result is cut down to a snippet of roughly
+// that many characters around the first match.
+func Highlight(text string, terms []string, width int) string {
+	tokens := words(text)
+
+	start, end := 0, len(text)
+	if width > 0 && len(text) > width {
+		first := -1
+		for _, token := range tokens {
+			if matchesAny(token.text, terms) {
+				first = token.start
+				break
+			}
+		}
+		if first < 0 {
+			first = 0
+		}
+
+		start = max(0, first-width/2)
+		end = min(len(text), start+width)
+		start = max(0, end-width)
+		start, end = snapToWords(tokens, start, end)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	position := start
+	for _, token := range tokens {
+		if token.start < start || token.end > end || !matchesAny(token.text, terms) {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[position:token.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(token.text))
+		b.WriteString("</mark>")
+		position = token.end
+	}
+	b.WriteString(html.EscapeString(text[position:end]))
+
+	if end < len(text) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+type word struct {
+	text       string
+	start, end int
+}
+
+func words(text string) []word {
+	var result []word
+
+	start := -1
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWordRune && start < 0:
+			start = i
+		case !isWordRune && start >= 0:
+			result = append(result, word{text: text[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		result = append(result, word{text: text[start:], start: start, end: len(text)})
+	}
+
+	return result
+}
+
+// snapToWords widens [start, end) so it does not cut a word in half.
+func snapToWords(tokens []word, start, end int) (int, int) {
+	for _, token := range tokens {
+		if token.start < start && token.end > start {
+			start = token.start
+		}
+		if token.start < end && token.end > end {
+			end = token.end
+		}
+	}
+	return start, end
+}
+
+func matchesAny(token string, terms []string) bool {
+	stemmed := stem(strings.ToLower(token))
+	for _, term := range terms {
+		if stemmed == term {
+			return true
+		}
+	}
+	return false
+}
+
+// stem strips a few common English suffixes so that e.g. "tasks" and
+// "task" or "running" and "run" match, roughly like MongoDB's stemmer.
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if trimmed, ok := strings.CutSuffix(word, suffix); ok && len(trimmed) >= 3 {
+			word = trimmed
+			break
+		}
+	}
+
+	// "running" -> "runn" -> "run"
+	if n := len(word); n >= 4 && word[n-1] == word[n-2] && !strings.ContainsRune("aeiou", rune(word[n-1])) {
+		word = word[:n-1]
+	}
+
+	return word
+}
@@ -0,0 +1,40 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"buy", "task", "run"}, Terms("  Buy TASKS, running!"))
+	assert.Empty(t, Terms("  ,.  "))
+}
+
+func TestScore(t *testing.T) {
+	terms := Terms("milk")
+
+	assert.Zero(t, Score("Buy bread", terms))
+	assert.Greater(t, Score("Buy milk", terms), 0.0)
+	assert.Greater(t, Score("Milk", terms), Score("Buy milk and eggs", terms))
+	assert.Greater(t, Score("Milk, more milk", terms), Score("Milk and eggs", terms))
+	assert.Zero(t, Score("Buy milk", nil))
+}
+
+func TestHighlight(t *testing.T) {
+	terms := Terms("milk")
+
+	assert.Equal(t, "Buy <mark>Milk</mark> &amp; eggs", Highlight("Buy Milk & eggs", terms, 0))
+	assert.Equal(t, "&lt;b&gt;bold&lt;/b&gt;", Highlight("<b>bold</b>", terms, 0))
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	text := "The quick brown fox jumps over the lazy dog and then buys some milk at the corner shop before going home"
+
+	snippet := Highlight(text, Terms("milk"), 40)
+
+	assert.Contains(t, snippet, "<mark>milk</mark>")
+	assert.True(t, len(snippet) < len(text))
+	assert.Equal(t, "…", snippet[:len("…")])
+	assert.Equal(t, "…", snippet[len(snippet)-len("…"):])
+}
@@ -0,0 +1,1236 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: taskpb/task.proto
+
+// The task API for services that speak gRPC. It runs on the same storage as
+// the REST API, with the same validation and events.
+
+package taskpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TaskStatus int32
+
+const (
+	TaskStatus_TASK_STATUS_UNSPECIFIED TaskStatus = 0
+	TaskStatus_TASK_STATUS_OPEN        TaskStatus = 1
+	TaskStatus_TASK_STATUS_DONE        TaskStatus = 2
+)
+
+// Enum value maps for TaskStatus.
+var (
+	TaskStatus_name = map[int32]string{
+		0: "TASK_STATUS_UNSPECIFIED",
+		1: "TASK_STATUS_OPEN",
+		2: "TASK_STATUS_DONE",
+	}
+	TaskStatus_value = map[string]int32{
+		"TASK_STATUS_UNSPECIFIED": 0,
+		"TASK_STATUS_OPEN":        1,
+		"TASK_STATUS_DONE":        2,
+	}
+)
+
+func (x TaskStatus) Enum() *TaskStatus {
+	p := new(TaskStatus)
+	*p = x
+	return p
+}
+
+func (x TaskStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TaskStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_taskpb_task_proto_enumTypes[0].Descriptor()
+}
+
+func (TaskStatus) Type() protoreflect.EnumType {
+	return &file_taskpb_task_proto_enumTypes[0]
+}
+
+func (x TaskStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TaskStatus.Descriptor instead.
+func (TaskStatus) EnumDescriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{0}
+}
+
+// What happens to the subtasks of a deleted task.
+type ChildrenPolicy int32
+
+const (
+	// They move up to the deleted task's parent.
+	ChildrenPolicy_CHILDREN_POLICY_PROMOTE ChildrenPolicy = 0
+	// They are deleted too.
+	ChildrenPolicy_CHILDREN_POLICY_CASCADE ChildrenPolicy = 1
+)
+
+// Enum value maps for ChildrenPolicy.
+var (
+	ChildrenPolicy_name = map[int32]string{
+		0: "CHILDREN_POLICY_PROMOTE",
+		1: "CHILDREN_POLICY_CASCADE",
+	}
+	ChildrenPolicy_value = map[string]int32{
+		"CHILDREN_POLICY_PROMOTE": 0,
+		"CHILDREN_POLICY_CASCADE": 1,
+	}
+)
+
+func (x ChildrenPolicy) Enum() *ChildrenPolicy {
+	p := new(ChildrenPolicy)
+	*p = x
+	return p
+}
+
+func (x ChildrenPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChildrenPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_taskpb_task_proto_enumTypes[1].Descriptor()
+}
+
+func (ChildrenPolicy) Type() protoreflect.EnumType {
+	return &file_taskpb_task_proto_enumTypes[1]
+}
+
+func (x ChildrenPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChildrenPolicy.Descriptor instead.
+func (ChildrenPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{1}
+}
+
+type Task struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Completed   bool                   `protobuf:"varint,3,opt,name=completed,proto3" json:"completed,omitempty"`
+	CompletedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	DueAt       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=due_at,json=dueAt,proto3" json:"due_at,omitempty"`
+	// Computed on every response.
+	Overdue  bool `protobuf:"varint,6,opt,name=overdue,proto3" json:"overdue,omitempty"`
+	DueToday bool `protobuf:"varint,7,opt,name=due_today,json=dueToday,proto3" json:"due_today,omitempty"`
+	// 1 is the highest priority; 0 means none.
+	Priority   int32       `protobuf:"varint,8,opt,name=priority,proto3" json:"priority,omitempty"`
+	Tags       []string    `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	Projects   []string    `protobuf:"bytes,10,rep,name=projects,proto3" json:"projects,omitempty"`
+	Recurrence *Recurrence `protobuf:"bytes,11,opt,name=recurrence,proto3" json:"recurrence,omitempty"`
+	// Empty for the inbox.
+	ListId string `protobuf:"bytes,12,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+	// Empty for top-level tasks.
+	ParentId string `protobuf:"bytes,13,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	// Set on tasks with subtasks.
+	Progress *Progress `protobuf:"bytes,14,opt,name=progress,proto3" json:"progress,omitempty"`
+	// The occurrence created by completing a recurring task.
+	Next          *Task                  `protobuf:"bytes,15,opt,name=next,proto3" json:"next,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Task) Reset() {
+	*x = Task{}
+	mi := &file_taskpb_task_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Task) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Task) ProtoMessage() {}
+
+func (x *Task) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Task.ProtoReflect.Descriptor instead.
+func (*Task) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Task) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Task) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Task) GetCompleted() bool {
+	if x != nil {
+		return x.Completed
+	}
+	return false
+}
+
+func (x *Task) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+func (x *Task) GetDueAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueAt
+	}
+	return nil
+}
+
+func (x *Task) GetOverdue() bool {
+	if x != nil {
+		return x.Overdue
+	}
+	return false
+}
+
+func (x *Task) GetDueToday() bool {
+	if x != nil {
+		return x.DueToday
+	}
+	return false
+}
+
+func (x *Task) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Task) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Task) GetProjects() []string {
+	if x != nil {
+		return x.Projects
+	}
+	return nil
+}
+
+func (x *Task) GetRecurrence() *Recurrence {
+	if x != nil {
+		return x.Recurrence
+	}
+	return nil
+}
+
+func (x *Task) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *Task) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *Task) GetProgress() *Progress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *Task) GetNext() *Task {
+	if x != nil {
+		return x.Next
+	}
+	return nil
+}
+
+func (x *Task) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type Recurrence struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// An RFC 5545 RRULE, e.g. "FREQ=WEEKLY;BYDAY=MO".
+	Rule string `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	// An IANA time zone; UTC when empty.
+	TimeZone string `protobuf:"bytes,2,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"`
+	// Defaults to the task's due date.
+	Start *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=start,proto3" json:"start,omitempty"`
+	// "generate" or "roll".
+	Mode          string `protobuf:"bytes,4,opt,name=mode,proto3" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Recurrence) Reset() {
+	*x = Recurrence{}
+	mi := &file_taskpb_task_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Recurrence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Recurrence) ProtoMessage() {}
+
+func (x *Recurrence) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Recurrence.ProtoReflect.Descriptor instead.
+func (*Recurrence) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Recurrence) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Recurrence) GetTimeZone() string {
+	if x != nil {
+		return x.TimeZone
+	}
+	return ""
+}
+
+func (x *Recurrence) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *Recurrence) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type Progress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Done          int32                  `protobuf:"varint,1,opt,name=done,proto3" json:"done,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_taskpb_task_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Progress) GetDone() int32 {
+	if x != nil {
+		return x.Done
+	}
+	return 0
+}
+
+func (x *Progress) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+// TaskFilter narrows down tasks like the query parameters of GET /api/tasks.
+type TaskFilter struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status TaskStatus             `protobuf:"varint,1,opt,name=status,proto3,enum=todo.v1.TaskStatus" json:"status,omitempty"`
+	// A list id, or "inbox".
+	List string `protobuf:"bytes,2,opt,name=list,proto3" json:"list,omitempty"`
+	Tag  string `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
+	// Words to search the descriptions for.
+	Q             string `protobuf:"bytes,4,opt,name=q,proto3" json:"q,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskFilter) Reset() {
+	*x = TaskFilter{}
+	mi := &file_taskpb_task_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskFilter) ProtoMessage() {}
+
+func (x *TaskFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskFilter.ProtoReflect.Descriptor instead.
+func (*TaskFilter) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TaskFilter) GetStatus() TaskStatus {
+	if x != nil {
+		return x.Status
+	}
+	return TaskStatus_TASK_STATUS_UNSPECIFIED
+}
+
+func (x *TaskFilter) GetList() string {
+	if x != nil {
+		return x.List
+	}
+	return ""
+}
+
+func (x *TaskFilter) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *TaskFilter) GetQ() string {
+	if x != nil {
+		return x.Q
+	}
+	return ""
+}
+
+type CreateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Task          *Task                  `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRequest) Reset() {
+	*x = CreateRequest{}
+	mi := &file_taskpb_task_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRequest) ProtoMessage() {}
+
+func (x *CreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRequest.ProtoReflect.Descriptor instead.
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateRequest) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_taskpb_task_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *TaskFilter            `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// The sort of GET /api/tasks, e.g. "-dueAt".
+	Sort string `protobuf:"bytes,2,opt,name=sort,proto3" json:"sort,omitempty"`
+	// The most tasks to send; all of them when 0.
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_taskpb_task_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListRequest) GetFilter() *TaskFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type UpdateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The task to change, found by its id.
+	Task *Task `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	// The fields to take from task: description, completed, due_at,
+	// priority, tags, projects, recurrence, list_id and parent_id. Without
+	// a mask all of them are taken.
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_taskpb_task_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateRequest) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Children      ChildrenPolicy         `protobuf:"varint,2,opt,name=children,proto3,enum=todo.v1.ChildrenPolicy" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_taskpb_task_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetChildren() ChildrenPolicy {
+	if x != nil {
+		return x.Children
+	}
+	return ChildrenPolicy_CHILDREN_POLICY_PROMOTE
+}
+
+type DeleteResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The tasks moved to the trash, subtasks included.
+	TaskIds       []string `protobuf:"bytes,1,rep,name=task_ids,json=taskIds,proto3" json:"task_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_taskpb_task_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteResponse) GetTaskIds() []string {
+	if x != nil {
+		return x.TaskIds
+	}
+	return nil
+}
+
+type DeleteAllRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The tasks to delete; ignored along with token.
+	Filter   *TaskFilter    `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Children ChildrenPolicy `protobuf:"varint,2,opt,name=children,proto3,enum=todo.v1.ChildrenPolicy" json:"children,omitempty"`
+	// The token of the first step, to confirm it.
+	Token         string `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAllRequest) Reset() {
+	*x = DeleteAllRequest{}
+	mi := &file_taskpb_task_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAllRequest) ProtoMessage() {}
+
+func (x *DeleteAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAllRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAllRequest) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteAllRequest) GetFilter() *TaskFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *DeleteAllRequest) GetChildren() ChildrenPolicy {
+	if x != nil {
+		return x.Children
+	}
+	return ChildrenPolicy_CHILDREN_POLICY_PROMOTE
+}
+
+func (x *DeleteAllRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type DeleteAllResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Set by the first step: the token to confirm with, and until when.
+	Token     string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// The tasks that would be deleted, or were, subtasks included.
+	Count int32 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	// Set by the second step: the tasks deleted. Undoing it with
+	// POST /api/tasks/undo is possible until expires_at.
+	TaskIds       []string `protobuf:"bytes,4,rep,name=task_ids,json=taskIds,proto3" json:"task_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAllResponse) Reset() {
+	*x = DeleteAllResponse{}
+	mi := &file_taskpb_task_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAllResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAllResponse) ProtoMessage() {}
+
+func (x *DeleteAllResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAllResponse.ProtoReflect.Descriptor instead.
+func (*DeleteAllResponse) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteAllResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *DeleteAllResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *DeleteAllResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *DeleteAllResponse) GetTaskIds() []string {
+	if x != nil {
+		return x.TaskIds
+	}
+	return nil
+}
+
+type WatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The id of the last event seen, to send the ones following it first.
+	After         string `protobuf:"bytes,1,opt,name=after,proto3" json:"after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_taskpb_task_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchRequest) GetAfter() string {
+	if x != nil {
+		return x.After
+	}
+	return ""
+}
+
+type TaskEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Empty on reset.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// task.created, task.updated, task.deleted, tasks.cleared, or reset when
+	// the events to resume from are no longer known.
+	Event string `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	// The task created or updated.
+	Task *Task `protobuf:"bytes,3,opt,name=task,proto3" json:"task,omitempty"`
+	// The tasks the event is about.
+	TaskIds       []string `protobuf:"bytes,4,rep,name=task_ids,json=taskIds,proto3" json:"task_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskEvent) Reset() {
+	*x = TaskEvent{}
+	mi := &file_taskpb_task_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskEvent) ProtoMessage() {}
+
+func (x *TaskEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_taskpb_task_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskEvent.ProtoReflect.Descriptor instead.
+func (*TaskEvent) Descriptor() ([]byte, []int) {
+	return file_taskpb_task_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TaskEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+func (x *TaskEvent) GetTaskIds() []string {
+	if x != nil {
+		return x.TaskIds
+	}
+	return nil
+}
+
+var File_taskpb_task_proto protoreflect.FileDescriptor
+
+const file_taskpb_task_proto_rawDesc = "" +
+	"\n" +
+	"\x11taskpb/task.proto\x12\atodo.v1\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc3\x04\n" +
+	"\x04Task\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x1c\n" +
+	"\tcompleted\x18\x03 \x01(\bR\tcompleted\x12=\n" +
+	"\fcompleted_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\x121\n" +
+	"\x06due_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x05dueAt\x12\x18\n" +
+	"\aoverdue\x18\x06 \x01(\bR\aoverdue\x12\x1b\n" +
+	"\tdue_today\x18\a \x01(\bR\bdueToday\x12\x1a\n" +
+	"\bpriority\x18\b \x01(\x05R\bpriority\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\x12\x1a\n" +
+	"\bprojects\x18\n" +
+	" \x03(\tR\bprojects\x123\n" +
+	"\n" +
+	"recurrence\x18\v \x01(\v2\x13.todo.v1.RecurrenceR\n" +
+	"recurrence\x12\x17\n" +
+	"\alist_id\x18\f \x01(\tR\x06listId\x12\x1b\n" +
+	"\tparent_id\x18\r \x01(\tR\bparentId\x12-\n" +
+	"\bprogress\x18\x0e \x01(\v2\x11.todo.v1.ProgressR\bprogress\x12!\n" +
+	"\x04next\x18\x0f \x01(\v2\r.todo.v1.TaskR\x04next\x129\n" +
+	"\n" +
+	"created_at\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x83\x01\n" +
+	"\n" +
+	"Recurrence\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x1b\n" +
+	"\ttime_zone\x18\x02 \x01(\tR\btimeZone\x120\n" +
+	"\x05start\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\tR\x04mode\"4\n" +
+	"\bProgress\x12\x12\n" +
+	"\x04done\x18\x01 \x01(\x05R\x04done\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"m\n" +
+	"\n" +
+	"TaskFilter\x12+\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x13.todo.v1.TaskStatusR\x06status\x12\x12\n" +
+	"\x04list\x18\x02 \x01(\tR\x04list\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\f\n" +
+	"\x01q\x18\x04 \x01(\tR\x01q\"2\n" +
+	"\rCreateRequest\x12!\n" +
+	"\x04task\x18\x01 \x01(\v2\r.todo.v1.TaskR\x04task\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"d\n" +
+	"\vListRequest\x12+\n" +
+	"\x06filter\x18\x01 \x01(\v2\x13.todo.v1.TaskFilterR\x06filter\x12\x12\n" +
+	"\x04sort\x18\x02 \x01(\tR\x04sort\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"o\n" +
+	"\rUpdateRequest\x12!\n" +
+	"\x04task\x18\x01 \x01(\v2\r.todo.v1.TaskR\x04task\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"T\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x123\n" +
+	"\bchildren\x18\x02 \x01(\x0e2\x17.todo.v1.ChildrenPolicyR\bchildren\"+\n" +
+	"\x0eDeleteResponse\x12\x19\n" +
+	"\btask_ids\x18\x01 \x03(\tR\ataskIds\"\x8a\x01\n" +
+	"\x10DeleteAllRequest\x12+\n" +
+	"\x06filter\x18\x01 \x01(\v2\x13.todo.v1.TaskFilterR\x06filter\x123\n" +
+	"\bchildren\x18\x02 \x01(\x0e2\x17.todo.v1.ChildrenPolicyR\bchildren\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\"\x95\x01\n" +
+	"\x11DeleteAllResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x19\n" +
+	"\btask_ids\x18\x04 \x03(\tR\ataskIds\"$\n" +
+	"\fWatchRequest\x12\x14\n" +
+	"\x05after\x18\x01 \x01(\tR\x05after\"o\n" +
+	"\tTaskEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05event\x18\x02 \x01(\tR\x05event\x12!\n" +
+	"\x04task\x18\x03 \x01(\v2\r.todo.v1.TaskR\x04task\x12\x19\n" +
+	"\btask_ids\x18\x04 \x03(\tR\ataskIds*U\n" +
+	"\n" +
+	"TaskStatus\x12\x1b\n" +
+	"\x17TASK_STATUS_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10TASK_STATUS_OPEN\x10\x01\x12\x14\n" +
+	"\x10TASK_STATUS_DONE\x10\x02*J\n" +
+	"\x0eChildrenPolicy\x12\x1b\n" +
+	"\x17CHILDREN_POLICY_PROMOTE\x10\x00\x12\x1b\n" +
+	"\x17CHILDREN_POLICY_CASCADE\x10\x012\xfe\x02\n" +
+	"\vTaskService\x12/\n" +
+	"\x06Create\x12\x16.todo.v1.CreateRequest\x1a\r.todo.v1.Task\x12)\n" +
+	"\x03Get\x12\x13.todo.v1.GetRequest\x1a\r.todo.v1.Task\x12-\n" +
+	"\x04List\x12\x14.todo.v1.ListRequest\x1a\r.todo.v1.Task0\x01\x12/\n" +
+	"\x06Update\x12\x16.todo.v1.UpdateRequest\x1a\r.todo.v1.Task\x129\n" +
+	"\x06Delete\x12\x16.todo.v1.DeleteRequest\x1a\x17.todo.v1.DeleteResponse\x12B\n" +
+	"\tDeleteAll\x12\x19.todo.v1.DeleteAllRequest\x1a\x1a.todo.v1.DeleteAllResponse\x124\n" +
+	"\x05Watch\x12\x15.todo.v1.WatchRequest\x1a\x12.todo.v1.TaskEvent0\x01B\"Z example.com/todo-rest-api/taskpbb\x06proto3"
+
+var (
+	file_taskpb_task_proto_rawDescOnce sync.Once
+	file_taskpb_task_proto_rawDescData []byte
+)
+
+func file_taskpb_task_proto_rawDescGZIP() []byte {
+	file_taskpb_task_proto_rawDescOnce.Do(func() {
+		file_taskpb_task_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_taskpb_task_proto_rawDesc), len(file_taskpb_task_proto_rawDesc)))
+	})
+	return file_taskpb_task_proto_rawDescData
+}
+
+var file_taskpb_task_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_taskpb_task_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_taskpb_task_proto_goTypes = []any{
+	(TaskStatus)(0),               // 0: todo.v1.TaskStatus
+	(ChildrenPolicy)(0),           // 1: todo.v1.ChildrenPolicy
+	(*Task)(nil),                  // 2: todo.v1.Task
+	(*Recurrence)(nil),            // 3: todo.v1.Recurrence
+	(*Progress)(nil),              // 4: todo.v1.Progress
+	(*TaskFilter)(nil),            // 5: todo.v1.TaskFilter
+	(*CreateRequest)(nil),         // 6: todo.v1.CreateRequest
+	(*GetRequest)(nil),            // 7: todo.v1.GetRequest
+	(*ListRequest)(nil),           // 8: todo.v1.ListRequest
+	(*UpdateRequest)(nil),         // 9: todo.v1.UpdateRequest
+	(*DeleteRequest)(nil),         // 10: todo.v1.DeleteRequest
+	(*DeleteResponse)(nil),        // 11: todo.v1.DeleteResponse
+	(*DeleteAllRequest)(nil),      // 12: todo.v1.DeleteAllRequest
+	(*DeleteAllResponse)(nil),     // 13: todo.v1.DeleteAllResponse
+	(*WatchRequest)(nil),          // 14: todo.v1.WatchRequest
+	(*TaskEvent)(nil),             // 15: todo.v1.TaskEvent
+	(*timestamppb.Timestamp)(nil), // 16: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil), // 17: google.protobuf.FieldMask
+}
+var file_taskpb_task_proto_depIdxs = []int32{
+	16, // 0: todo.v1.Task.completed_at:type_name -> google.protobuf.Timestamp
+	16, // 1: todo.v1.Task.due_at:type_name -> google.protobuf.Timestamp
+	3,  // 2: todo.v1.Task.recurrence:type_name -> todo.v1.Recurrence
+	4,  // 3: todo.v1.Task.progress:type_name -> todo.v1.Progress
+	2,  // 4: todo.v1.Task.next:type_name -> todo.v1.Task
+	16, // 5: todo.v1.Task.created_at:type_name -> google.protobuf.Timestamp
+	16, // 6: todo.v1.Recurrence.start:type_name -> google.protobuf.Timestamp
+	0,  // 7: todo.v1.TaskFilter.status:type_name -> todo.v1.TaskStatus
+	2,  // 8: todo.v1.CreateRequest.task:type_name -> todo.v1.Task
+	5,  // 9: todo.v1.ListRequest.filter:type_name -> todo.v1.TaskFilter
+	2,  // 10: todo.v1.UpdateRequest.task:type_name -> todo.v1.Task
+	17, // 11: todo.v1.UpdateRequest.update_mask:type_name -> google.protobuf.FieldMask
+	1,  // 12: todo.v1.DeleteRequest.children:type_name -> todo.v1.ChildrenPolicy
+	5,  // 13: todo.v1.DeleteAllRequest.filter:type_name -> todo.v1.TaskFilter
+	1,  // 14: todo.v1.DeleteAllRequest.children:type_name -> todo.v1.ChildrenPolicy
+	16, // 15: todo.v1.DeleteAllResponse.expires_at:type_name -> google.protobuf.Timestamp
+	2,  // 16: todo.v1.TaskEvent.task:type_name -> todo.v1.Task
+	6,  // 17: todo.v1.TaskService.Create:input_type -> todo.v1.CreateRequest
+	7,  // 18: todo.v1.TaskService.Get:input_type -> todo.v1.GetRequest
+	8,  // 19: todo.v1.TaskService.List:input_type -> todo.v1.ListRequest
+	9,  // 20: todo.v1.TaskService.Update:input_type -> todo.v1.UpdateRequest
+	10, // 21: todo.v1.TaskService.Delete:input_type -> todo.v1.DeleteRequest
+	12, // 22: todo.v1.TaskService.DeleteAll:input_type -> todo.v1.DeleteAllRequest
+	14, // 23: todo.v1.TaskService.Watch:input_type -> todo.v1.WatchRequest
+	2,  // 24: todo.v1.TaskService.Create:output_type -> todo.v1.Task
+	2,  // 25: todo.v1.TaskService.Get:output_type -> todo.v1.Task
+	2,  // 26: todo.v1.TaskService.List:output_type -> todo.v1.Task
+	2,  // 27: todo.v1.TaskService.Update:output_type -> todo.v1.Task
+	11, // 28: todo.v1.TaskService.Delete:output_type -> todo.v1.DeleteResponse
+	13, // 29: todo.v1.TaskService.DeleteAll:output_type -> todo.v1.DeleteAllResponse
+	15, // 30: todo.v1.TaskService.Watch:output_type -> todo.v1.TaskEvent
+	24, // [24:31] is the sub-list for method output_type
+	17, // [17:24] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_taskpb_task_proto_init() }
+func file_taskpb_task_proto_init() {
+	if File_taskpb_task_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_taskpb_task_proto_rawDesc), len(file_taskpb_task_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_taskpb_task_proto_goTypes,
+		DependencyIndexes: file_taskpb_task_proto_depIdxs,
+		EnumInfos:         file_taskpb_task_proto_enumTypes,
+		MessageInfos:      file_taskpb_task_proto_msgTypes,
+	}.Build()
+	File_taskpb_task_proto = out.File
+	file_taskpb_task_proto_goTypes = nil
+	file_taskpb_task_proto_depIdxs = nil
+}
@@ -0,0 +1,234 @@
+// Package webhook delivers task events to the URLs users subscribe, as
+// signed JSON requests that are retried until the endpoint accepts them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// The events webhooks can subscribe to.
+const (
+	EventTaskCreated  = "task.created"
+	EventTaskUpdated  = "task.updated"
+	EventTaskDeleted  = "task.deleted"
+	EventTasksCleared = "tasks.cleared"
+)
+
+// Events lists every event, in the order they are documented.
+var Events = []string{EventTaskCreated, EventTaskUpdated, EventTaskDeleted, EventTasksCleared}
+
+// Headers sent with every delivery.
+const (
+	// SignatureHeader holds "sha256=" and the hex HMAC-SHA256 of the body,
+	// keyed with the webhook's secret.
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	// DeliveryHeader holds the event id, which retries repeat so that
+	// endpoints can drop duplicates.
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+// Defaults of a new Dispatcher.
+const (
+	DefaultMaxAttempts  = 5
+	DefaultBackoff      = time.Second
+	DefaultDisableAfter = 5
+	DefaultTimeout      = 10 * time.Second
+)
+
+// storageTimeout bounds each storage call made while delivering.
+const storageTimeout = 5 * time.Second
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	Id        string    `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// Sign returns the SignatureHeader value of body for secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher delivers events in the background. An attempt that fails
+// with a network error, a 408, a 429 or a 5xx status is retried after
+// Backoff, doubling each time, up to MaxAttempts attempts; other statuses
+// fail the delivery at once. Every attempt is logged, and a webhook whose
+// last DisableAfter deliveries all failed is disabled.
+type Dispatcher struct {
+	Client       *http.Client
+	MaxAttempts  int
+	Backoff      time.Duration
+	DisableAfter int
+
+	webhooks   repository.WebhookRepository
+	deliveries repository.DeliveryRepository
+	now        func() time.Time
+	wg         sync.WaitGroup
+}
+
+func NewDispatcher(webhooks repository.WebhookRepository, deliveries repository.DeliveryRepository) *Dispatcher {
+	return &Dispatcher{
+		Client:       &http.Client{Timeout: DefaultTimeout},
+		MaxAttempts:  DefaultMaxAttempts,
+		Backoff:      DefaultBackoff,
+		DisableAfter: DefaultDisableAfter,
+		webhooks:     webhooks,
+		deliveries:   deliveries,
+		now:          time.Now,
+	}
+}
+
+// Publish sends event, with data as its payload, to the active webhooks of
+// owner that subscribe to it. It returns at once; deliveries that are
+// still being retried when the process exits are lost.
+func (d *Dispatcher) Publish(owner *bson.ObjectID, event string, data any) {
+	payload := Payload{
+		Id:        bson.NewObjectID().Hex(),
+		Event:     event,
+		CreatedAt: d.now().UTC(),
+		Data:      data,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("Error encoding webhook payload:", err)
+		return
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		defer cancel()
+
+		webhooks, err := d.webhooks.List(ctx, owner)
+		if err != nil {
+			log.Println("Error fetching webhooks:", err)
+			return
+		}
+
+		for _, webhook := range webhooks {
+			if webhook.Active && webhook.Subscribes(event) {
+				d.wg.Add(1)
+				go d.deliver(webhook.Id, payload, body)
+			}
+		}
+	}()
+}
+
+// Wait blocks until every delivery under way is done, retries included.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// deliver makes the attempts of one delivery. The webhook is fetched
+// again before each one, so that a webhook deleted, disabled or given a
+// new secret meanwhile is respected.
+func (d *Dispatcher) deliver(id bson.ObjectID, payload Payload, body []byte) {
+	defer d.wg.Done()
+
+	for attempt := 1; attempt <= d.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(d.Backoff << (attempt - 2))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		webhook, err := d.webhooks.Get(ctx, id)
+		cancel()
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && !webhook.Active) {
+			return
+		}
+		if err != nil {
+			log.Println("Error fetching webhook:", err)
+			continue
+		}
+
+		delivery, retry := d.attempt(webhook, payload, body, attempt)
+		d.store(func(ctx context.Context) error { return d.deliveries.Append(ctx, delivery) })
+
+		if delivery.Succeeded || !retry {
+			d.store(func(ctx context.Context) error {
+				return d.webhooks.RecordResult(ctx, id, delivery.Succeeded, d.DisableAfter, d.now().UTC())
+			})
+			return
+		}
+	}
+
+	d.store(func(ctx context.Context) error {
+		return d.webhooks.RecordResult(ctx, id, false, d.DisableAfter, d.now().UTC())
+	})
+}
+
+// attempt posts body to the webhook once and reports whether a failure
+// may be retried.
+func (d *Dispatcher) attempt(webhook models.Webhook, payload Payload, body []byte, attempt int) (models.Delivery, bool) {
+	delivery := models.Delivery{
+		WebhookId: webhook.Id,
+		EventId:   payload.Id,
+		Event:     payload.Event,
+		Attempt:   attempt,
+		At:        d.now().UTC(),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-rest-api-webhooks")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, payload.Id)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+
+	start := time.Now()
+	resp, err := d.Client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, true
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Succeeded = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Succeeded {
+		delivery.Error = resp.Status
+	}
+
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return delivery, retry
+}
+
+// store runs a storage call of a delivery, logging failures: they must not
+// stop the delivery itself.
+func (d *Dispatcher) store(call func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+
+	if err := call(ctx); err != nil {
+		log.Println("Error recording webhook delivery:", err)
+	}
+}
@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/todo-rest-api/models"
+	"example.com/todo-rest-api/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endpoint records the requests it receives and answers with the given
+// statuses in turn, then 200.
+type endpoint struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.requests = append(e.requests, r)
+	e.bodies = append(e.bodies, body)
+
+	status := http.StatusOK
+	if len(e.statuses) > 0 {
+		status, e.statuses = e.statuses[0], e.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func setup(t *testing.T, statuses ...int) (*Dispatcher, *endpoint, models.Webhook) {
+	e := &endpoint{statuses: statuses}
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	webhooks := repository.NewMemoryWebhookRepository()
+	d := NewDispatcher(webhooks, repository.NewMemoryDeliveryRepository())
+	d.Backoff = time.Millisecond
+
+	hook, err := webhooks.Insert(context.Background(), models.Webhook{URL: server.URL, Secret: "s3cret", Active: true})
+	require.NoError(t, err)
+
+	return d, e, hook
+}
+
+func (d *Dispatcher) log(t *testing.T, hook models.Webhook) []models.Delivery {
+	deliveries, err := d.deliveries.List(context.Background(), hook.Id, 100)
+	require.NoError(t, err)
+	return deliveries
+}
+
+func TestPublishSigns(t *testing.T) {
+	d, e, hook := setup(t)
+
+	d.Publish(nil, EventTaskCreated, map[string]string{"description": "Buy milk"})
+	d.Wait()
+
+	require.Len(t, e.requests, 1)
+	req := e.requests[0]
+	assert.Equal(t, EventTaskCreated, req.Header.Get(EventHeader))
+	assert.NotEmpty(t, req.Header.Get(DeliveryHeader))
+	assert.Equal(t, Sign(hook.Secret, e.bodies[0]), req.Header.Get(SignatureHeader))
+	assert.Contains(t, string(e.bodies[0]), `"data":{"description":"Buy milk"}`)
+
+	deliveries := d.log(t, hook)
+	require.Len(t, deliveries, 1)
+	assert.True(t, deliveries[0].Succeeded)
+	assert.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+}
+
+func TestPublishRetries(t *testing.T) {
+	d, e, hook := setup(t, http.StatusServiceUnavailable, http.StatusInternalServerError)
+
+	d.Publish(nil, EventTaskDeleted, nil)
+	d.Wait()
+
+	require.Len(t, e.requests, 3)
+	// Retries repeat the event id.
+	assert.Equal(t, e.requests[0].Header.Get(DeliveryHeader), e.requests[2].Header.Get(DeliveryHeader))
+
+	deliveries := d.log(t, hook)
+	require.Len(t, deliveries, 3)
+	assert.Equal(t, 3, deliveries[0].Attempt)
+	assert.True(t, deliveries[0].Succeeded)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[2].StatusCode)
+	assert.False(t, deliveries[2].Succeeded)
+}
+
+func TestPublishGivesUpAndDisables(t *testing.T) {
+	d, e, hook := setup(t, http.StatusBadRequest, http.StatusGone)
+	d.DisableAfter = 2
+
+	// Client errors are not retried.
+	d.Publish(nil, EventTaskUpdated, nil)
+	d.Wait()
+	assert.Len(t, e.requests, 1)
+
+	stored, err := d.webhooks.Get(context.Background(), hook.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Failures)
+	assert.True(t, stored.Active)
+
+	d.Publish(nil, EventTaskUpdated, nil)
+	d.Wait()
+
+	stored, err = d.webhooks.Get(context.Background(), hook.Id)
+	require.NoError(t, err)
+	assert.False(t, stored.Active)
+	assert.NotNil(t, stored.DisabledAt)
+
+	// Disabled webhooks get nothing.
+	d.Publish(nil, EventTaskUpdated, nil)
+	d.Wait()
+	assert.Len(t, e.requests, 2)
+}
+
+func TestPublishFiltersEvents(t *testing.T) {
+	d, e, hook := setup(t)
+	hook.Events = []string{EventTasksCleared}
+	require.NoError(t, d.webhooks.Update(context.Background(), hook))
+
+	d.Publish(nil, EventTaskCreated, nil)
+	d.Publish(nil, EventTasksCleared, nil)
+	d.Wait()
+
+	require.Len(t, e.requests, 1)
+	assert.Equal(t, EventTasksCleared, e.requests[0].Header.Get(EventHeader))
+}